@@ -8,11 +8,82 @@ import (
 	"strconv"
 )
 
+type Activity struct {
+	Sessions []*Session `json:"Sessions"`
+	Locks    []*Lock    `json:"Locks"`
+}
+
+type Assertion struct {
+	Name       string  `json:"Name"`
+	Schema     string  `json:"Schema"`
+	Table      string  `json:"Table"`
+	Condition  *string `json:"Condition,omitempty"`
+	Column     *string `json:"Column,omitempty"`
+	References *string `json:"References,omitempty"`
+}
+
+type AssertionResult struct {
+	Assertion  *Assertion  `json:"Assertion"`
+	Violations int         `json:"Violations"`
+	Sample     *RowsResult `json:"Sample,omitempty"`
+	Error      *string     `json:"Error,omitempty"`
+}
+
+type AssetDependency struct {
+	Type   string            `json:"Type"`
+	ID     string            `json:"ID"`
+	Name   string            `json:"Name"`
+	Tables []*TableReference `json:"Tables"`
+}
+
+type BatchUpdateResponse struct {
+	AffectedRows int `json:"AffectedRows"`
+}
+
 type Column struct {
 	Type string `json:"Type"`
 	Name string `json:"Name"`
 }
 
+type ColumnLineage struct {
+	Column  string          `json:"Column"`
+	Sources []*ColumnSource `json:"Sources"`
+}
+
+type ColumnSource struct {
+	Schema string `json:"Schema"`
+	Table  string `json:"Table"`
+	Column string `json:"Column"`
+}
+
+type DatabaseGrant struct {
+	Privilege string `json:"Privilege"`
+	Schema    string `json:"Schema"`
+	Object    string `json:"Object"`
+	Grantable bool   `json:"Grantable"`
+}
+
+type DatabaseUser struct {
+	Name      string           `json:"Name"`
+	Host      string           `json:"Host"`
+	CanLogin  bool             `json:"CanLogin"`
+	Superuser bool             `json:"Superuser"`
+	MemberOf  []string         `json:"MemberOf"`
+	Grants    []*DatabaseGrant `json:"Grants"`
+}
+
+type FormatOptions struct {
+	KeywordCase *KeywordCase `json:"KeywordCase,omitempty"`
+	IndentWidth *int         `json:"IndentWidth,omitempty"`
+}
+
+type GrantInput struct {
+	Privilege string  `json:"Privilege"`
+	Schema    *string `json:"Schema,omitempty"`
+	Object    *string `json:"Object,omitempty"`
+	Grantable *bool   `json:"Grantable,omitempty"`
+}
+
 type GraphUnit struct {
 	Unit      *StorageUnit             `json:"Unit"`
 	Relations []*GraphUnitRelationship `json:"Relations"`
@@ -23,20 +94,89 @@ type GraphUnitRelationship struct {
 	Relationship GraphUnitRelationshipType `json:"Relationship"`
 }
 
+type InferredField struct {
+	Path       string   `json:"Path"`
+	Types      []string `json:"Types"`
+	Occurrence float64  `json:"Occurrence"`
+}
+
+type JoinSuggestion struct {
+	Schema string `json:"Schema"`
+	Table  string `json:"Table"`
+	Alias  string `json:"Alias"`
+	Clause string `json:"Clause"`
+}
+
+type LintIssue struct {
+	Severity LintSeverity `json:"Severity"`
+	Rule     LintRule     `json:"Rule"`
+	Message  string       `json:"Message"`
+	Line     int          `json:"Line"`
+	Column   int          `json:"Column"`
+}
+
+type Lock struct {
+	SessionID string `json:"SessionID"`
+	Type      string `json:"Type"`
+	Object    string `json:"Object"`
+	Mode      string `json:"Mode"`
+	Granted   bool   `json:"Granted"`
+}
+
 type LoginCredentials struct {
-	Type     string `json:"Type"`
-	Hostname string `json:"Hostname"`
-	Username string `json:"Username"`
-	Password string `json:"Password"`
-	Database string `json:"Database"`
+	Type     string         `json:"Type"`
+	Hostname string         `json:"Hostname"`
+	Username string         `json:"Username"`
+	Password string         `json:"Password"`
+	Database string         `json:"Database"`
+	Advanced []*RecordInput `json:"Advanced,omitempty"`
+}
+
+type MaterializedView struct {
+	Name        string  `json:"Name"`
+	LastRefresh *string `json:"LastRefresh,omitempty"`
+	Populated   bool    `json:"Populated"`
+	Concurrent  bool    `json:"Concurrent"`
 }
 
 type Mutation struct {
 }
 
+type PIIFinding struct {
+	StorageUnit string  `json:"StorageUnit"`
+	Column      string  `json:"Column"`
+	Kind        PIIKind `json:"Kind"`
+	Confidence  float64 `json:"Confidence"`
+	Sampled     int     `json:"Sampled"`
+}
+
 type Query struct {
 }
 
+type QueryOptions struct {
+	Timeout      *string `json:"Timeout,omitempty"`
+	MaxRows      *int    `json:"MaxRows,omitempty"`
+	NoCache      *bool   `json:"NoCache,omitempty"`
+	MaxCellBytes *int    `json:"MaxCellBytes,omitempty"`
+}
+
+type QuerySnapshot struct {
+	RanAt      string     `json:"RanAt"`
+	DurationMs int        `json:"DurationMs"`
+	RowCount   int        `json:"RowCount"`
+	Checksum   string     `json:"Checksum"`
+	Changed    bool       `json:"Changed"`
+	Error      *string    `json:"Error,omitempty"`
+	Columns    []*Column  `json:"Columns"`
+	Rows       [][]string `json:"Rows"`
+}
+
+type QueryVariable struct {
+	Name  string             `json:"Name"`
+	Type  *QueryVariableType `json:"Type,omitempty"`
+	Value string             `json:"Value"`
+}
+
 type Record struct {
 	Key   string `json:"Key"`
 	Value string `json:"Value"`
@@ -47,10 +187,105 @@ type RecordInput struct {
 	Value string `json:"Value"`
 }
 
+type RecycledItem struct {
+	ID        string `json:"ID"`
+	Type      string `json:"Type"`
+	Name      string `json:"Name"`
+	DeletedAt string `json:"DeletedAt"`
+	ExpiresAt string `json:"ExpiresAt"`
+}
+
+type Routine struct {
+	Name       string             `json:"Name"`
+	Type       string             `json:"Type"`
+	ReturnType string             `json:"ReturnType"`
+	Arguments  []*RoutineArgument `json:"Arguments"`
+	Definition string             `json:"Definition"`
+}
+
+type RoutineArgument struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"`
+	Mode string `json:"Mode"`
+}
+
+type RowCount struct {
+	Count     int  `json:"Count"`
+	Estimated bool `json:"Estimated"`
+}
+
 type RowsResult struct {
-	Columns       []*Column  `json:"Columns"`
-	Rows          [][]string `json:"Rows"`
-	DisableUpdate bool       `json:"DisableUpdate"`
+	Columns        []*Column        `json:"Columns"`
+	Rows           [][]string       `json:"Rows"`
+	DisableUpdate  bool             `json:"DisableUpdate"`
+	Warnings       []string         `json:"Warnings"`
+	TruncatedCells []*TruncatedCell `json:"TruncatedCells"`
+}
+
+type ScheduledQuery struct {
+	ID           string  `json:"ID"`
+	Name         string  `json:"Name"`
+	Schedule     string  `json:"Schedule"`
+	Query        string  `json:"Query"`
+	StoreResults bool    `json:"StoreResults"`
+	WebhookURL   *string `json:"WebhookURL,omitempty"`
+	Enabled      bool    `json:"Enabled"`
+	LastRun      *string `json:"LastRun,omitempty"`
+	NextRun      *string `json:"NextRun,omitempty"`
+	LastError    *string `json:"LastError,omitempty"`
+}
+
+type SchemaChange struct {
+	Change string  `json:"Change"`
+	Object string  `json:"Object"`
+	Table  string  `json:"Table"`
+	Name   string  `json:"Name"`
+	Before *string `json:"Before,omitempty"`
+	After  *string `json:"After,omitempty"`
+}
+
+type SearchHit struct {
+	StorageUnit string    `json:"StorageUnit"`
+	Column      string    `json:"Column"`
+	Row         []*Record `json:"Row"`
+}
+
+type Session struct {
+	ID               string   `json:"ID"`
+	User             string   `json:"User"`
+	Database         string   `json:"Database"`
+	Client           string   `json:"Client"`
+	State            string   `json:"State"`
+	Query            string   `json:"Query"`
+	TransactionStart *string  `json:"TransactionStart,omitempty"`
+	QueryStart       *string  `json:"QueryStart,omitempty"`
+	BlockedBy        []string `json:"BlockedBy"`
+}
+
+type Setting struct {
+	Key         string       `json:"Key"`
+	Type        SettingType  `json:"Type"`
+	Value       string       `json:"Value"`
+	Default     string       `json:"Default"`
+	Scope       SettingScope `json:"Scope"`
+	Description string       `json:"Description"`
+	Values      []string     `json:"Values"`
+}
+
+type SlowQuery struct {
+	StartedAt  string  `json:"StartedAt"`
+	DurationMs int     `json:"DurationMs"`
+	Caller     string  `json:"Caller"`
+	Method     string  `json:"Method"`
+	Statement  string  `json:"Statement"`
+	Plan       *string `json:"Plan,omitempty"`
+}
+
+type SortCondition struct {
+	Column    string         `json:"Column"`
+	Function  *SortFunction  `json:"Function,omitempty"`
+	Direction *SortDirection `json:"Direction,omitempty"`
+	Nulls     *NullsOrder    `json:"Nulls,omitempty"`
 }
 
 type StatusResponse struct {
@@ -62,14 +297,44 @@ type StorageUnit struct {
 	Attributes []*Record `json:"Attributes"`
 }
 
+type TableReference struct {
+	Schema string `json:"Schema"`
+	Table  string `json:"Table"`
+}
+
+type TenantUsage struct {
+	Name              string `json:"Name"`
+	QueriesPerMinute  int    `json:"QueriesPerMinute"`
+	QueriesLastMinute int    `json:"QueriesLastMinute"`
+	RowsPerDay        int    `json:"RowsPerDay"`
+	RowsToday         int    `json:"RowsToday"`
+}
+
+type TruncatedCell struct {
+	Row    int `json:"Row"`
+	Column int `json:"Column"`
+	Size   int `json:"Size"`
+}
+
+type VersionInfo struct {
+	Version         string  `json:"Version"`
+	Commit          string  `json:"Commit"`
+	LatestVersion   *string `json:"LatestVersion,omitempty"`
+	UpdateAvailable bool    `json:"UpdateAvailable"`
+	AssetsError     *string `json:"AssetsError,omitempty"`
+}
+
 type DatabaseType string
 
 const (
-	DatabaseTypePostgres DatabaseType = "Postgres"
-	DatabaseTypeMySQL    DatabaseType = "MySQL"
-	DatabaseTypeSqlite3  DatabaseType = "Sqlite3"
-	DatabaseTypeMongoDb  DatabaseType = "MongoDB"
-	DatabaseTypeRedis    DatabaseType = "Redis"
+	DatabaseTypePostgres  DatabaseType = "Postgres"
+	DatabaseTypeMySQL     DatabaseType = "MySQL"
+	DatabaseTypeSqlite3   DatabaseType = "Sqlite3"
+	DatabaseTypeMongoDb   DatabaseType = "MongoDB"
+	DatabaseTypeRedis     DatabaseType = "Redis"
+	DatabaseTypeSnowflake DatabaseType = "Snowflake"
+	DatabaseTypeBigQuery  DatabaseType = "BigQuery"
+	DatabaseTypeOracle    DatabaseType = "Oracle"
 )
 
 var AllDatabaseType = []DatabaseType{
@@ -78,11 +343,14 @@ var AllDatabaseType = []DatabaseType{
 	DatabaseTypeSqlite3,
 	DatabaseTypeMongoDb,
 	DatabaseTypeRedis,
+	DatabaseTypeSnowflake,
+	DatabaseTypeBigQuery,
+	DatabaseTypeOracle,
 }
 
 func (e DatabaseType) IsValid() bool {
 	switch e {
-	case DatabaseTypePostgres, DatabaseTypeMySQL, DatabaseTypeSqlite3, DatabaseTypeMongoDb, DatabaseTypeRedis:
+	case DatabaseTypePostgres, DatabaseTypeMySQL, DatabaseTypeSqlite3, DatabaseTypeMongoDb, DatabaseTypeRedis, DatabaseTypeSnowflake, DatabaseTypeBigQuery, DatabaseTypeOracle:
 		return true
 	}
 	return false
@@ -109,6 +377,47 @@ func (e DatabaseType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type DiagramFormat string
+
+const (
+	DiagramFormatDot     DiagramFormat = "DOT"
+	DiagramFormatMermaid DiagramFormat = "Mermaid"
+)
+
+var AllDiagramFormat = []DiagramFormat{
+	DiagramFormatDot,
+	DiagramFormatMermaid,
+}
+
+func (e DiagramFormat) IsValid() bool {
+	switch e {
+	case DiagramFormatDot, DiagramFormatMermaid:
+		return true
+	}
+	return false
+}
+
+func (e DiagramFormat) String() string {
+	return string(e)
+}
+
+func (e *DiagramFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DiagramFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DiagramFormat", str)
+	}
+	return nil
+}
+
+func (e DiagramFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type GraphUnitRelationshipType string
 
 const (
@@ -155,3 +464,537 @@ func (e *GraphUnitRelationshipType) UnmarshalGQL(v interface{}) error {
 func (e GraphUnitRelationshipType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
+
+type KeywordCase string
+
+const (
+	KeywordCaseUpper    KeywordCase = "Upper"
+	KeywordCaseLower    KeywordCase = "Lower"
+	KeywordCasePreserve KeywordCase = "Preserve"
+)
+
+var AllKeywordCase = []KeywordCase{
+	KeywordCaseUpper,
+	KeywordCaseLower,
+	KeywordCasePreserve,
+}
+
+func (e KeywordCase) IsValid() bool {
+	switch e {
+	case KeywordCaseUpper, KeywordCaseLower, KeywordCasePreserve:
+		return true
+	}
+	return false
+}
+
+func (e KeywordCase) String() string {
+	return string(e)
+}
+
+func (e *KeywordCase) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = KeywordCase(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid KeywordCase", str)
+	}
+	return nil
+}
+
+func (e KeywordCase) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LintRule string
+
+const (
+	LintRuleUnterminated          LintRule = "Unterminated"
+	LintRuleUnbalancedParenthesis LintRule = "UnbalancedParenthesis"
+	LintRuleUnknownStatement      LintRule = "UnknownStatement"
+	LintRuleTrailingComma         LintRule = "TrailingComma"
+	LintRuleDialect               LintRule = "Dialect"
+	LintRuleUnknownTable          LintRule = "UnknownTable"
+	LintRuleUnknownColumn         LintRule = "UnknownColumn"
+	LintRuleSelectStar            LintRule = "SelectStar"
+	LintRuleMissingWhere          LintRule = "MissingWhere"
+)
+
+var AllLintRule = []LintRule{
+	LintRuleUnterminated,
+	LintRuleUnbalancedParenthesis,
+	LintRuleUnknownStatement,
+	LintRuleTrailingComma,
+	LintRuleDialect,
+	LintRuleUnknownTable,
+	LintRuleUnknownColumn,
+	LintRuleSelectStar,
+	LintRuleMissingWhere,
+}
+
+func (e LintRule) IsValid() bool {
+	switch e {
+	case LintRuleUnterminated, LintRuleUnbalancedParenthesis, LintRuleUnknownStatement, LintRuleTrailingComma, LintRuleDialect, LintRuleUnknownTable, LintRuleUnknownColumn, LintRuleSelectStar, LintRuleMissingWhere:
+		return true
+	}
+	return false
+}
+
+func (e LintRule) String() string {
+	return string(e)
+}
+
+func (e *LintRule) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LintRule(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LintRule", str)
+	}
+	return nil
+}
+
+func (e LintRule) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "Error"
+	LintSeverityWarning LintSeverity = "Warning"
+)
+
+var AllLintSeverity = []LintSeverity{
+	LintSeverityError,
+	LintSeverityWarning,
+}
+
+func (e LintSeverity) IsValid() bool {
+	switch e {
+	case LintSeverityError, LintSeverityWarning:
+		return true
+	}
+	return false
+}
+
+func (e LintSeverity) String() string {
+	return string(e)
+}
+
+func (e *LintSeverity) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LintSeverity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LintSeverity", str)
+	}
+	return nil
+}
+
+func (e LintSeverity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type MaintenanceAction string
+
+const (
+	MaintenanceActionVacuum         MaintenanceAction = "Vacuum"
+	MaintenanceActionIntegrityCheck MaintenanceAction = "IntegrityCheck"
+)
+
+var AllMaintenanceAction = []MaintenanceAction{
+	MaintenanceActionVacuum,
+	MaintenanceActionIntegrityCheck,
+}
+
+func (e MaintenanceAction) IsValid() bool {
+	switch e {
+	case MaintenanceActionVacuum, MaintenanceActionIntegrityCheck:
+		return true
+	}
+	return false
+}
+
+func (e MaintenanceAction) String() string {
+	return string(e)
+}
+
+func (e *MaintenanceAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MaintenanceAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MaintenanceAction", str)
+	}
+	return nil
+}
+
+func (e MaintenanceAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NullsOrder string
+
+const (
+	NullsOrderFirst NullsOrder = "First"
+	NullsOrderLast  NullsOrder = "Last"
+)
+
+var AllNullsOrder = []NullsOrder{
+	NullsOrderFirst,
+	NullsOrderLast,
+}
+
+func (e NullsOrder) IsValid() bool {
+	switch e {
+	case NullsOrderFirst, NullsOrderLast:
+		return true
+	}
+	return false
+}
+
+func (e NullsOrder) String() string {
+	return string(e)
+}
+
+func (e *NullsOrder) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NullsOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NullsOrder", str)
+	}
+	return nil
+}
+
+func (e NullsOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PIIKind string
+
+const (
+	PIIKindEmail      PIIKind = "Email"
+	PIIKindPhone      PIIKind = "Phone"
+	PIIKindNationalID PIIKind = "NationalID"
+	PIIKindCreditCard PIIKind = "CreditCard"
+)
+
+var AllPIIKind = []PIIKind{
+	PIIKindEmail,
+	PIIKindPhone,
+	PIIKindNationalID,
+	PIIKindCreditCard,
+}
+
+func (e PIIKind) IsValid() bool {
+	switch e {
+	case PIIKindEmail, PIIKindPhone, PIIKindNationalID, PIIKindCreditCard:
+		return true
+	}
+	return false
+}
+
+func (e PIIKind) String() string {
+	return string(e)
+}
+
+func (e *PIIKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PIIKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PIIKind", str)
+	}
+	return nil
+}
+
+func (e PIIKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type QueryVariableType string
+
+const (
+	QueryVariableTypeText       QueryVariableType = "Text"
+	QueryVariableTypeNumber     QueryVariableType = "Number"
+	QueryVariableTypeBoolean    QueryVariableType = "Boolean"
+	QueryVariableTypeDate       QueryVariableType = "Date"
+	QueryVariableTypeIdentifier QueryVariableType = "Identifier"
+	QueryVariableTypeRaw        QueryVariableType = "Raw"
+)
+
+var AllQueryVariableType = []QueryVariableType{
+	QueryVariableTypeText,
+	QueryVariableTypeNumber,
+	QueryVariableTypeBoolean,
+	QueryVariableTypeDate,
+	QueryVariableTypeIdentifier,
+	QueryVariableTypeRaw,
+}
+
+func (e QueryVariableType) IsValid() bool {
+	switch e {
+	case QueryVariableTypeText, QueryVariableTypeNumber, QueryVariableTypeBoolean, QueryVariableTypeDate, QueryVariableTypeIdentifier, QueryVariableTypeRaw:
+		return true
+	}
+	return false
+}
+
+func (e QueryVariableType) String() string {
+	return string(e)
+}
+
+func (e *QueryVariableType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = QueryVariableType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid QueryVariableType", str)
+	}
+	return nil
+}
+
+func (e QueryVariableType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettingScope string
+
+const (
+	SettingScopeGlobal     SettingScope = "Global"
+	SettingScopeConnection SettingScope = "Connection"
+	SettingScopeUser       SettingScope = "User"
+)
+
+var AllSettingScope = []SettingScope{
+	SettingScopeGlobal,
+	SettingScopeConnection,
+	SettingScopeUser,
+}
+
+func (e SettingScope) IsValid() bool {
+	switch e {
+	case SettingScopeGlobal, SettingScopeConnection, SettingScopeUser:
+		return true
+	}
+	return false
+}
+
+func (e SettingScope) String() string {
+	return string(e)
+}
+
+func (e *SettingScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettingScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettingScope", str)
+	}
+	return nil
+}
+
+func (e SettingScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettingType string
+
+const (
+	SettingTypeString   SettingType = "String"
+	SettingTypeInt      SettingType = "Int"
+	SettingTypeBool     SettingType = "Bool"
+	SettingTypeDuration SettingType = "Duration"
+)
+
+var AllSettingType = []SettingType{
+	SettingTypeString,
+	SettingTypeInt,
+	SettingTypeBool,
+	SettingTypeDuration,
+}
+
+func (e SettingType) IsValid() bool {
+	switch e {
+	case SettingTypeString, SettingTypeInt, SettingTypeBool, SettingTypeDuration:
+		return true
+	}
+	return false
+}
+
+func (e SettingType) String() string {
+	return string(e)
+}
+
+func (e *SettingType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettingType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettingType", str)
+	}
+	return nil
+}
+
+func (e SettingType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SnippetLanguage string
+
+const (
+	SnippetLanguageCurl   SnippetLanguage = "Curl"
+	SnippetLanguageGo     SnippetLanguage = "Go"
+	SnippetLanguagePython SnippetLanguage = "Python"
+)
+
+var AllSnippetLanguage = []SnippetLanguage{
+	SnippetLanguageCurl,
+	SnippetLanguageGo,
+	SnippetLanguagePython,
+}
+
+func (e SnippetLanguage) IsValid() bool {
+	switch e {
+	case SnippetLanguageCurl, SnippetLanguageGo, SnippetLanguagePython:
+		return true
+	}
+	return false
+}
+
+func (e SnippetLanguage) String() string {
+	return string(e)
+}
+
+func (e *SnippetLanguage) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SnippetLanguage(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SnippetLanguage", str)
+	}
+	return nil
+}
+
+func (e SnippetLanguage) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "Asc"
+	SortDirectionDesc SortDirection = "Desc"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortFunction string
+
+const (
+	SortFunctionLower  SortFunction = "Lower"
+	SortFunctionUpper  SortFunction = "Upper"
+	SortFunctionLength SortFunction = "Length"
+	SortFunctionAbs    SortFunction = "Abs"
+	SortFunctionTrim   SortFunction = "Trim"
+)
+
+var AllSortFunction = []SortFunction{
+	SortFunctionLower,
+	SortFunctionUpper,
+	SortFunctionLength,
+	SortFunctionAbs,
+	SortFunctionTrim,
+}
+
+func (e SortFunction) IsValid() bool {
+	switch e {
+	case SortFunctionLower, SortFunctionUpper, SortFunctionLength, SortFunctionAbs, SortFunctionTrim:
+		return true
+	}
+	return false
+}
+
+func (e SortFunction) String() string {
+	return string(e)
+}
+
+func (e *SortFunction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortFunction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortFunction", str)
+	}
+	return nil
+}
+
+func (e SortFunction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
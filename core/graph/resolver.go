@@ -1,5 +1,35 @@
 package graph
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/graph/model"
+	"github.com/clidey/whodb/core/src"
+	"github.com/clidey/whodb/core/src/assertion"
+	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/recyclebin"
+	"github.com/clidey/whodb/core/src/scheduledquery"
+	"github.com/clidey/whodb/core/src/scheduler"
+	"github.com/clidey/whodb/core/src/schemadiff"
+	"github.com/clidey/whodb/core/src/settings"
+	"github.com/clidey/whodb/core/src/template"
+	"github.com/clidey/whodb/core/src/validation"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Storage unit stats are only hints, so they are reused for a while rather than
+// queried every time a table list is shown.
+var storageUnitStatsCache = cache.New[map[string][]engine.Record]("storage-unit-stats", 5*time.Minute)
+
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
@@ -7,3 +37,385 @@ package graph
 //go:generate go run github.com/99designs/gqlgen generate
 
 type Resolver struct{}
+
+// applyQueryOptions lets a single query override the connection's query defaults.
+func applyQueryOptions(config *engine.PluginConfig, options *model.QueryOptions) error {
+	if options == nil {
+		return nil
+	}
+	// Options can only tighten the configured limits, where zero means no limit.
+	if options.Timeout != nil {
+		timeout, err := time.ParseDuration(*options.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+		if timeout < 0 {
+			return errors.New("invalid timeout: must not be negative")
+		}
+		if timeout > 0 && (config.QueryTimeout == 0 || timeout < config.QueryTimeout) {
+			config.QueryTimeout = timeout
+		}
+	}
+	if options.MaxRows != nil {
+		if *options.MaxRows < 0 {
+			return errors.New("invalid max rows: must not be negative")
+		}
+		if *options.MaxRows > 0 && (config.MaxRows == 0 || *options.MaxRows < config.MaxRows) {
+			config.MaxRows = *options.MaxRows
+		}
+	}
+	if options.NoCache != nil && *options.NoCache {
+		config.ResultCacheTTL = 0
+	}
+	return nil
+}
+
+// renderQueryVariables substitutes the template variables of a query. Queries run without
+// variables are left as written, so text that only looks like a placeholder is kept.
+func renderQueryVariables(typeArg model.DatabaseType, query string, variables []*model.QueryVariable) (string, error) {
+	if len(variables) == 0 {
+		return query, nil
+	}
+	values := []template.Variable{}
+	for _, variable := range variables {
+		value := template.Variable{Name: variable.Name, Value: variable.Value}
+		if variable.Type != nil {
+			value.Type = template.Type(*variable.Type)
+		}
+		values = append(values, value)
+	}
+	return template.Render(engine.DatabaseType(typeArg), query, values)
+}
+
+func getActivityModel(activity *engine.Activity) *model.Activity {
+	activityModel := &model.Activity{Sessions: []*model.Session{}, Locks: []*model.Lock{}}
+	for _, session := range activity.Sessions {
+		sessionModel := &model.Session{
+			ID:        session.ID,
+			User:      session.User,
+			Database:  session.Database,
+			Client:    session.Client,
+			State:     session.State,
+			Query:     session.Query,
+			BlockedBy: append([]string{}, session.BlockedBy...),
+		}
+		if !session.TransactionStart.IsZero() {
+			transactionStart := session.TransactionStart.Format(time.RFC3339)
+			sessionModel.TransactionStart = &transactionStart
+		}
+		if !session.QueryStart.IsZero() {
+			queryStart := session.QueryStart.Format(time.RFC3339)
+			sessionModel.QueryStart = &queryStart
+		}
+		activityModel.Sessions = append(activityModel.Sessions, sessionModel)
+	}
+	for _, lock := range activity.Locks {
+		activityModel.Locks = append(activityModel.Locks, &model.Lock{
+			SessionID: lock.SessionID,
+			Type:      lock.Type,
+			Object:    lock.Object,
+			Mode:      lock.Mode,
+			Granted:   lock.Granted,
+		})
+	}
+	return activityModel
+}
+
+// readableAssertions returns the assertions of the connection on the tables the user can read.
+func readableAssertions(ctx context.Context, typeArg model.DatabaseType, config *engine.PluginConfig, schema *string, storageUnit *string) []assertion.Assertion {
+	if src.MainAssertions == nil {
+		return nil
+	}
+	schemaName, table := "", ""
+	if schema != nil {
+		schemaName = *schema
+	}
+	if storageUnit != nil {
+		table = *storageUnit
+	}
+	readable := []assertion.Assertion{}
+	for _, rule := range src.MainAssertions.For(engine.DatabaseType(typeArg), config, schemaName, table) {
+		if auth.CheckAccess(ctx, rule.Schema, auth.Operation_Read) == nil {
+			readable = append(readable, rule)
+		}
+	}
+	return readable
+}
+
+func getAssertionModel(rule assertion.Assertion) *model.Assertion {
+	assertionModel := &model.Assertion{
+		Name:   rule.Name,
+		Schema: rule.Schema,
+		Table:  rule.Table,
+	}
+	if len(rule.Condition) > 0 {
+		assertionModel.Condition = &rule.Condition
+	} else {
+		assertionModel.Column = &rule.Column
+		assertionModel.References = &rule.References
+	}
+	return assertionModel
+}
+
+func getSchemaChangeModel(change schemadiff.Change) *model.SchemaChange {
+	changeModel := &model.SchemaChange{
+		Change: change.Change,
+		Object: change.Object,
+		Table:  change.Table,
+		Name:   change.Name,
+	}
+	if len(change.Before) > 0 {
+		before := change.Before
+		changeModel.Before = &before
+	}
+	if len(change.After) > 0 {
+		after := change.After
+		changeModel.After = &after
+	}
+	return changeModel
+}
+
+func getAssertionResultModel(result assertion.Result) *model.AssertionResult {
+	resultModel := &model.AssertionResult{
+		Assertion:  getAssertionModel(result.Assertion),
+		Violations: int(result.Violations),
+	}
+	if len(result.Error) > 0 {
+		resultModel.Error = &result.Error
+	}
+	if result.Sample != nil {
+		columns := []*model.Column{}
+		for _, column := range result.Sample.Columns {
+			columns = append(columns, &model.Column{
+				Type: column.Type,
+				Name: column.Name,
+			})
+		}
+		resultModel.Sample = &model.RowsResult{
+			Columns:        columns,
+			Rows:           result.Sample.Rows,
+			DisableUpdate:  result.Sample.DisableUpdate,
+			Warnings:       getWarnings(result.Sample),
+			TruncatedCells: []*model.TruncatedCell{},
+		}
+	}
+	return resultModel
+}
+
+func getGrant(grant model.GrantInput) engine.Grant {
+	result := engine.Grant{Privilege: grant.Privilege}
+	if grant.Schema != nil {
+		result.Schema = *grant.Schema
+	}
+	if grant.Object != nil {
+		result.Object = *grant.Object
+	}
+	if grant.Grantable != nil {
+		result.Grantable = *grant.Grantable
+	}
+	return result
+}
+
+// getSortConditions converts the sort of a Row query, whose enums the engine spells in SQL.
+func getSortConditions(sort []*model.SortCondition) []engine.SortCondition {
+	conditions := []engine.SortCondition{}
+	for _, condition := range sort {
+		sortCondition := engine.SortCondition{Column: condition.Column}
+		if condition.Function != nil {
+			sortCondition.Function = engine.SortFunction(strings.ToUpper(string(*condition.Function)))
+		}
+		if condition.Direction != nil {
+			sortCondition.Direction = engine.SortDirection(strings.ToUpper(string(*condition.Direction)))
+		}
+		if condition.Nulls != nil {
+			sortCondition.Nulls = engine.NullsOrder(strings.ToUpper(string(*condition.Nulls)))
+		}
+		conditions = append(conditions, sortCondition)
+	}
+	return conditions
+}
+
+// withStorageUnitStats appends the cached statistics of each storage unit to its attributes.
+// Stats are best effort: when they cannot be read, the storage units are returned as is.
+func withStorageUnitStats(typeArg model.DatabaseType, config *engine.PluginConfig, schema string, units []engine.StorageUnit) []engine.StorageUnit {
+	key := fmt.Sprintf("%v/%v", settings.TargetFor(string(typeArg), config.Credentials).Connection, schema)
+	stats, err := storageUnitStatsCache.GetOrLoad(key, func() (map[string][]engine.Record, error) {
+		return src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetStorageUnitStats(config, schema)
+	})
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			log.LogFields(log.Fields{
+				"type":   typeArg,
+				"schema": schema,
+			}).Warnf("Unable to read storage unit stats: %v", err)
+		}
+		return units
+	}
+	for i, unit := range units {
+		units[i].Attributes = append(unit.Attributes, stats[unit.Name]...)
+	}
+	return units
+}
+
+// getScheduledQuery returns the scheduled query only if it was created from the current
+// connection by the current user.
+func getScheduledQuery(ctx context.Context, typeArg model.DatabaseType, id string) (scheduler.Job, scheduledquery.Query, error) {
+	job, err := src.MainScheduler.GetJob(id)
+	if err != nil {
+		return job, scheduledquery.Query{}, err
+	}
+	query, err := scheduledquery.GetQuery(job)
+	if err != nil || query.Connection != settings.TargetFor(string(typeArg), auth.GetCredentials(ctx)).Connection || !query.OwnedBy(auth.GetIdentity(ctx)) {
+		return job, query, scheduler.ErrJobNotFound
+	}
+	return job, query, nil
+}
+
+func getScheduledQueryModel(job scheduler.Job, query scheduledquery.Query) *model.ScheduledQuery {
+	scheduledQuery := &model.ScheduledQuery{
+		ID:           job.ID,
+		Name:         job.Name,
+		Schedule:     job.Schedule,
+		Query:        query.Query,
+		StoreResults: query.StoreResults,
+		Enabled:      job.Enabled,
+	}
+	if len(query.WebhookURL) > 0 {
+		scheduledQuery.WebhookURL = &query.WebhookURL
+	}
+	if !job.LastRun.IsZero() {
+		lastRun := job.LastRun.Format(time.RFC3339)
+		scheduledQuery.LastRun = &lastRun
+	}
+	if !job.NextRun.IsZero() {
+		nextRun := job.NextRun.Format(time.RFC3339)
+		scheduledQuery.NextRun = &nextRun
+	}
+	for _, status := range src.MainScheduler.Status() {
+		if status.ID == job.ID && len(status.LastError) > 0 {
+			lastError := status.LastError
+			scheduledQuery.LastError = &lastError
+		}
+	}
+	return scheduledQuery
+}
+
+// validateEdit checks the values of an edit before they are sent to the database and reports
+// each invalid value as its own error, with the column in the error's extensions.
+func validateEdit(typeArg model.DatabaseType, config *engine.PluginConfig, schema string, storageUnit string, values map[string]string) error {
+	err := validation.ValidateEdit(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema, storageUnit, values)
+	var validationErr *validation.Error
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	errorList := gqlerror.List{}
+	for _, field := range validationErr.Fields {
+		errorList = append(errorList, &gqlerror.Error{
+			Message: fmt.Sprintf("%v %v", field.Column, field.Message),
+			Extensions: map[string]interface{}{
+				"code":   "INVALID_VALUE",
+				"column": field.Column,
+			},
+		})
+	}
+	return errorList
+}
+
+func getSlowQueryModel(slowQuery engine.SlowQuery) *model.SlowQuery {
+	caller := slowQuery.Caller
+	if len(caller) == 0 {
+		caller = "GraphQL"
+	}
+	slowQueryModel := &model.SlowQuery{
+		StartedAt:  slowQuery.StartedAt.Format(time.RFC3339),
+		DurationMs: int(slowQuery.Duration.Milliseconds()),
+		Caller:     caller,
+		Method:     slowQuery.Method,
+		Statement:  slowQuery.Statement,
+	}
+	if len(slowQuery.Plan) > 0 {
+		plan := slowQuery.Plan
+		slowQueryModel.Plan = &plan
+	}
+	return slowQueryModel
+}
+
+// getWarnings returns the warnings of a result, which the schema requires to be a list even when empty.
+func getWarnings(rowsResult *engine.GetRowsResult) []string {
+	if rowsResult.Warnings == nil {
+		return []string{}
+	}
+	return rowsResult.Warnings
+}
+
+const assetType_ScheduledQuery = "ScheduledQuery"
+
+// getRecycledItem returns the item of the recycle bin only if it was deleted from the current
+// connection, belonged to the current user, and the user may change the structure of its schema.
+func getRecycledItem(ctx context.Context, typeArg model.DatabaseType, id string) (recyclebin.Item, error) {
+	item, err := src.MainRecycleBin.Get(settings.TargetFor(string(typeArg), auth.GetCredentials(ctx)).Connection, id)
+	if err != nil {
+		return item, err
+	}
+	if item.Owner != identitySubject(auth.GetIdentity(ctx)) {
+		return item, recyclebin.ErrItemNotFound
+	}
+	if err := auth.CheckAccess(ctx, item.Schema, auth.Operation_DDL); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// identitySubject returns the subject of the identity, or nothing when users are not identified.
+func identitySubject(identity *auth.Identity) string {
+	if identity == nil {
+		return ""
+	}
+	return identity.Subject
+}
+
+// restoreRecycledItem puts a deleted asset back as it was, keeping its ID.
+func restoreRecycledItem(item recyclebin.Item) error {
+	switch item.Type {
+	case assetType_ScheduledQuery:
+		removed := scheduledquery.Removed{}
+		if err := json.Unmarshal(item.Payload, &removed); err != nil {
+			return err
+		}
+		if _, err := src.MainScheduler.AddJob(removed.Job); err != nil {
+			return err
+		}
+		return src.MainQueryRunner.RestoreSnapshots(removed.Job.ID, removed.Snapshots)
+	}
+	return fmt.Errorf("cannot restore items of type %v", item.Type)
+}
+
+// referencesTable reports whether any of the tables is the given one. References without a
+// schema match the table in any schema, since the schema they resolve to depends on the session.
+func referencesTable(tables []lineage.Table, schema *string, table string) bool {
+	for _, reference := range tables {
+		if !strings.EqualFold(reference.Table, table) {
+			continue
+		}
+		if schema == nil || len(reference.Schema) == 0 || strings.EqualFold(reference.Schema, *schema) {
+			return true
+		}
+	}
+	return false
+}
+
+func getAssetDependencyModel(assetType string, id string, name string, tables []lineage.Table) *model.AssetDependency {
+	tablesModel := []*model.TableReference{}
+	for _, table := range tables {
+		tablesModel = append(tablesModel, &model.TableReference{
+			Schema: table.Schema,
+			Table:  table.Table,
+		})
+	}
+	return &model.AssetDependency{
+		Type:   assetType,
+		ID:     id,
+		Name:   name,
+		Tables: tablesModel,
+	}
+}
@@ -6,23 +6,52 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/clidey/whodb/core/graph/model"
 	"github.com/clidey/whodb/core/src"
+	"github.com/clidey/whodb/core/src/assertion"
 	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/cell"
 	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/erd"
+	"github.com/clidey/whodb/core/src/joins"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/lint"
+	"github.com/clidey/whodb/core/src/pii"
+	"github.com/clidey/whodb/core/src/recyclebin"
+	"github.com/clidey/whodb/core/src/scheduledquery"
+	"github.com/clidey/whodb/core/src/schemadiff"
+	"github.com/clidey/whodb/core/src/settings"
+	"github.com/clidey/whodb/core/src/snippet"
+	"github.com/clidey/whodb/core/src/template"
+	"github.com/clidey/whodb/core/src/tenant"
 )
 
 // Login is the resolver for the Login field.
 func (r *mutationResolver) Login(ctx context.Context, credentails model.LoginCredentials) (*model.StatusResponse, error) {
+	advanced := []engine.Record{}
+	for _, record := range credentails.Advanced {
+		advanced = append(advanced, engine.Record{
+			Key:   record.Key,
+			Value: record.Value,
+		})
+	}
+	credentials := &engine.Credentials{
+		Hostname: credentails.Hostname,
+		Username: credentails.Username,
+		Password: credentails.Password,
+		Database: credentails.Database,
+		Advanced: advanced,
+	}
+	// Connections the policy does not allow are not even tried.
+	if err := auth.CheckLogin(ctx, credentails.Type, credentials); err != nil {
+		return nil, err
+	}
 	if !src.MainEngine.Choose(engine.DatabaseType(credentails.Type)).IsAvailable(&engine.PluginConfig{
-		Credentials: &engine.Credentials{
-			Hostname: credentails.Hostname,
-			Username: credentails.Username,
-			Password: credentails.Password,
-			Database: credentails.Database,
-		},
+		Credentials: credentials,
 	}) {
 		return nil, errors.New("unauthorized")
 	}
@@ -36,11 +65,18 @@ func (r *mutationResolver) Logout(ctx context.Context) (*model.StatusResponse, e
 
 // UpdateStorageUnit is the resolver for the UpdateStorageUnit field.
 func (r *mutationResolver) UpdateStorageUnit(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, values []*model.RecordInput) (*model.StatusResponse, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Write); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
 	valuesMap := map[string]string{}
 	for _, value := range values {
 		valuesMap[value.Key] = value.Value
 	}
+	if err := validateEdit(typeArg, config, schema, storageUnit, valuesMap); err != nil {
+		return nil, err
+	}
 	status, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).UpdateStorageUnit(config, schema, storageUnit, valuesMap)
 	if err != nil {
 		return nil, err
@@ -50,6 +86,352 @@ func (r *mutationResolver) UpdateStorageUnit(ctx context.Context, typeArg model.
 	}, nil
 }
 
+// BatchUpdateStorageUnit is the resolver for the BatchUpdateStorageUnit field.
+func (r *mutationResolver) BatchUpdateStorageUnit(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, where string, values []*model.RecordInput) (*model.BatchUpdateResponse, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Write); err != nil {
+		return nil, err
+	}
+	if err := auth.CheckFilterAccess(ctx, string(typeArg), schema, storageUnit, where); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	valuesMap := map[string]string{}
+	for _, value := range values {
+		valuesMap[value.Key] = value.Value
+	}
+	if err := validateEdit(typeArg, config, schema, storageUnit, valuesMap); err != nil {
+		return nil, err
+	}
+	affectedRows, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).BatchUpdateStorageUnit(config, schema, storageUnit, where, valuesMap)
+	if err != nil {
+		return nil, err
+	}
+	return &model.BatchUpdateResponse{
+		AffectedRows: int(affectedRows),
+	}, nil
+}
+
+// ExecuteRoutine is the resolver for the ExecuteRoutine field.
+func (r *mutationResolver) ExecuteRoutine(ctx context.Context, typeArg model.DatabaseType, schema string, routine string, arguments []string) (*model.RowsResult, error) {
+	// Routines can modify data, so there is no way to tell a safe call apart.
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Write); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	rowsResult, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).ExecuteRoutine(config, schema, routine, arguments)
+	if err != nil {
+		return nil, err
+	}
+	columns := []*model.Column{}
+	for _, column := range rowsResult.Columns {
+		columns = append(columns, &model.Column{
+			Type: column.Type,
+			Name: column.Name,
+		})
+	}
+	return &model.RowsResult{
+		Columns:        columns,
+		Rows:           rowsResult.Rows,
+		Warnings:       getWarnings(rowsResult),
+		TruncatedCells: []*model.TruncatedCell{},
+	}, nil
+}
+
+// AddScheduledQuery is the resolver for the AddScheduledQuery field.
+func (r *mutationResolver) AddScheduledQuery(ctx context.Context, typeArg model.DatabaseType, name string, schedule string, query string, storeResults *bool, webhookURL *string) (*model.ScheduledQuery, error) {
+	credentials := auth.GetCredentials(ctx)
+	if err := auth.CheckQueryAccess(ctx, query); err != nil {
+		return nil, err
+	}
+	scheduledQuery := scheduledquery.Query{
+		DatabaseType: string(typeArg),
+		Connection:   settings.TargetFor(string(typeArg), credentials).Connection,
+		Credentials:  *credentials,
+		Tenant:       credentials.Tenant,
+		Identity:     auth.GetIdentity(ctx),
+		Query:        query,
+	}
+	if storeResults != nil {
+		scheduledQuery.StoreResults = *storeResults
+	}
+	if webhookURL != nil && len(*webhookURL) > 0 {
+		if err := scheduledquery.ValidateWebhookURL(*webhookURL); err != nil {
+			return nil, err
+		}
+		scheduledQuery.WebhookURL = *webhookURL
+	}
+	job, err := scheduledquery.NewJob(name, schedule, scheduledQuery)
+	if err != nil {
+		return nil, err
+	}
+	addedJob, err := src.MainScheduler.AddJob(job)
+	if err != nil {
+		return nil, err
+	}
+	return getScheduledQueryModel(*addedJob, scheduledQuery), nil
+}
+
+// RemoveScheduledQuery is the resolver for the RemoveScheduledQuery field.
+func (r *mutationResolver) RemoveScheduledQuery(ctx context.Context, typeArg model.DatabaseType, id string) (*model.StatusResponse, error) {
+	job, query, err := getScheduledQuery(ctx, typeArg, id)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := src.MainQueryRunner.Removed(job)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(removed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := src.MainRecycleBin.Add(recyclebin.Item{
+		Type:       assetType_ScheduledQuery,
+		Name:       job.Name,
+		Connection: query.Connection,
+		Owner:      identitySubject(query.Identity),
+		Payload:    payload,
+	}); err != nil {
+		return nil, err
+	}
+	if err := src.MainScheduler.RemoveJob(id); err != nil {
+		return nil, err
+	}
+	if err := src.MainQueryRunner.DeleteSnapshots(id); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// RunScheduledQuery is the resolver for the RunScheduledQuery field.
+func (r *mutationResolver) RunScheduledQuery(ctx context.Context, typeArg model.DatabaseType, id string) (*model.StatusResponse, error) {
+	_, query, err := getScheduledQuery(ctx, typeArg, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckQueryAccess(ctx, query.Query); err != nil {
+		return nil, err
+	}
+	if err := src.MainScheduler.RunNow(id); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// RestoreRecycledItem is the resolver for the RestoreRecycledItem field.
+func (r *mutationResolver) RestoreRecycledItem(ctx context.Context, typeArg model.DatabaseType, id string) (*model.StatusResponse, error) {
+	item, err := getRecycledItem(ctx, typeArg, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := restoreRecycledItem(item); err != nil {
+		return nil, err
+	}
+	if err := src.MainRecycleBin.Remove(item.Connection, id); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// PurgeRecycledItem is the resolver for the PurgeRecycledItem field.
+func (r *mutationResolver) PurgeRecycledItem(ctx context.Context, typeArg model.DatabaseType, id string) (*model.StatusResponse, error) {
+	item, err := getRecycledItem(ctx, typeArg, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := src.MainRecycleBin.Remove(item.Connection, id); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// CreateDatabase is the resolver for the CreateDatabase field.
+func (r *mutationResolver) CreateDatabase(ctx context.Context, typeArg model.DatabaseType, name string) (*model.StatusResponse, error) {
+	if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).CreateDatabase(config, name); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// RunMaintenance is the resolver for the RunMaintenance field.
+func (r *mutationResolver) RunMaintenance(ctx context.Context, typeArg model.DatabaseType, action model.MaintenanceAction) ([]string, error) {
+	// Checking integrity only reads, while vacuuming rewrites the whole database.
+	operation := auth.Operation_Write
+	if action == model.MaintenanceActionIntegrityCheck {
+		operation = auth.Operation_Read
+	}
+	if err := auth.CheckAccess(ctx, "", operation); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	return src.MainEngine.Choose(engine.DatabaseType(typeArg)).RunMaintenance(config, engine.MaintenanceAction(action))
+}
+
+// KillSession is the resolver for the KillSession field.
+func (r *mutationResolver) KillSession(ctx context.Context, typeArg model.DatabaseType, id string) (*model.StatusResponse, error) {
+	// Sessions belong to the whole server, whatever schema they use, and may be anyone's.
+	if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).KillSession(config, id); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// RefreshMaterializedView is the resolver for the RefreshMaterializedView field.
+func (r *mutationResolver) RefreshMaterializedView(ctx context.Context, typeArg model.DatabaseType, schema string, view string, options *model.QueryOptions) (*model.StatusResponse, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Write); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := applyQueryOptions(config, options); err != nil {
+		return nil, err
+	}
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).RefreshMaterializedView(config, schema, view); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// ExpireKeys is the resolver for the ExpireKeys field.
+func (r *mutationResolver) ExpireKeys(ctx context.Context, typeArg model.DatabaseType, pattern string, ttl int, dryRun *bool) ([]string, error) {
+	// Keys are not grouped into schemas, so expiring them needs access to the whole database.
+	operation := auth.Operation_Write
+	if dryRun != nil && *dryRun {
+		operation = auth.Operation_Read
+	}
+	if err := auth.CheckAccess(ctx, "", operation); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	return src.MainEngine.Choose(engine.DatabaseType(typeArg)).ExpireKeys(config, pattern, time.Duration(ttl)*time.Second, dryRun != nil && *dryRun)
+}
+
+// CreateDatabaseUser is the resolver for the CreateDatabaseUser field.
+func (r *mutationResolver) CreateDatabaseUser(ctx context.Context, typeArg model.DatabaseType, name string, password string) (*model.StatusResponse, error) {
+	// Users belong to the whole server, and managing them is as sensitive as changing its structure.
+	if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).CreateDatabaseUser(config, name, password); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// GrantPrivilege is the resolver for the GrantPrivilege field.
+func (r *mutationResolver) GrantPrivilege(ctx context.Context, typeArg model.DatabaseType, user string, grant model.GrantInput) (*model.StatusResponse, error) {
+	// Privileges reach beyond a single schema, and granting them is as sensitive as changing its structure.
+	if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GrantPrivilege(config, user, getGrant(grant)); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// RevokePrivilege is the resolver for the RevokePrivilege field.
+func (r *mutationResolver) RevokePrivilege(ctx context.Context, typeArg model.DatabaseType, user string, grant model.GrantInput) (*model.StatusResponse, error) {
+	if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	if err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).RevokePrivilege(config, user, getGrant(grant)); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// UpdateSetting is the resolver for the UpdateSetting field.
+func (r *mutationResolver) UpdateSetting(ctx context.Context, typeArg model.DatabaseType, scope model.SettingScope, key string, value string) (*model.StatusResponse, error) {
+	target := settings.TargetFor(string(typeArg), auth.GetCredentials(ctx))
+	if scope == model.SettingScopeGlobal && len(auth.GetTenant(ctx)) > 0 {
+		return nil, errors.New("global settings are shared by all tenants and cannot be changed by one")
+	}
+	// Global and connection settings apply to everyone using them, so only identities allowed
+	// to change the structure of any schema may change them.
+	if scope == model.SettingScopeGlobal || scope == model.SettingScopeConnection {
+		if err := auth.CheckAccess(ctx, "", auth.Operation_DDL); err != nil {
+			return nil, err
+		}
+	}
+	if err := settings.Set(target, settings.Scope(scope), key, value); err != nil {
+		return nil, err
+	}
+	return &model.StatusResponse{
+		Status: true,
+	}, nil
+}
+
+// FormatQuery is the resolver for the FormatQuery field.
+func (r *mutationResolver) FormatQuery(ctx context.Context, typeArg model.DatabaseType, query string, options *model.FormatOptions) (string, error) {
+	formatOptions := lint.FormatOptions{}
+	if options != nil {
+		if options.KeywordCase != nil {
+			formatOptions.KeywordCase = lint.KeywordCase(*options.KeywordCase)
+		}
+		if options.IndentWidth != nil {
+			formatOptions.IndentWidth = *options.IndentWidth
+		}
+	}
+	return lint.Format(engine.DatabaseType(typeArg), query, formatOptions)
+}
+
+// RunAssertions is the resolver for the RunAssertions field.
+func (r *mutationResolver) RunAssertions(ctx context.Context, typeArg model.DatabaseType, schema *string, storageUnit *string, sampleSize *int) ([]*model.AssertionResult, error) {
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	plugin := src.MainEngine.Choose(engine.DatabaseType(typeArg))
+	size := 0
+	if sampleSize != nil {
+		size = *sampleSize
+	}
+	results := []*model.AssertionResult{}
+	for _, rule := range readableAssertions(ctx, typeArg, config, schema, storageUnit) {
+		results = append(results, getAssertionResultModel(assertion.Check(plugin, config, rule, size)))
+	}
+	return results, nil
+}
+
 // Database is the resolver for the Database field.
 func (r *queryResolver) Database(ctx context.Context, typeArg model.DatabaseType) ([]string, error) {
 	return src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetDatabases()
@@ -57,17 +439,36 @@ func (r *queryResolver) Database(ctx context.Context, typeArg model.DatabaseType
 
 // Schema is the resolver for the Schema field.
 func (r *queryResolver) Schema(ctx context.Context, typeArg model.DatabaseType) ([]string, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
-	return src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetSchema(config)
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	schemas, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetSchema(config)
+	if err != nil {
+		return nil, err
+	}
+	access := auth.GetAccess(ctx)
+	allowed := []string{}
+	for _, schema := range schemas {
+		if access.AllowsSchema(schema) {
+			allowed = append(allowed, schema)
+		}
+	}
+	return allowed, nil
 }
 
 // StorageUnit is the resolver for the StorageUnit field.
-func (r *queryResolver) StorageUnit(ctx context.Context, typeArg model.DatabaseType, schema string) ([]*model.StorageUnit, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
+func (r *queryResolver) StorageUnit(ctx context.Context, typeArg model.DatabaseType, schema string, withStats *bool) ([]*model.StorageUnit, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
 	units, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetStorageUnits(config, schema)
 	if err != nil {
 		return nil, err
 	}
+	if withStats != nil && *withStats {
+		units = withStorageUnitStats(typeArg, config, schema, units)
+	}
 	storageUnits := []*model.StorageUnit{}
 	for _, unit := range units {
 		storageUnits = append(storageUnits, engine.GetStorageUnitModel(unit))
@@ -76,12 +477,44 @@ func (r *queryResolver) StorageUnit(ctx context.Context, typeArg model.DatabaseT
 }
 
 // Row is the resolver for the Row field.
-func (r *queryResolver) Row(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*model.RowsResult, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
-	rowsResult, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetRows(config, schema, storageUnit, where, pageSize, pageOffset)
+func (r *queryResolver) Row(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, where string, pageSize int, pageOffset int, options *model.QueryOptions, sort []*model.SortCondition) (*model.RowsResult, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	if err := auth.CheckFilterAccess(ctx, string(typeArg), schema, storageUnit, where); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	// The filter is raw SQL, so the rows are read in a read-only transaction.
+	config.ReadOnly = true
+	if err := applyQueryOptions(config, options); err != nil {
+		return nil, err
+	}
+	target := settings.TargetFor(string(typeArg), config.Credentials)
+	if pageSize <= 0 {
+		pageSize = settings.GetInt(target, settings.Key_PageSize)
+	}
+	if maxPageSize := settings.GetInt(target, settings.Key_MaxPageSize); maxPageSize > 0 && pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	rowsResult, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetRows(config, schema, storageUnit, where, getSortConditions(sort), pageSize, pageOffset)
 	if err != nil {
 		return nil, err
 	}
+	maxCellBytes := settings.GetInt(target, settings.Key_MaxCellBytes)
+	// The option can only cut values shorter than the setting, where zero means no limit.
+	if options != nil && options.MaxCellBytes != nil && *options.MaxCellBytes > 0 && (maxCellBytes == 0 || *options.MaxCellBytes < maxCellBytes) {
+		maxCellBytes = *options.MaxCellBytes
+	}
+	truncatedCells := []*model.TruncatedCell{}
+	for _, truncated := range cell.Truncate(rowsResult, maxCellBytes) {
+		truncatedCells = append(truncatedCells, &model.TruncatedCell{
+			Row:    truncated.Row,
+			Column: truncated.Column,
+			Size:   truncated.Size,
+		})
+	}
 	columns := []*model.Column{}
 	for _, column := range rowsResult.Columns {
 		columns = append(columns, &model.Column{
@@ -90,15 +523,77 @@ func (r *queryResolver) Row(ctx context.Context, typeArg model.DatabaseType, sch
 		})
 	}
 	return &model.RowsResult{
-		Columns:       columns,
-		Rows:          rowsResult.Rows,
-		DisableUpdate: rowsResult.DisableUpdate,
+		Columns:        columns,
+		Rows:           rowsResult.Rows,
+		DisableUpdate:  rowsResult.DisableUpdate,
+		Warnings:       getWarnings(rowsResult),
+		TruncatedCells: truncatedCells,
 	}, nil
 }
 
+// RowCount is the resolver for the RowCount field.
+func (r *queryResolver) RowCount(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, where string, exact *bool) (*model.RowCount, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	if err := auth.CheckFilterAccess(ctx, string(typeArg), schema, storageUnit, where); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	// The filter is raw SQL, so the rows are read in a read-only transaction.
+	config.ReadOnly = true
+	plugin := src.MainEngine.Choose(engine.DatabaseType(typeArg))
+	// Estimates come from table statistics, so they can only stand in for unfiltered counts.
+	if (exact == nil || !*exact) && len(where) == 0 {
+		threshold := settings.GetInt(settings.TargetFor(string(typeArg), config.Credentials), settings.Key_RowCountEstimateThreshold)
+		estimate, err := plugin.EstimateRowCount(config, schema, storageUnit)
+		if err == nil && estimate >= int64(threshold) {
+			return &model.RowCount{
+				Count:     int(estimate),
+				Estimated: true,
+			}, nil
+		}
+	}
+	count, err := plugin.CountRows(config, schema, storageUnit, where)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RowCount{
+		Count:     int(count),
+		Estimated: false,
+	}, nil
+}
+
+// Cell is the resolver for the Cell field.
+func (r *queryResolver) Cell(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, column string, key []*model.RecordInput) (*string, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	keyMap := map[string]string{}
+	for _, record := range key {
+		keyMap[record.Key] = record.Value
+	}
+	return cell.Fetch(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema, storageUnit, column, keyMap)
+}
+
 // RawExecute is the resolver for the RawExecute field.
-func (r *queryResolver) RawExecute(ctx context.Context, typeArg model.DatabaseType, query string) (*model.RowsResult, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
+func (r *queryResolver) RawExecute(ctx context.Context, typeArg model.DatabaseType, query string, options *model.QueryOptions, variables []*model.QueryVariable) (*model.RowsResult, error) {
+	query, err := renderQueryVariables(typeArg, query, variables)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckQueryAccess(ctx, query); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	config.ReadOnly = auth.RequiresReadOnly(ctx, query)
+	if err := applyQueryOptions(config, options); err != nil {
+		return nil, err
+	}
 	rowsResult, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).RawExecute(config, query)
 	if err != nil {
 		return nil, err
@@ -111,14 +606,49 @@ func (r *queryResolver) RawExecute(ctx context.Context, typeArg model.DatabaseTy
 		})
 	}
 	return &model.RowsResult{
-		Columns: columns,
-		Rows:    rowsResult.Rows,
+		Columns:        columns,
+		Rows:           rowsResult.Rows,
+		Warnings:       getWarnings(rowsResult),
+		TruncatedCells: []*model.TruncatedCell{},
 	}, nil
 }
 
+// QueryVariables is the resolver for the QueryVariables field.
+func (r *queryResolver) QueryVariables(ctx context.Context, query string) ([]string, error) {
+	return template.Variables(query), nil
+}
+
+// LintQuery is the resolver for the LintQuery field.
+func (r *queryResolver) LintQuery(ctx context.Context, typeArg model.DatabaseType, schema string, query string) ([]*model.LintIssue, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	issues, err := lint.LintQuery(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema, query)
+	if err != nil {
+		return nil, err
+	}
+	issuesModel := []*model.LintIssue{}
+	for _, issue := range issues {
+		issuesModel = append(issuesModel, &model.LintIssue{
+			Severity: model.LintSeverity(issue.Severity),
+			Rule:     model.LintRule(issue.Rule),
+			Message:  issue.Message,
+			Line:     issue.Line,
+			Column:   issue.Column,
+		})
+	}
+	return issuesModel, nil
+}
+
 // Graph is the resolver for the Graph field.
 func (r *queryResolver) Graph(ctx context.Context, typeArg model.DatabaseType, schema string) ([]*model.GraphUnit, error) {
-	config := engine.NewPluginConfig(auth.GetCredentials(ctx))
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
 	graphUnits, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetGraph(config, schema)
 	if err != nil {
 		return nil, err
@@ -140,6 +670,515 @@ func (r *queryResolver) Graph(ctx context.Context, typeArg model.DatabaseType, s
 	return graphUnitsModel, nil
 }
 
+// Routines is the resolver for the Routines field.
+func (r *queryResolver) Routines(ctx context.Context, typeArg model.DatabaseType, schema string) ([]*model.Routine, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	routines, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetRoutines(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	routinesModel := []*model.Routine{}
+	for _, routine := range routines {
+		arguments := []*model.RoutineArgument{}
+		for _, argument := range routine.Arguments {
+			arguments = append(arguments, &model.RoutineArgument{
+				Name: argument.Name,
+				Type: argument.Type,
+				Mode: argument.Mode,
+			})
+		}
+		routinesModel = append(routinesModel, &model.Routine{
+			Name:       routine.Name,
+			Type:       routine.Type,
+			ReturnType: routine.ReturnType,
+			Arguments:  arguments,
+			Definition: routine.Definition,
+		})
+	}
+	return routinesModel, nil
+}
+
+// ViewLineage is the resolver for the ViewLineage field.
+func (r *queryResolver) ViewLineage(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string) ([]*model.ColumnLineage, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	view, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetViewDefinition(config, schema, storageUnit)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := lineage.ViewLineage(view, schema)
+	if err != nil {
+		return nil, err
+	}
+	lineageModel := []*model.ColumnLineage{}
+	for _, column := range columns {
+		sources := []*model.ColumnSource{}
+		for _, source := range column.Sources {
+			sources = append(sources, &model.ColumnSource{
+				Schema: source.Schema,
+				Table:  source.Table,
+				Column: source.Column,
+			})
+		}
+		lineageModel = append(lineageModel, &model.ColumnLineage{
+			Column:  column.Column,
+			Sources: sources,
+		})
+	}
+	return lineageModel, nil
+}
+
+// ERDiagram is the resolver for the ERDiagram field.
+func (r *queryResolver) ERDiagram(ctx context.Context, typeArg model.DatabaseType, schema string, format model.DiagramFormat) (string, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return "", err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	graphUnits, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetGraph(config, schema)
+	if err != nil {
+		return "", err
+	}
+	return erd.Render(graphUnits, erd.Format(format))
+}
+
+// Ddl is the resolver for the DDL field.
+func (r *queryResolver) Ddl(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string) (string, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return "", err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	return src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetDDL(config, schema, storageUnit)
+}
+
+// SchemaSnapshot is the resolver for the SchemaSnapshot field.
+func (r *queryResolver) SchemaSnapshot(ctx context.Context, typeArg model.DatabaseType, schema string) (string, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return "", err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	snapshot, err := schemadiff.Take(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema)
+	if err != nil {
+		return "", err
+	}
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// SchemaDiff is the resolver for the SchemaDiff field.
+func (r *queryResolver) SchemaDiff(ctx context.Context, typeArg model.DatabaseType, schema string, snapshot string) ([]*model.SchemaChange, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	stored, err := schemadiff.Parse(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	live, err := schemadiff.Take(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema)
+	if err != nil {
+		return nil, err
+	}
+	changes := []*model.SchemaChange{}
+	for _, change := range schemadiff.Diff(stored, live) {
+		changes = append(changes, getSchemaChangeModel(change))
+	}
+	return changes, nil
+}
+
+// InferredSchema is the resolver for the InferredSchema field.
+func (r *queryResolver) InferredSchema(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, sampleSize *int) ([]*model.InferredField, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	size := 0
+	if sampleSize != nil {
+		size = *sampleSize
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	fields, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).InferSchema(config, schema, storageUnit, size)
+	if err != nil {
+		return nil, err
+	}
+	inferredFields := []*model.InferredField{}
+	for _, field := range fields {
+		inferredFields = append(inferredFields, &model.InferredField{
+			Path:       field.Path,
+			Types:      field.Types,
+			Occurrence: field.Occurrence,
+		})
+	}
+	return inferredFields, nil
+}
+
+// JoinSuggestions is the resolver for the JoinSuggestions field.
+func (r *queryResolver) JoinSuggestions(ctx context.Context, typeArg model.DatabaseType, schema string, storageUnit string, alias *string) ([]*model.JoinSuggestion, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	foreignKeys, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetForeignKeys(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	sourceAlias := ""
+	if alias != nil {
+		sourceAlias = *alias
+	}
+	suggestionsModel := []*model.JoinSuggestion{}
+	for _, suggestion := range joins.Suggest(engine.DatabaseType(typeArg), foreignKeys, schema, storageUnit, sourceAlias) {
+		suggestionsModel = append(suggestionsModel, &model.JoinSuggestion{
+			Schema: suggestion.Schema,
+			Table:  suggestion.Table,
+			Alias:  suggestion.Alias,
+			Clause: suggestion.Clause,
+		})
+	}
+	return suggestionsModel, nil
+}
+
+// Search is the resolver for the Search field.
+func (r *queryResolver) Search(ctx context.Context, typeArg model.DatabaseType, schema string, search string, limit *int) ([]*model.SearchHit, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	if len(search) == 0 {
+		return nil, errors.New("search cannot be empty")
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	searchLimit := 0
+	if limit != nil {
+		searchLimit = *limit
+	}
+	hits, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).SearchStorageUnits(config, schema, search, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+	hitsModel := []*model.SearchHit{}
+	for _, hit := range hits {
+		row := []*model.Record{}
+		for _, record := range hit.Row {
+			row = append(row, &model.Record{
+				Key:   record.Key,
+				Value: record.Value,
+			})
+		}
+		hitsModel = append(hitsModel, &model.SearchHit{
+			StorageUnit: hit.StorageUnit,
+			Column:      hit.Column,
+			Row:         row,
+		})
+	}
+	return hitsModel, nil
+}
+
+// Settings is the resolver for the Settings field.
+func (r *queryResolver) Settings(ctx context.Context, typeArg model.DatabaseType) ([]*model.Setting, error) {
+	target := settings.TargetFor(string(typeArg), auth.GetCredentials(ctx))
+	settingsModel := []*model.Setting{}
+	for _, setting := range settings.GetAll(target) {
+		values := setting.Values
+		if values == nil {
+			values = []string{}
+		}
+		settingsModel = append(settingsModel, &model.Setting{
+			Key:         setting.Key,
+			Type:        model.SettingType(setting.Type),
+			Value:       setting.Value,
+			Default:     setting.Default,
+			Scope:       model.SettingScope(setting.Scope),
+			Description: setting.Description,
+			Values:      values,
+		})
+	}
+	return settingsModel, nil
+}
+
+// ScheduledQueries is the resolver for the ScheduledQueries field.
+func (r *queryResolver) ScheduledQueries(ctx context.Context, typeArg model.DatabaseType) ([]*model.ScheduledQuery, error) {
+	connection := settings.TargetFor(string(typeArg), auth.GetCredentials(ctx)).Connection
+	scheduledQueries := []*model.ScheduledQuery{}
+	for _, job := range src.MainScheduler.GetJobs() {
+		query, err := scheduledquery.GetQuery(job)
+		if err != nil || query.Connection != connection || !query.OwnedBy(auth.GetIdentity(ctx)) {
+			continue
+		}
+		scheduledQueries = append(scheduledQueries, getScheduledQueryModel(job, query))
+	}
+	return scheduledQueries, nil
+}
+
+// ScheduledQuerySnapshots is the resolver for the ScheduledQuerySnapshots field.
+func (r *queryResolver) ScheduledQuerySnapshots(ctx context.Context, typeArg model.DatabaseType, id string) ([]*model.QuerySnapshot, error) {
+	if _, _, err := getScheduledQuery(ctx, typeArg, id); err != nil {
+		return nil, err
+	}
+	snapshots, err := src.MainQueryRunner.Snapshots(id)
+	if err != nil {
+		return nil, err
+	}
+	snapshotsModel := []*model.QuerySnapshot{}
+	for _, snapshot := range snapshots {
+		columns := []*model.Column{}
+		for _, column := range snapshot.Columns {
+			columns = append(columns, &model.Column{
+				Type: column.Type,
+				Name: column.Name,
+			})
+		}
+		rows := snapshot.Rows
+		if rows == nil {
+			rows = [][]string{}
+		}
+		snapshotModel := &model.QuerySnapshot{
+			RanAt:      snapshot.RanAt.Format(time.RFC3339),
+			DurationMs: int(snapshot.Duration.Milliseconds()),
+			RowCount:   snapshot.RowCount,
+			Checksum:   snapshot.Checksum,
+			Changed:    snapshot.Changed,
+			Columns:    columns,
+			Rows:       rows,
+		}
+		if len(snapshot.Error) > 0 {
+			snapshotError := snapshot.Error
+			snapshotModel.Error = &snapshotError
+		}
+		snapshotsModel = append(snapshotsModel, snapshotModel)
+	}
+	return snapshotsModel, nil
+}
+
+// AssetDependencies is the resolver for the AssetDependencies field.
+func (r *queryResolver) AssetDependencies(ctx context.Context, typeArg model.DatabaseType, schema *string, storageUnit *string) ([]*model.AssetDependency, error) {
+	connection := settings.TargetFor(string(typeArg), auth.GetCredentials(ctx)).Connection
+	dependencies := []*model.AssetDependency{}
+	for _, job := range src.MainScheduler.GetJobs() {
+		query, err := scheduledquery.GetQuery(job)
+		if err != nil || query.Connection != connection {
+			continue
+		}
+		tables := lineage.ReferencedTables(query.Query)
+		if storageUnit != nil && !referencesTable(tables, schema, *storageUnit) {
+			continue
+		}
+		dependencies = append(dependencies, getAssetDependencyModel(assetType_ScheduledQuery, job.ID, job.Name, tables))
+	}
+	return dependencies, nil
+}
+
+// RecycleBin is the resolver for the RecycleBin field.
+func (r *queryResolver) RecycleBin(ctx context.Context, typeArg model.DatabaseType) ([]*model.RecycledItem, error) {
+	items, err := src.MainRecycleBin.List(settings.TargetFor(string(typeArg), auth.GetCredentials(ctx)).Connection)
+	if err != nil {
+		return nil, err
+	}
+	owner := identitySubject(auth.GetIdentity(ctx))
+	recycledItems := []*model.RecycledItem{}
+	for _, item := range items {
+		if item.Owner != owner {
+			continue
+		}
+		recycledItems = append(recycledItems, &model.RecycledItem{
+			ID:        item.ID,
+			Type:      item.Type,
+			Name:      item.Name,
+			DeletedAt: item.DeletedAt.Format(time.RFC3339),
+			ExpiresAt: item.ExpiresAt().Format(time.RFC3339),
+		})
+	}
+	return recycledItems, nil
+}
+
+// PIIScan is the resolver for the PIIScan field.
+func (r *queryResolver) PIIScan(ctx context.Context, typeArg model.DatabaseType, schema string, sampleSize *int) ([]*model.PIIFinding, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	size := 0
+	if sampleSize != nil {
+		size = *sampleSize
+	}
+	findings, err := pii.Scan(src.MainEngine.Choose(engine.DatabaseType(typeArg)), config, schema, size)
+	if err != nil {
+		return nil, err
+	}
+	findingsModel := []*model.PIIFinding{}
+	for _, finding := range findings {
+		findingsModel = append(findingsModel, &model.PIIFinding{
+			StorageUnit: finding.StorageUnit,
+			Column:      finding.Column,
+			Kind:        model.PIIKind(finding.Kind),
+			Confidence:  finding.Confidence,
+			Sampled:     finding.Sampled,
+		})
+	}
+	return findingsModel, nil
+}
+
+// Snippet is the resolver for the Snippet field.
+func (r *queryResolver) Snippet(ctx context.Context, language model.SnippetLanguage, operation string, variables *string) (string, error) {
+	snippetVariables := ""
+	if variables != nil {
+		snippetVariables = *variables
+	}
+	return snippet.Generate(snippet.Language(language), operation, snippetVariables)
+}
+
+// SlowQueries is the resolver for the SlowQueries field.
+func (r *queryResolver) SlowQueries(ctx context.Context, typeArg model.DatabaseType) ([]*model.SlowQuery, error) {
+	credentials := auth.GetCredentials(ctx)
+	slowQueries := src.MainEngine.SlowQueries().Recent(func(query engine.SlowQuery) bool {
+		return query.DatabaseType == engine.DatabaseType(typeArg) && query.Hostname == credentials.Hostname &&
+			query.Database == credentials.Database && query.Username == credentials.Username && query.Tenant == credentials.Tenant
+	})
+	slowQueryModels := []*model.SlowQuery{}
+	for _, slowQuery := range slowQueries {
+		slowQueryModels = append(slowQueryModels, getSlowQueryModel(slowQuery))
+	}
+	return slowQueryModels, nil
+}
+
+// TenantUsage is the resolver for the TenantUsage field.
+func (r *queryResolver) TenantUsage(ctx context.Context) (*model.TenantUsage, error) {
+	tenantName := auth.GetTenant(ctx)
+	currentTenant, ok := tenant.Get(tenantName)
+	if !ok {
+		return nil, nil
+	}
+	queries, rows := src.MainQuotas.Usage(tenantName)
+	return &model.TenantUsage{
+		Name:              currentTenant.Name,
+		QueriesPerMinute:  currentTenant.QueriesPerMinute,
+		QueriesLastMinute: queries,
+		RowsPerDay:        currentTenant.RowsPerDay,
+		RowsToday:         rows,
+	}, nil
+}
+
+// Version is the resolver for the Version field.
+func (r *queryResolver) Version(ctx context.Context) (*model.VersionInfo, error) {
+	info := src.MainVersion.Info()
+	versionModel := &model.VersionInfo{
+		Version:         info.Version,
+		Commit:          info.Commit,
+		UpdateAvailable: info.UpdateAvailable,
+	}
+	if len(info.LatestVersion) > 0 {
+		latestVersion := info.LatestVersion
+		versionModel.LatestVersion = &latestVersion
+	}
+	if info.AssetsError != nil {
+		assetsError := info.AssetsError.Error()
+		versionModel.AssetsError = &assetsError
+	}
+	return versionModel, nil
+}
+
+// Activity is the resolver for the Activity field.
+func (r *queryResolver) Activity(ctx context.Context, typeArg model.DatabaseType) (*model.Activity, error) {
+	// The queries of every session are shown, whatever schema they use.
+	if err := auth.CheckAccess(ctx, "", auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	activity, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetActivity(config)
+	if err != nil {
+		return nil, err
+	}
+	return getActivityModel(activity), nil
+}
+
+// MaterializedViews is the resolver for the MaterializedViews field.
+func (r *queryResolver) MaterializedViews(ctx context.Context, typeArg model.DatabaseType, schema string) ([]*model.MaterializedView, error) {
+	if err := auth.CheckAccess(ctx, schema, auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	views, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetMaterializedViews(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	viewsModel := []*model.MaterializedView{}
+	for _, view := range views {
+		viewModel := &model.MaterializedView{
+			Name:       view.Name,
+			Populated:  view.Populated,
+			Concurrent: view.Concurrent,
+		}
+		if !view.LastRefresh.IsZero() {
+			lastRefresh := view.LastRefresh.Format(time.RFC3339)
+			viewModel.LastRefresh = &lastRefresh
+		}
+		viewsModel = append(viewsModel, viewModel)
+	}
+	return viewsModel, nil
+}
+
+// DatabaseUsers is the resolver for the DatabaseUsers field.
+func (r *queryResolver) DatabaseUsers(ctx context.Context, typeArg model.DatabaseType) ([]*model.DatabaseUser, error) {
+	if err := auth.CheckAccess(ctx, "", auth.Operation_Read); err != nil {
+		return nil, err
+	}
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	users, err := src.MainEngine.Choose(engine.DatabaseType(typeArg)).GetDatabaseUsers(config)
+	if err != nil {
+		return nil, err
+	}
+	usersModel := []*model.DatabaseUser{}
+	for _, user := range users {
+		userModel := &model.DatabaseUser{
+			Name:      user.Name,
+			Host:      user.Host,
+			CanLogin:  user.CanLogin,
+			Superuser: user.Superuser,
+			MemberOf:  user.MemberOf,
+			Grants:    []*model.DatabaseGrant{},
+		}
+		for _, grant := range user.Grants {
+			userModel.Grants = append(userModel.Grants, &model.DatabaseGrant{
+				Privilege: grant.Privilege,
+				Schema:    grant.Schema,
+				Object:    grant.Object,
+				Grantable: grant.Grantable,
+			})
+		}
+		usersModel = append(usersModel, userModel)
+	}
+	return usersModel, nil
+}
+
+// Assertions is the resolver for the Assertions field.
+func (r *queryResolver) Assertions(ctx context.Context, typeArg model.DatabaseType, schema *string, storageUnit *string) ([]*model.Assertion, error) {
+	config := settings.PluginConfigFor(string(typeArg), auth.GetCredentials(ctx))
+	config.Context = ctx
+	assertions := []*model.Assertion{}
+	for _, rule := range readableAssertions(ctx, typeArg, config, schema, storageUnit) {
+		assertions = append(assertions, getAssertionModel(rule))
+	}
+	return assertions, nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
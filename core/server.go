@@ -6,6 +6,10 @@ import (
 )
 
 func main() {
+	src.InitializeVersion()
+	src.InitializeCache()
+	src.InitializeSettings()
 	src.InitializeEngine()
+	src.InitializeScheduler()
 	router.InitializeRouter()
 }
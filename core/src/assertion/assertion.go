@@ -0,0 +1,153 @@
+package assertion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+const defaultSampleSize = 10
+
+// Assertion is a rule every row of a table must follow, either a condition or a reference
+// to another table's column.
+type Assertion struct {
+	Name string `json:"name"`
+	// Connections are patterns of the form "<type>://<hostname>/<database>", as in the auth policy.
+	Connections []string `json:"connections"`
+	Schema      string   `json:"schema"`
+	Table       string   `json:"table"`
+	// Condition is what every row must satisfy: a SQL expression, e.g. amount >= 0, or a
+	// MongoDB filter. Rows for which a SQL condition is NULL are not violations.
+	Condition string `json:"condition"`
+	// Column and References require every value of Column that is not NULL to be found in
+	// References, a column named table.column or schema.table.column.
+	Column     string `json:"column"`
+	References string `json:"references"`
+}
+
+// Result is the outcome of checking an assertion: the number of rows violating it and a
+// sample of them, or the error that kept it from being checked.
+type Result struct {
+	Assertion  Assertion
+	Violations int64
+	Sample     *engine.GetRowsResult
+	Error      string
+}
+
+// Assertions are the data quality rules read from the assertions file.
+type Assertions struct {
+	assertions []Assertion
+}
+
+// Load reads the assertions, a JSON array, from path.
+func Load(path string) (*Assertions, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read assertions: %w", err)
+	}
+	assertions := []Assertion{}
+	if err := json.Unmarshal(content, &assertions); err != nil {
+		return nil, fmt.Errorf("invalid assertions: %w", err)
+	}
+	names := map[string]bool{}
+	for _, assertion := range assertions {
+		if err := assertion.validate(); err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", assertion.Name, err)
+		}
+		if names[assertion.Name] {
+			return nil, fmt.Errorf("assertion %q is defined twice", assertion.Name)
+		}
+		names[assertion.Name] = true
+	}
+	return &Assertions{assertions: assertions}, nil
+}
+
+func (a Assertion) validate() error {
+	if len(a.Name) == 0 {
+		return errors.New("name is required")
+	}
+	if len(a.Table) == 0 {
+		return errors.New("table is required")
+	}
+	references := len(a.Column) > 0 || len(a.References) > 0
+	switch {
+	case len(a.Condition) > 0 && references:
+		return errors.New("condition cannot be combined with column and references")
+	case len(a.Condition) == 0 && !references:
+		return errors.New("either condition or column and references is required")
+	case references && (len(a.Column) == 0 || len(a.References) == 0):
+		return errors.New("column and references go together")
+	case references && !strings.Contains(a.References, "."):
+		return errors.New("references must name a column as table.column")
+	}
+	return nil
+}
+
+// For returns the assertions of a connection, only keeping those of schema and table when
+// they are given.
+func (a *Assertions) For(databaseType engine.DatabaseType, config *engine.PluginConfig, schema string, table string) []Assertion {
+	connection := fmt.Sprintf("%v://", databaseType)
+	if config != nil && config.Credentials != nil {
+		connection = fmt.Sprintf("%v://%v/%v", databaseType, config.Credentials.Hostname, config.Credentials.Database)
+	}
+	matching := []Assertion{}
+	for _, assertion := range a.assertions {
+		if len(assertion.Connections) > 0 && !common.MatchesAnyPattern(assertion.Connections, connection) {
+			continue
+		}
+		if (len(schema) > 0 && assertion.Schema != schema) || (len(table) > 0 && assertion.Table != table) {
+			continue
+		}
+		matching = append(matching, assertion)
+	}
+	return matching
+}
+
+// Check counts the rows violating an assertion and reads up to sampleSize of them.
+func Check(plugin *engine.Plugin, config *engine.PluginConfig, assertion Assertion, sampleSize int) Result {
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	result := Result{Assertion: assertion}
+	filter, err := violations(plugin.Type, assertion)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Violations, err = plugin.CountRows(config, assertion.Schema, assertion.Table, filter)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if result.Violations > 0 {
+		result.Sample, err = plugin.GetRows(config, assertion.Schema, assertion.Table, filter, nil, sampleSize, 0)
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// violations returns the filter selecting the rows that violate the assertion.
+func violations(databaseType engine.DatabaseType, assertion Assertion) (string, error) {
+	switch databaseType {
+	case engine.DatabaseType_Redis:
+		return "", errors.ErrUnsupported
+	case engine.DatabaseType_MongoDB:
+		if len(assertion.Condition) == 0 {
+			return "", errors.New("references cannot be checked on MongoDB")
+		}
+		return fmt.Sprintf(`{"$nor": [%v]}`, assertion.Condition), nil
+	}
+	condition := assertion.Condition
+	if len(condition) == 0 {
+		i := strings.LastIndex(assertion.References, ".")
+		condition = fmt.Sprintf("%v IS NULL OR %v IN (SELECT %v FROM %v)", assertion.Column, assertion.Column, assertion.References[i+1:], assertion.References[:i])
+	}
+	return fmt.Sprintf("NOT (%v)", condition), nil
+}
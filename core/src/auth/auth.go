@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/tenant"
 )
 
 type AuthKey string
@@ -17,20 +20,116 @@ type AuthKey string
 const (
 	AuthKey_Token       AuthKey = "Token"
 	AuthKey_Credentials AuthKey = "Credentials"
+	AuthKey_Identity    AuthKey = "Identity"
+	AuthKey_Access      AuthKey = "Access"
+	AuthKey_Type        AuthKey = "Type"
 )
 
+var ErrTypeMismatch = errors.New("the request is for another database type than the connection logged in to")
+
 func GetCredentials(ctx context.Context) *engine.Credentials {
 	return ctx.Value(AuthKey_Credentials).(*engine.Credentials)
 }
 
+// GetIdentity returns the user making the request, or nil when no authenticator is configured.
+func GetIdentity(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(AuthKey_Identity).(*Identity)
+	return identity
+}
+
+// GetTenant returns the tenant the request is made for, or an empty string when tenants are not configured.
+func GetTenant(ctx context.Context) string {
+	credentials, _ := ctx.Value(AuthKey_Credentials).(*engine.Credentials)
+	if credentials == nil {
+		return ""
+	}
+	return credentials.Tenant
+}
+
+// GetAccess returns what the identity's policy allows on the current connection, or nil when
+// users are not identified.
+func GetAccess(ctx context.Context) *Access {
+	access, _ := ctx.Value(AuthKey_Access).(*Access)
+	return access
+}
+
+// IsReadOnly reports whether the identity's policy only allows reads on the current connection.
+func IsReadOnly(ctx context.Context) bool {
+	return GetAccess(ctx).ReadOnly()
+}
+
+// CheckAccess returns an error unless the identity's policy allows operation on schema.
+func CheckAccess(ctx context.Context, schema string, operation Operation) error {
+	return GetAccess(ctx).Check(schema, operation)
+}
+
+// CheckType returns an error unless databaseType is the type of the connection logged in
+// to, which the connection policy was checked against. Requests made without a connection,
+// such as logging in, have no type to check.
+func CheckType(ctx context.Context, databaseType string) error {
+	loggedInType, ok := ctx.Value(AuthKey_Type).(string)
+	if !ok || loggedInType == databaseType {
+		return nil
+	}
+	return ErrTypeMismatch
+}
+
+// CheckQueryAccess returns an error unless the identity's policy allows running query.
+func CheckQueryAccess(ctx context.Context, query string) error {
+	return GetAccess(ctx).CheckQuery(query)
+}
+
+// CheckFilterAccess returns an error unless the identity's policy allows reading the rows
+// of storageUnit that match where, a raw SQL condition that may itself read other schemas or
+// call functions. Filters of databases that do not use SQL have nothing more to check.
+func CheckFilterAccess(ctx context.Context, databaseType string, schema string, storageUnit string, where string) error {
+	if len(strings.TrimSpace(where)) == 0 || databaseType == engine.DatabaseType_MongoDB || databaseType == engine.DatabaseType_Redis {
+		return nil
+	}
+	table := quoteFilterIdentifier(storageUnit)
+	if len(schema) > 0 {
+		table = quoteFilterIdentifier(schema) + "." + table
+	}
+	return CheckQueryAccess(ctx, fmt.Sprintf("SELECT * FROM %v WHERE %v", table, where))
+}
+
+func quoteFilterIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// RequiresReadOnly reports whether query must run in a read-only transaction, as the
+// identity's policy only allows it as a read.
+func RequiresReadOnly(ctx context.Context, query string) bool {
+	return GetAccess(ctx).RequiresReadOnly(query)
+}
+
 func isPublicRoute(r *http.Request) bool {
 	return !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/api"
 }
 
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		identity, err := authenticate(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if identity != nil {
+			ctx = context.WithValue(ctx, AuthKey_Identity, identity)
+		}
+
 		if isPublicRoute(r) {
-			next.ServeHTTP(w, r)
+			if IsOIDCEnabled() && identity == nil && !strings.HasPrefix(r.URL.Path, "/auth/oidc") && strings.Contains(r.Header.Get("Accept"), "text/html") {
+				http.Redirect(w, r, oidcLoginPath, http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if isIdentityRequired() && identity == nil {
+			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
@@ -42,7 +141,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		r.Body = io.NopCloser(bytes.NewReader(body))
 		if isAllowed(r, body) {
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
@@ -65,7 +164,30 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := r.Context()
+		connection := struct{ Type string }{}
+		if err := json.Unmarshal(decodedValue, &connection); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ctx = context.WithValue(ctx, AuthKey_Type, connection.Type)
+
+		if identity != nil {
+			access, err := CheckConnectionAccess(identity, connection.Type, credentials)
+			if err != nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			ctx = context.WithValue(ctx, AuthKey_Access, access)
+			credentials.Subject = identity.Subject
+			if tenant.IsEnabled() {
+				credentials.Tenant, err = CheckTenantAccess(identity, connection.Type, credentials)
+				if err != nil {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+			}
+		}
+
 		ctx = context.WithValue(ctx, AuthKey_Credentials, credentials)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/tenant"
+)
+
+const (
+	Authenticator_Session       = "session"
+	Authenticator_Bearer        = "bearer"
+	Authenticator_ClientCert    = "mtls"
+	Authenticator_TrustedHeader = "header"
+)
+
+// Authenticator establishes who is making a request. It returns a nil identity when the
+// request carries nothing it understands, so the next authenticator can be tried, and an
+// error when the request carries proof that turns out to be invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+var authenticators []Authenticator
+
+// Initialize sets up OIDC, the authenticator chain, tenants and the connection policy.
+func Initialize() error {
+	if err := InitializeOIDC(); err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(env.Authenticators, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 && IsOIDCEnabled() {
+		names = []string{Authenticator_Session, Authenticator_Bearer}
+	}
+
+	authenticators = []Authenticator{}
+	for _, name := range names {
+		authenticator, err := newAuthenticator(name)
+		if err != nil {
+			return err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if len(authenticators) > 0 {
+		log.Logger.Infof("Identifying users with: %v", strings.Join(names, ", "))
+	}
+
+	if len(env.TenantsFile) > 0 {
+		if len(authenticators) == 0 {
+			return errors.New("tenants require an authenticator to identify users")
+		}
+		if err := tenant.Load(env.TenantsFile); err != nil {
+			return err
+		}
+	}
+	return loadPolicy()
+}
+
+func newAuthenticator(name string) (Authenticator, error) {
+	switch name {
+	case Authenticator_Session, Authenticator_Bearer:
+		if !IsOIDCEnabled() {
+			return nil, fmt.Errorf("the %v authenticator requires WHODB_OIDC_ISSUER", name)
+		}
+		if name == Authenticator_Session {
+			return &sessionAuthenticator{}, nil
+		}
+		return &bearerAuthenticator{}, nil
+	case Authenticator_ClientCert:
+		if len(env.TLSClientCAFile) == 0 {
+			return nil, errors.New("the mtls authenticator requires WHODB_TLS_CLIENT_CA")
+		}
+		// Client certificates are only presented over HTTPS.
+		if len(env.TLSCertFile) == 0 || len(env.TLSKeyFile) == 0 {
+			return nil, errors.New("the mtls authenticator requires WHODB_TLS_CERT and WHODB_TLS_KEY")
+		}
+		return &clientCertAuthenticator{}, nil
+	case Authenticator_TrustedHeader:
+		return newTrustedHeaderAuthenticator()
+	}
+	return nil, fmt.Errorf("unknown authenticator: %v", name)
+}
+
+func isIdentityRequired() bool {
+	return len(authenticators) > 0
+}
+
+// authenticate returns the identity from the first authenticator that recognizes the request.
+func authenticate(r *http.Request) (*Identity, error) {
+	for _, authenticator := range authenticators {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if identity != nil {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+type clientCertAuthenticator struct{}
+
+// Authenticate identifies users by the client certificate they presented, which the TLS
+// handshake has already verified against WHODB_TLS_CLIENT_CA. Organizational units become roles.
+func (a *clientCertAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	identity := &Identity{
+		Subject: certificate.Subject.CommonName,
+		Roles:   certificate.Subject.OrganizationalUnit,
+		Expiry:  certificate.NotAfter,
+	}
+	if len(certificate.EmailAddresses) > 0 {
+		identity.Email = certificate.EmailAddresses[0]
+	}
+	return identity, nil
+}
+
+type trustedHeaderAuthenticator struct {
+	proxies      []*net.IPNet
+	userHeader   string
+	emailHeader  string
+	groupsHeader string
+}
+
+func newTrustedHeaderAuthenticator() (*trustedHeaderAuthenticator, error) {
+	authenticator := &trustedHeaderAuthenticator{
+		userHeader:   headerOrDefault(env.TrustedUserHeader, "X-Forwarded-User"),
+		emailHeader:  headerOrDefault(env.TrustedEmailHeader, "X-Forwarded-Email"),
+		groupsHeader: headerOrDefault(env.TrustedGroupsHeader, "X-Forwarded-Groups"),
+	}
+	for _, proxy := range strings.Split(env.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if len(proxy) == 0 {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		authenticator.proxies = append(authenticator.proxies, network)
+	}
+	if len(authenticator.proxies) == 0 {
+		return nil, errors.New("the header authenticator requires WHODB_TRUSTED_PROXIES")
+	}
+	return authenticator, nil
+}
+
+func headerOrDefault(header string, defaultHeader string) string {
+	if len(header) == 0 {
+		return defaultHeader
+	}
+	return header
+}
+
+// Authenticate trusts the identity headers of requests sent by one of the trusted proxies.
+// Anyone else could set those headers themselves, so they are ignored on other requests.
+func (a *trustedHeaderAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	user := r.Header.Get(a.userHeader)
+	if len(user) == 0 || !a.isTrustedPeer(r) {
+		return nil, nil
+	}
+	identity := &Identity{
+		Subject: user,
+		Email:   r.Header.Get(a.emailHeader),
+		Roles:   []string{},
+	}
+	for _, group := range strings.Split(r.Header.Get(a.groupsHeader), ",") {
+		if group = strings.TrimSpace(group); len(group) > 0 {
+			identity.Roles = append(identity.Roles, group)
+		}
+	}
+	return identity, nil
+}
+
+func (a *trustedHeaderAuthenticator) isTrustedPeer(r *http.Request) bool {
+	peerAddress, ok := r.Context().Value(common.RouterKey_PeerAddress).(string)
+	if !ok {
+		peerAddress = r.RemoteAddr
+	}
+	host, _, err := net.SplitHostPort(peerAddress)
+	if err != nil {
+		host = peerAddress
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range a.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -9,8 +9,28 @@ import (
 
 	"github.com/clidey/whodb/core/graph/model"
 	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/tenant"
 )
 
+// CheckLogin returns an error unless the identity may use the connection, which is checked
+// before anything connects to it.
+func CheckLogin(ctx context.Context, databaseType string, credentials *engine.Credentials) error {
+	identity := GetIdentity(ctx)
+	if identity == nil {
+		return nil
+	}
+	if _, err := CheckConnectionAccess(identity, databaseType, credentials); err != nil {
+		return err
+	}
+	if tenant.IsEnabled() {
+		if _, err := CheckTenantAccess(identity, databaseType, credentials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func Login(ctx context.Context, input *model.LoginCredentials) (*model.StatusResponse, error) {
 	loginInfoJSON, err := json.Marshal(input)
 	if err != nil {
@@ -25,6 +45,8 @@ func Login(ctx context.Context, input *model.LoginCredentials) (*model.StatusRes
 		Path:     "/",
 		HttpOnly: true,
 		Expires:  time.Now().Add(24 * time.Hour),
+		// Not sent along with requests made by other sites.
+		SameSite: http.SameSiteLaxMode,
 	}
 
 	http.SetCookie(ctx.Value(common.RouterKey_ResponseWriter).(http.ResponseWriter), cookie)
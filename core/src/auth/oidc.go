@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcLoginPath    = "/auth/oidc/login"
+	oidcCallbackPath = "/auth/oidc/callback"
+	oidcStateCookie  = "OIDCState"
+	sessionDuration  = 24 * time.Hour
+)
+
+type oidcProvider struct {
+	verifier   *oidc.IDTokenVerifier
+	config     oauth2.Config
+	rolesClaim string
+}
+
+type oidcState struct {
+	State string
+	Nonce string
+}
+
+var oidcAuth *oidcProvider
+
+func IsOIDCEnabled() bool {
+	return oidcAuth != nil
+}
+
+// InitializeOIDC enables OIDC sign-in when an issuer is configured.
+func InitializeOIDC() error {
+	if len(env.OIDCIssuer) == 0 {
+		return nil
+	}
+	provider, err := oidc.NewProvider(context.Background(), env.OIDCIssuer)
+	if err != nil {
+		return fmt.Errorf("unable to discover OIDC provider: %w", err)
+	}
+
+	rolesClaim := env.OIDCRolesClaim
+	if len(rolesClaim) == 0 {
+		rolesClaim = "groups"
+	}
+
+	oidcAuth = &oidcProvider{
+		verifier: provider.Verifier(&oidc.Config{ClientID: env.OIDCClientID}),
+		config: oauth2.Config{
+			ClientID:     env.OIDCClientID,
+			ClientSecret: env.OIDCClientSecret,
+			RedirectURL:  env.OIDCRedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		rolesClaim: rolesClaim,
+	}
+	log.Logger.Infof("OIDC sign-in enabled with issuer %v", env.OIDCIssuer)
+	return nil
+}
+
+func SetupOIDCRoutes(router chi.Router) {
+	if !IsOIDCEnabled() {
+		return
+	}
+	router.Get(oidcLoginPath, oidcLoginHandler)
+	router.Get(oidcCallbackPath, oidcCallbackHandler)
+}
+
+func oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	state, err := randomString()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	signedState, err := signSession(oidcState{State: state, Nonce: nonce})
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    signedState,
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		Expires:  time.Now().Add(10 * time.Minute),
+	})
+	http.Redirect(w, r, oidcAuth.config.AuthCodeURL(state, oidc.Nonce(nonce)), http.StatusFound)
+}
+
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	stateCookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		http.Error(w, "Missing sign-in state", http.StatusBadRequest)
+		return
+	}
+	expected := oidcState{}
+	if err := verifySession(stateCookie.Value, &expected); err != nil || expected.State != r.URL.Query().Get("state") {
+		http.Error(w, "Invalid sign-in state", http.StatusBadRequest)
+		return
+	}
+
+	token, err := oidcAuth.config.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		log.Logger.Errorf("OIDC code exchange failed: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	idToken, err := oidcAuth.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil || idToken.Nonce != expected.Nonce {
+		log.Logger.Errorf("OIDC token verification failed: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identity, err := identityFromToken(idToken)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	identity.Expiry = time.Now().Add(sessionDuration)
+
+	if err := setIdentityCookie(w, identity); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func identityFromToken(idToken *oidc.IDToken) (*Identity, error) {
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	identity := &Identity{
+		Subject: idToken.Subject,
+		Roles:   rolesFromClaim(claims[oidcAuth.rolesClaim]),
+		Expiry:  idToken.Expiry,
+	}
+	if email, ok := claims["email"].(string); ok {
+		identity.Email = email
+	}
+	return identity, nil
+}
+
+func rolesFromClaim(claim interface{}) []string {
+	roles := []string{}
+	switch value := claim.(type) {
+	case string:
+		roles = append(roles, strings.Fields(value)...)
+	case []interface{}:
+		for _, role := range value {
+			if roleName, ok := role.(string); ok {
+				roles = append(roles, roleName)
+			}
+		}
+	}
+	return roles
+}
+
+func setIdentityCookie(w http.ResponseWriter, identity *Identity) error {
+	value, err := signSession(identity)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     string(AuthKey_Identity),
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  identity.Expiry,
+		// Not sent along with requests made by other sites.
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+type sessionAuthenticator struct{}
+
+// Authenticate reads the signed session cookie issued at the end of the OIDC sign-in.
+func (a *sessionAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	cookie, err := r.Cookie(string(AuthKey_Identity))
+	if err != nil {
+		return nil, nil
+	}
+	identity := &Identity{}
+	if err := verifySession(cookie.Value, identity); err != nil {
+		return nil, err
+	}
+	if time.Now().After(identity.Expiry) {
+		return nil, errInvalidSession
+	}
+	return identity, nil
+}
+
+type bearerAuthenticator struct{}
+
+// Authenticate verifies an ID token from the OIDC provider sent as a bearer token, which
+// lets API clients use WhoDB without going through the browser sign-in.
+func (a *bearerAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil, nil
+	}
+	idToken, err := oidcAuth.verifier.Verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	return identityFromToken(idToken)
+}
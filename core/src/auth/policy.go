@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/tenant"
+)
+
+const defaultRoleName = "*"
+
+var (
+	ErrConnectionNotAllowed = errors.New("you are not allowed to access this connection")
+	ErrReadOnlyConnection   = errors.New("you only have read access to this connection")
+	ErrSchemaNotAllowed     = errors.New("you are not allowed to access this schema")
+	ErrDDLNotAllowed        = errors.New("you are not allowed to change the structure of this connection")
+)
+
+// Operation is a class of statements that roles allow.
+type Operation string
+
+const (
+	Operation_Read  Operation = "read"
+	Operation_Write Operation = "write"
+	// Operation_DDL changes the structure of the database, e.g. CREATE, ALTER or DROP.
+	Operation_DDL Operation = "ddl"
+)
+
+// Identity is the user making a request, as established by one of the authenticators.
+type Identity struct {
+	Subject string
+	Email   string
+	Roles   []string
+	Expiry  time.Time
+}
+
+// ConnectionRole grants access to the connections matching any of its patterns. Patterns
+// have the form "<type>://<hostname>/<database>" and support path.Match wildcards.
+type ConnectionRole struct {
+	Connections []string `json:"connections"`
+	// Schemas limits the role to the schemas matching any of these patterns; all when empty.
+	Schemas []string `json:"schemas"`
+	// Operations limits what the role allows; every operation when empty.
+	Operations []Operation `json:"operations"`
+	// ReadOnly only allows reads, whatever Operations says.
+	ReadOnly bool `json:"readOnly"`
+}
+
+func (r ConnectionRole) allowsSchema(schema string) bool {
+	return len(r.Schemas) == 0 || common.MatchesAnyPattern(r.Schemas, schema)
+}
+
+func (r ConnectionRole) allowsOperation(operation Operation) bool {
+	if r.ReadOnly {
+		return operation == Operation_Read
+	}
+	if len(r.Operations) == 0 {
+		return true
+	}
+	for _, allowed := range r.Operations {
+		if allowed == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Access is what an identity may do on a connection: anything any of its roles matching the
+// connection allows. A nil Access, for users who are not identified, allows everything.
+type Access struct {
+	roles []ConnectionRole
+}
+
+// Allows reports whether operation may be run on schema. An empty schema stands for one
+// that is not known, such as the default schema of an unqualified table, which only roles
+// without schema restrictions allow.
+func (a *Access) Allows(schema string, operation Operation) bool {
+	if a == nil {
+		return true
+	}
+	for _, role := range a.roles {
+		if (len(role.Schemas) == 0 || (len(schema) > 0 && role.allowsSchema(schema))) && role.allowsOperation(operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsSchema reports whether anything at all may be done on schema.
+func (a *Access) AllowsSchema(schema string) bool {
+	if a == nil {
+		return true
+	}
+	for _, role := range a.roles {
+		if role.allowsSchema(schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnly reports whether no schema may be written to or changed.
+func (a *Access) ReadOnly() bool {
+	if a == nil {
+		return false
+	}
+	for _, role := range a.roles {
+		if role.allowsOperation(Operation_Write) || role.allowsOperation(Operation_DDL) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns why operation may not be run on schema, or nil when it may.
+func (a *Access) Check(schema string, operation Operation) error {
+	if a.Allows(schema, operation) {
+		return nil
+	}
+	if operation == Operation_Read || !a.Allows(schema, Operation_Read) {
+		return ErrSchemaNotAllowed
+	}
+	if operation == Operation_DDL {
+		return ErrDDLNotAllowed
+	}
+	return ErrReadOnlyConnection
+}
+
+// CheckQuery checks a raw query against every schema it references, as the operation it is.
+// Queries referencing no schema, and calls to functions that may read any schema, are
+// checked against an unknown schema.
+func (a *Access) CheckQuery(query string) error {
+	return a.checkQuery(query, QueryOperation(query))
+}
+
+// RequiresReadOnly reports whether query is only allowed because it looks like a read. As
+// reads are told apart by their keywords alone, such queries must run in a read-only
+// transaction.
+func (a *Access) RequiresReadOnly(query string) bool {
+	if a == nil || QueryOperation(query) != Operation_Read {
+		return false
+	}
+	return a.checkQuery(query, Operation_Write) != nil || a.checkQuery(query, Operation_DDL) != nil
+}
+
+// builtInFunctions are the functions that only compute from their arguments. Any other
+// function may read tables that cannot be told from the query, such as
+// query_to_xml('SELECT * FROM secret.users', ...), so it counts as a reference to an
+// unknown schema.
+var builtInFunctions = []string{
+	"ABS", "ARRAY", "ARRAY_AGG", "ARRAY_LENGTH", "AVG", "CEIL", "CEILING", "CHAR_LENGTH", "COALESCE",
+	"CONCAT", "CONCAT_WS", "CONVERT", "COUNT", "DATE", "DATE_FORMAT", "DATE_PART", "DATE_TRUNC",
+	"DATETIME", "DAY", "DENSE_RANK", "EXTRACT", "FILTER", "FIRST_VALUE", "FLOOR", "GREATEST",
+	"GROUP_CONCAT", "HOUR", "IF", "IFNULL", "JSON_AGG", "JSON_BUILD_OBJECT", "JSON_EXTRACT",
+	"JSON_OBJECT", "JSONB_AGG", "JSONB_BUILD_OBJECT", "LAG", "LAST_VALUE", "LEAD", "LEAST", "LENGTH",
+	"LOWER", "LTRIM", "MAX", "MIN", "MINUTE", "MOD", "MONTH", "NOW", "NTILE", "NULLIF", "POSITION",
+	"POWER", "RANK", "REPLACE", "ROUND", "ROW_NUMBER", "RTRIM", "SQRT", "STRFTIME", "STRING_AGG",
+	"SUBSTR", "SUBSTRING", "SUM", "TIME", "TO_CHAR", "TO_DATE", "TO_TIMESTAMP", "TRIM", "UPPER", "YEAR",
+}
+
+func (a *Access) checkQuery(query string, operation Operation) error {
+	schemas := []string{}
+	for _, table := range lineage.ReferencedTables(query) {
+		if !common.ContainsString(schemas, table.Schema) {
+			schemas = append(schemas, table.Schema)
+		}
+	}
+	for _, function := range lineage.CalledFunctions(query) {
+		if (len(function.Schema) > 0 || !common.ContainsString(builtInFunctions, strings.ToUpper(function.Name))) && !common.ContainsString(schemas, "") {
+			schemas = append(schemas, "")
+		}
+	}
+	if len(schemas) == 0 {
+		schemas = append(schemas, "")
+	}
+	for _, schema := range schemas {
+		if err := a.Check(schema, operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryOperation returns the operation a raw query is: a read, a change of structure, or
+// otherwise a write.
+func QueryOperation(query string) Operation {
+	if common.IsReadOnlyQuery(query) {
+		return Operation_Read
+	}
+	if common.IsDDLQuery(query) {
+		return Operation_DDL
+	}
+	return Operation_Write
+}
+
+var roles = map[string]ConnectionRole{
+	defaultRoleName: {Connections: []string{"*"}},
+}
+
+func loadPolicy() error {
+	policyFile := env.AuthPolicyFile
+	if len(env.OIDCPolicyFile) > 0 {
+		if len(policyFile) > 0 && policyFile != env.OIDCPolicyFile {
+			return errors.New("WHODB_AUTH_POLICY_FILE and WHODB_OIDC_POLICY_FILE name different files, only set WHODB_AUTH_POLICY_FILE")
+		}
+		log.Logger.Warn("WHODB_OIDC_POLICY_FILE is deprecated, use WHODB_AUTH_POLICY_FILE instead")
+		policyFile = env.OIDCPolicyFile
+	}
+	if len(policyFile) == 0 {
+		return nil
+	}
+	content, err := os.ReadFile(policyFile)
+	if err != nil {
+		return fmt.Errorf("unable to read auth policy: %w", err)
+	}
+	policy := map[string]ConnectionRole{}
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return fmt.Errorf("invalid auth policy: %w", err)
+	}
+	roles = policy
+	return nil
+}
+
+// CheckConnectionAccess returns what the identity may do on the connection, from the roles
+// matching it, or an error when none does.
+func CheckConnectionAccess(identity *Identity, databaseType string, credentials *engine.Credentials) (*Access, error) {
+	if identity == nil {
+		return nil, nil
+	}
+	connections := connectionNames(databaseType, credentials)
+	access := &Access{}
+	for _, roleName := range append([]string{defaultRoleName}, identity.Roles...) {
+		role, ok := roles[roleName]
+		if !ok || !matchesEveryConnection(role.Connections, connections) {
+			continue
+		}
+		access.roles = append(access.roles, role)
+	}
+	if len(access.roles) == 0 {
+		return nil, ErrConnectionNotAllowed
+	}
+	return access, nil
+}
+
+// CheckTenantAccess returns the tenant the identity uses the connection as, which must be
+// one of the connections of that tenant.
+func CheckTenantAccess(identity *Identity, databaseType string, credentials *engine.Credentials) (string, error) {
+	if identity == nil {
+		return "", tenant.ErrNoTenant
+	}
+	identityTenant, err := tenant.ForRoles(identity.Roles)
+	if err != nil {
+		return "", err
+	}
+	for _, connection := range connectionNames(databaseType, credentials) {
+		if !identityTenant.AllowsConnection(connection) {
+			return "", ErrConnectionNotAllowed
+		}
+	}
+	return identityTenant.Name, nil
+}
+
+func connectionName(databaseType string, credentials *engine.Credentials) string {
+	return fmt.Sprintf("%v://%v/%v", databaseType, credentials.Hostname, credentials.Database)
+}
+
+// connectionNames names the connection and, when it has one, its read replica, which is
+// reached with the same credentials and so must be allowed as well.
+func connectionNames(databaseType string, credentials *engine.Credentials) []string {
+	connections := []string{connectionName(databaseType, credentials)}
+	if replica := engine.ReadReplica(credentials); replica != nil {
+		connections = append(connections, connectionName(databaseType, replica))
+	}
+	return connections
+}
+
+func matchesEveryConnection(patterns []string, connections []string) bool {
+	for _, connection := range connections {
+		if !common.MatchesAnyPattern(patterns, connection) {
+			return false
+		}
+	}
+	return true
+}
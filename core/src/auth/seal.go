@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var errInvalidSealed = errors.New("invalid sealed value")
+
+// sealCipher encrypts with a key derived from the session secret, so that values sealed by
+// one server can be opened by the others and after a restart, as long as it is set.
+func sealCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256(append([]byte("whodb-seal\x00"), getSessionSecret()...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts a value kept on the server, such as the credentials of a scheduled query.
+func Seal(plaintext []byte) (string, error) {
+	aead, err := sealCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Unseal decrypts a value encrypted with Seal.
+func Unseal(sealed string) ([]byte, error) {
+	aead, err := sealCipher()
+	if err != nil {
+		return nil, err
+	}
+	content, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(content) < aead.NonceSize() {
+		return nil, errInvalidSealed
+	}
+	plaintext, err := aead.Open(nil, content[:aead.NonceSize()], content[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errInvalidSealed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+var errInvalidSession = errors.New("invalid session")
+
+var (
+	sessionSecret     []byte
+	sessionSecretOnce sync.Once
+)
+
+func getSessionSecret() []byte {
+	sessionSecretOnce.Do(func() {
+		if len(env.SessionSecret) > 0 {
+			sessionSecret = []byte(env.SessionSecret)
+			return
+		}
+		sessionSecret = make([]byte, 32)
+		if _, err := rand.Read(sessionSecret); err != nil {
+			panic(err)
+		}
+		log.Logger.Warn("WHODB_SESSION_SECRET is not set, sessions will not survive a restart")
+	})
+	return sessionSecret
+}
+
+// signSession encodes value as JSON and appends an HMAC so it can be stored in a cookie
+// without the client being able to tamper with it.
+func signSession(value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(content)
+	mac := hmac.New(sha256.New, getSessionSecret())
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifySession(signed string, value interface{}) error {
+	payload, signature, found := strings.Cut(signed, ".")
+	if !found {
+		return errInvalidSession
+	}
+	expectedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return errInvalidSession
+	}
+	mac := hmac.New(sha256.New, getSessionSecret())
+	mac.Write([]byte(payload))
+	if !hmac.Equal(mac.Sum(nil), expectedSignature) {
+		return errInvalidSession
+	}
+	content, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return errInvalidSession
+	}
+	return json.Unmarshal(content, value)
+}
+
+func randomString() (string, error) {
+	buffer := make([]byte, 24)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const backendTimeout = 2 * time.Second
+
+// Backend keeps cache entries and counters outside the server, so that servers behind a load
+// balancer share them.
+type Backend interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	// Increment adds delta to a counter that expires ttl after it is created, and returns its
+	// new value.
+	Increment(key string, delta int64, ttl time.Duration) (int64, error)
+	// Claim sets key for ttl unless it is already set, and reports whether it did, so that
+	// only one server acts on what every server sees.
+	Claim(key string, ttl time.Duration) (bool, error)
+}
+
+var (
+	backendMutex sync.RWMutex
+	backend      Backend
+)
+
+// SetBackend moves every cache to shared, or back to memory when it is nil.
+func SetBackend(shared Backend) {
+	backendMutex.Lock()
+	defer backendMutex.Unlock()
+	backend = shared
+}
+
+// GetBackend returns the shared backend, or nil when caches are kept in memory.
+func GetBackend() Backend {
+	backendMutex.RLock()
+	defer backendMutex.RUnlock()
+	return backend
+}
+
+// RedisBackend keeps cache entries and counters in Redis, under keys starting with whodb:.
+type RedisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisBackend connects to the Redis server at url, e.g. redis://:password@host:6379/0.
+func NewRedisBackend(url string) (*RedisBackend, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisBackend{client: client}, nil
+}
+
+func (r *RedisBackend) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	value, err := r.client.Get(ctx, "whodb:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *RedisBackend) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	return r.client.Set(ctx, "whodb:"+key, value, ttl).Err()
+}
+
+func (r *RedisBackend) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	value, err := r.client.IncrBy(ctx, "whodb:"+key, delta).Result()
+	if err != nil {
+		return 0, err
+	}
+	// The counter was just created; setting the expiry only then keeps its window fixed.
+	if value == delta {
+		if err := r.client.Expire(ctx, "whodb:"+key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return value, nil
+}
+
+func (r *RedisBackend) Claim(key string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	return r.client.SetNX(ctx, "whodb:"+key, 1, ttl).Result()
+}
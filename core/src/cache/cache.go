@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/log"
+)
+
+type entry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// Cache is a key/value cache whose entries expire after a fixed time to live. Entries are
+// kept in memory, or in the shared backend when one is set, so that every server sees them.
+type Cache[T any] struct {
+	name    string
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]entry[T]
+}
+
+// New creates a cache whose entries are stored under name in the shared backend.
+func New[T any](name string, ttl time.Duration) *Cache[T] {
+	return &Cache[T]{
+		name:    name,
+		ttl:     ttl,
+		entries: map[string]entry[T]{},
+	}
+}
+
+func (c *Cache[T]) Get(key string) (T, bool) {
+	var value T
+	if backend := GetBackend(); backend != nil {
+		content, ok, err := backend.Get(c.sharedKey(key))
+		if err == nil && ok {
+			err = json.Unmarshal(content, &value)
+		}
+		if err != nil {
+			log.Logger.Warnf("Unable to read %v from the shared cache: %v", c.name, err)
+			return value, false
+		}
+		return value, ok
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cached, ok := c.entries[key]
+	if !ok {
+		return value, false
+	}
+	if time.Now().After(cached.expires) {
+		delete(c.entries, key)
+		return value, false
+	}
+	return cached.value, true
+}
+
+func (c *Cache[T]) Set(key string, value T) {
+	if backend := GetBackend(); backend != nil {
+		content, err := json.Marshal(value)
+		if err == nil {
+			err = backend.Set(c.sharedKey(key), content, c.ttl)
+		}
+		if err != nil {
+			log.Logger.Warnf("Unable to write %v to the shared cache: %v", c.name, err)
+		}
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	for key, cached := range c.entries {
+		if now.After(cached.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[key] = entry[T]{value: value, expires: now.Add(c.ttl)}
+}
+
+// GetOrLoad returns the cached value for key, calling load to compute it on a miss.
+// Errors are not cached.
+func (c *Cache[T]) GetOrLoad(key string, load func() (T, error)) (T, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
+
+func (c *Cache[T]) sharedKey(key string) string {
+	return fmt.Sprintf("cache:%v:%v", c.name, key)
+}
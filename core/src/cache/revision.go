@@ -0,0 +1,31 @@
+package cache
+
+import "time"
+
+// revisionTTL keeps revisions well past the time servers take to notice them. A revision that
+// expires starts over, which servers still see as a change.
+const revisionTTL = 7 * 24 * time.Hour
+
+// Revision returns how many times the state called name changed on any server, so that
+// servers keeping a copy of it know when to reload it. It is 0 without a shared backend.
+func Revision(name string) (int64, error) {
+	backend := GetBackend()
+	if backend == nil {
+		return 0, nil
+	}
+	return backend.Increment(revisionKey(name), 0, revisionTTL)
+}
+
+// Touch records a change of the state called name for the other servers.
+func Touch(name string) error {
+	backend := GetBackend()
+	if backend == nil {
+		return nil
+	}
+	_, err := backend.Increment(revisionKey(name), 1, revisionTTL)
+	return err
+}
+
+func revisionKey(name string) string {
+	return "revision:" + name
+}
@@ -0,0 +1,112 @@
+package cell
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/template"
+)
+
+var ErrAmbiguousKey = errors.New("key matches more than one row")
+
+// Truncated is a value cut short in a result, at Row and Column, whose full value is Size
+// bytes long.
+type Truncated struct {
+	Row    int
+	Column int
+	Size   int
+}
+
+// Truncate cuts the values of result longer than maxBytes, without splitting a character,
+// and returns where they were. Nothing is cut when maxBytes is 0 or less.
+func Truncate(result *engine.GetRowsResult, maxBytes int) []Truncated {
+	truncated := []Truncated{}
+	if maxBytes <= 0 {
+		return truncated
+	}
+	for i, row := range result.Rows {
+		for j, value := range row {
+			if len(value) <= maxBytes {
+				continue
+			}
+			end := maxBytes
+			for end > 0 && !utf8.RuneStart(value[end]) {
+				end--
+			}
+			row[j] = value[:end]
+			truncated = append(truncated, Truncated{Row: i, Column: j, Size: len(value)})
+		}
+	}
+	return truncated
+}
+
+// Fetch reads the full value of a column in the row identified by key, which maps the
+// columns of the primary key to their values as shown in results. On MongoDB, the column is
+// document and the key is _id, with its value in Extended JSON, e.g. {"$oid": "..."}.
+// A NULL value is returned as nil.
+func Fetch(plugin *engine.Plugin, config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string) (*string, error) {
+	if len(key) == 0 {
+		return nil, errors.New("key is required")
+	}
+	filter, err := keyFilter(plugin.Type, key)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxRows = 0
+	result, err := plugin.GetRows(config, schema, storageUnit, filter, nil, 2, 0)
+	if err != nil {
+		return nil, err
+	}
+	switch len(result.Rows) {
+	case 0:
+		return nil, engine.ErrRowNotFound
+	case 1:
+	default:
+		return nil, ErrAmbiguousKey
+	}
+	for i, resultColumn := range result.Columns {
+		if resultColumn.Name != column {
+			continue
+		}
+		if len(result.Nulls) > 0 && result.Nulls[0][i] {
+			return nil, nil
+		}
+		return &result.Rows[0][i], nil
+	}
+	return nil, fmt.Errorf("column %v was not found in %v", column, storageUnit)
+}
+
+// keyFilter writes the condition selecting the row of key, in the filter syntax of
+// databaseType.
+func keyFilter(databaseType engine.DatabaseType, key map[string]string) (string, error) {
+	columns := make([]string, 0, len(key))
+	for column := range key {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	switch databaseType {
+	case engine.DatabaseType_Redis:
+		return "", errors.ErrUnsupported
+	case engine.DatabaseType_MongoDB:
+		if len(key) != 1 || len(key["_id"]) == 0 {
+			return "", errors.New("documents are identified by _id alone")
+		}
+		return fmt.Sprintf(`{"_id": %v}`, key["_id"]), nil
+	}
+
+	conditions := []string{}
+	variables := []template.Variable{}
+	for i, column := range columns {
+		conditions = append(conditions, fmt.Sprintf("{{column%d}} = {{value%d}}", i, i))
+		variables = append(variables,
+			template.Variable{Name: fmt.Sprintf("column%d", i), Type: template.Type_Identifier, Value: column},
+			template.Variable{Name: fmt.Sprintf("value%d", i), Type: template.Type_Text, Value: key[column]},
+		)
+	}
+	return template.Render(databaseType, strings.Join(conditions, " AND "), variables)
+}
@@ -4,4 +4,7 @@ type RouterKey string
 
 const (
 	RouterKey_ResponseWriter RouterKey = "ResponseWriter"
+	// RouterKey_PeerAddress holds the address of the connecting peer, before RealIP
+	// replaces the remote address with the client address forwarded by a proxy.
+	RouterKey_PeerAddress RouterKey = "PeerAddress"
 )
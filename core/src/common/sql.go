@@ -0,0 +1,103 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+)
+
+var readOnlyStatements = []string{"SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "VALUES", "TABLE"}
+
+var writeKeywords = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "CREATE", "ALTER", "DROP", "TRUNCATE", "GRANT", "REVOKE", "CALL", "COPY", "REPLACE", "INTO", "LOCK", "VACUUM", "ATTACH", "DETACH"}
+
+// sideEffectFunctions act outside the transaction they are called in, so that a read-only
+// transaction does not stop them, e.g. by ending other sessions or through another connection.
+var sideEffectFunctions = []string{"PG_TERMINATE_BACKEND", "PG_CANCEL_BACKEND", "PG_RELOAD_CONF", "PG_ROTATE_LOGFILE", "PG_FILE_WRITE", "LO_EXPORT", "DBLINK", "DBLINK_EXEC", "DBLINK_SEND_QUERY", "PG_ADVISORY_LOCK"}
+
+// SQLKeywords returns the upper-cased words of a SQL statement, skipping string literals,
+// quoted identifiers and comments so that they cannot be mistaken for keywords.
+// Backslashes are taken literally, as in standard SQL strings.
+func SQLKeywords(query string) []string {
+	return sqlKeywords(query, false)
+}
+
+// sqlKeywords is SQLKeywords, with backslashes escaping the next character of string
+// literals when backslashEscapes is set, as in MySQL and Postgres' E'...' strings.
+func sqlKeywords(query string, backslashEscapes bool) []string {
+	keywords := []string{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if backslashEscapes && r == '\'' && runes[i] == '\\' {
+					i++
+				}
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+		case r == ';':
+			keywords = append(keywords, ";")
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for ; i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_'); i++ {
+			}
+			keywords = append(keywords, strings.ToUpper(string(runes[start:i])))
+			i--
+		}
+	}
+	return keywords
+}
+
+// IsReadOnlyQuery reports whether the query is a single statement that only reads data.
+// It errs on the side of caution: anything it cannot classify is treated as a write, and
+// the query must read the same whether backslashes escape quotes or not.
+// It is a hint that cannot see what functions do: read-only access is enforced by running
+// queries in read-only transactions (see engine.PluginConfig.ReadOnly).
+func IsReadOnlyQuery(query string) bool {
+	return isReadOnlyStatement(sqlKeywords(query, false)) && isReadOnlyStatement(sqlKeywords(query, true))
+}
+
+func isReadOnlyStatement(keywords []string) bool {
+	for len(keywords) > 0 && keywords[len(keywords)-1] == ";" {
+		keywords = keywords[:len(keywords)-1]
+	}
+	if len(keywords) == 0 || !ContainsString(readOnlyStatements, keywords[0]) {
+		return false
+	}
+	for _, keyword := range keywords {
+		if keyword == ";" || ContainsString(writeKeywords, keyword) || ContainsString(sideEffectFunctions, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+var ddlStatements = []string{"CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT", "GRANT", "REVOKE"}
+
+// IsDDLQuery reports whether any statement of query changes the structure of the database
+// rather than its rows, such as CREATE TABLE or DROP INDEX, whether backslashes escape
+// quotes or not.
+func IsDDLQuery(query string) bool {
+	return hasDDLStatement(sqlKeywords(query, false)) || hasDDLStatement(sqlKeywords(query, true))
+}
+
+func hasDDLStatement(keywords []string) bool {
+	statementStart := true
+	for _, keyword := range keywords {
+		if keyword == ";" {
+			statementStart = true
+			continue
+		}
+		if statementStart && ContainsString(ddlStatements, keyword) {
+			return true
+		}
+		statementStart = false
+	}
+	return false
+}
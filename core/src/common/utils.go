@@ -1,5 +1,7 @@
 package common
 
+import "path"
+
 func ContainsString(slice []string, element string) bool {
 	for _, item := range slice {
 		if item == element {
@@ -8,3 +10,16 @@ func ContainsString(slice []string, element string) bool {
 	}
 	return false
 }
+
+// MatchesAnyPattern reports whether value matches one of the path.Match patterns, "*" matching anything.
+func MatchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -1,28 +1,52 @@
 package engine
 
-import "github.com/clidey/whodb/core/graph/model"
+import (
+	"sync"
+
+	"github.com/clidey/whodb/core/graph/model"
+)
 
 type DatabaseType string
 
 const (
-	DatabaseType_Postgres = "Postgres"
-	DatabaseType_MySQL    = "MySQL"
-	DatabaseType_Sqlite3  = "Sqlite3"
-	DatabaseType_MongoDB  = "MongoDB"
-	DatabaseType_Redis    = "Redis"
+	DatabaseType_Postgres  = "Postgres"
+	DatabaseType_MySQL     = "MySQL"
+	DatabaseType_Sqlite3   = "Sqlite3"
+	DatabaseType_MongoDB   = "MongoDB"
+	DatabaseType_Redis     = "Redis"
+	DatabaseType_Snowflake = "Snowflake"
+	DatabaseType_BigQuery  = "BigQuery"
+	DatabaseType_Oracle    = "Oracle"
 )
 
 type Engine struct {
-	plugins map[DatabaseType]*Plugin
+	plugins        map[DatabaseType]*Plugin
+	circuitBreaker CircuitBreaker
+	quotaEnforcer  QuotaEnforcer
+	resultCache    *ResultCache
+	queryHooks     []QueryHooks
+	metrics        Metrics
+
+	slowQueries     *SlowQueryLog
+	slowQueriesOnce sync.Once
 }
 
 func (e *Engine) RegistryPlugin(plugin *Plugin) {
 	if e.plugins == nil {
 		e.plugins = map[DatabaseType]*Plugin{}
 	}
+	plugin.PluginFunctions = newGuardedPlugin(e, plugin.Type, plugin.PluginFunctions)
 	e.plugins[plugin.Type] = plugin
 }
 
+func (e *Engine) SetCircuitBreaker(circuitBreaker CircuitBreaker) {
+	e.circuitBreaker = circuitBreaker
+}
+
+func (e *Engine) SetQuotaEnforcer(quotaEnforcer QuotaEnforcer) {
+	e.quotaEnforcer = quotaEnforcer
+}
+
 func (e *Engine) Choose(databaseType DatabaseType) *Plugin {
 	return e.plugins[databaseType]
 }
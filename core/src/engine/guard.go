@@ -0,0 +1,510 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+var (
+	ErrPluginPanic       = errors.New("plugin panicked")
+	ErrPluginCircuitOpen = errors.New("plugin is temporarily disabled after repeated failures")
+)
+
+// CircuitBreaker is consulted before every plugin call and notified whenever a call panics,
+// so a misbehaving plugin can be short-circuited instead of being hit again and again.
+type CircuitBreaker interface {
+	Allow(databaseType DatabaseType) bool
+	RecordPanic(databaseType DatabaseType, method string, recovered interface{})
+}
+
+type panicCircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mutex     sync.Mutex
+	failures  map[DatabaseType][]time.Time
+	openUntil map[DatabaseType]time.Time
+}
+
+// NewPanicCircuitBreaker opens the circuit for a plugin once it panics threshold times within cooldown,
+// and keeps it open for cooldown before letting calls through again.
+func NewPanicCircuitBreaker(threshold int, cooldown time.Duration) CircuitBreaker {
+	return &panicCircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		failures:  map[DatabaseType][]time.Time{},
+		openUntil: map[DatabaseType]time.Time{},
+	}
+}
+
+func (b *panicCircuitBreaker) Allow(databaseType DatabaseType) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return time.Now().After(b.openUntil[databaseType])
+}
+
+func (b *panicCircuitBreaker) RecordPanic(databaseType DatabaseType, method string, recovered interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	recent := []time.Time{}
+	for _, failure := range b.failures[databaseType] {
+		if now.Sub(failure) < b.cooldown {
+			recent = append(recent, failure)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) >= b.threshold {
+		b.openUntil[databaseType] = now.Add(b.cooldown)
+		recent = nil
+		log.LogFields(log.Fields{
+			"type": databaseType,
+		}).Warnf("Circuit opened for %v plugin for %v", databaseType, b.cooldown)
+	}
+	b.failures[databaseType] = recent
+}
+
+// guardedPlugin wraps every plugin call with panic recovery so that a single bad
+// driver response surfaces as an error instead of crashing the server.
+type guardedPlugin struct {
+	functions    PluginFunctions
+	databaseType DatabaseType
+	engine       *Engine
+}
+
+func newGuardedPlugin(engine *Engine, databaseType DatabaseType, functions PluginFunctions) PluginFunctions {
+	return &guardedPlugin{
+		functions:    functions,
+		databaseType: databaseType,
+		engine:       engine,
+	}
+}
+
+func (g *guardedPlugin) allow() error {
+	if g.engine.circuitBreaker != nil && !g.engine.circuitBreaker.Allow(g.databaseType) {
+		return fmt.Errorf("%w: %v", ErrPluginCircuitOpen, g.databaseType)
+	}
+	return nil
+}
+
+func (g *guardedPlugin) recoverPanic(method string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	log.LogFields(log.Fields{
+		"type":   g.databaseType,
+		"method": method,
+		"stack":  string(debug.Stack()),
+	}).Errorf("Recovered from plugin panic: %v", recovered)
+	if g.engine.circuitBreaker != nil {
+		g.engine.circuitBreaker.RecordPanic(g.databaseType, method, recovered)
+	}
+	if err != nil {
+		*err = fmt.Errorf("%w: %v %v: %v", ErrPluginPanic, g.databaseType, method, recovered)
+	}
+}
+
+func (g *guardedPlugin) GetDatabases() (databases []string, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetDatabases", &err)
+	return g.functions.GetDatabases()
+}
+
+func (g *guardedPlugin) IsAvailable(config *PluginConfig) (available bool) {
+	if err := g.allow(); err != nil {
+		return false
+	}
+	defer g.recoverPanic("IsAvailable", nil)
+	return g.functions.IsAvailable(config)
+}
+
+func (g *guardedPlugin) GetSchema(config *PluginConfig) (schemas []string, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetSchema", &err)
+	return g.functions.GetSchema(config)
+}
+
+func (g *guardedPlugin) GetStorageUnits(config *PluginConfig, schema string) (storageUnits []StorageUnit, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetStorageUnits", &err)
+	return g.functions.GetStorageUnits(config, schema)
+}
+
+func (g *guardedPlugin) UpdateStorageUnit(config *PluginConfig, schema string, storageUnit string, values map[string]string) (status bool, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return false, err
+	}
+	after, err := g.runHooks(config, updateStatement(schema, storageUnit, valueColumns(values), ""))
+	if err != nil {
+		return false, err
+	}
+	defer func() { after(nil, err) }()
+	defer g.recoverPanic("UpdateStorageUnit", &err)
+	defer g.invalidateResults(config, storageUnit)
+	return g.functions.UpdateStorageUnit(config, schema, storageUnit, values)
+}
+
+func (g *guardedPlugin) BatchUpdateStorageUnit(config *PluginConfig, schema string, storageUnit string, where string, values map[string]string) (affectedRows int64, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return 0, err
+	}
+	after, err := g.runHooks(config, updateStatement(schema, storageUnit, valueColumns(values), where))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { after(nil, err) }()
+	defer g.recoverPanic("BatchUpdateStorageUnit", &err)
+	defer g.invalidateResults(config, storageUnit)
+	return g.functions.BatchUpdateStorageUnit(config, schema, storageUnit, where, values)
+}
+
+func (g *guardedPlugin) GetRows(config *PluginConfig, schema string, storageUnit string, where string, sort []SortCondition, pageSize int, pageOffset int) (result *GetRowsResult, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	after, err := g.runHooks(config, selectStatement(schema, storageUnit, "*", where))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { after(result, err) }()
+	defer g.observe(config, "GetRows", describeRows(schema, storageUnit, where), time.Now())
+	defer g.measure("GetRows", g.queryStarted(), &err)
+	defer g.recoverPanic("GetRows", &err)
+	result, err = g.functions.GetRows(config, schema, storageUnit, where, sort, pageSize, pageOffset)
+	g.recordRows(config, result)
+	return result, err
+}
+
+func (g *guardedPlugin) GetGraph(config *PluginConfig, schema string) (graph []GraphUnit, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetGraph", &err)
+	return g.functions.GetGraph(config, schema)
+}
+
+func (g *guardedPlugin) RawExecute(config *PluginConfig, query string) (result *GetRowsResult, err error) {
+	// Cached results are charged like any other query.
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	// Each set of hooks sees the statement as rewritten by the ones before it, and is told
+	// how it went only if it let it through.
+	for _, queryHooks := range g.engine.queryHooks {
+		query, err = queryHooks.Before(g.databaseType, config, query)
+		if err != nil {
+			return nil, err
+		}
+		defer func(queryHooks QueryHooks, query string, startedAt time.Time) {
+			queryHooks.After(g.databaseType, config, query, result, err, time.Since(startedAt))
+		}(queryHooks, query, time.Now())
+	}
+	resultCache := g.engine.resultCache
+	// Streamed rows are not kept, so there is nothing to cache.
+	cacheable := resultCache != nil && config.ResultCacheTTL > 0 && config.StreamRows == nil && common.IsReadOnlyQuery(query)
+	if cacheable {
+		cached, ok := resultCache.Get(g.databaseType, config, query)
+		if g.engine.metrics != nil {
+			g.engine.metrics.CacheLookup("results", ok)
+		}
+		if ok {
+			return cached, nil
+		}
+	}
+	defer g.observe(config, "RawExecute", query, time.Now())
+	defer g.measure("RawExecute", g.queryStarted(), &err)
+	defer g.recoverPanic("RawExecute", &err)
+	if resultCache != nil && !common.IsReadOnlyQuery(query) {
+		// Invalidated even when the write fails, as it may have partly gone through.
+		defer resultCache.InvalidateQuery(g.databaseType, config, query)
+	}
+	result, err = g.functions.RawExecute(config, query)
+	g.recordRows(config, result)
+	if cacheable && err == nil {
+		resultCache.Set(g.databaseType, config, query, result)
+	}
+	return result, err
+}
+
+// valueColumns returns the columns an edit sets.
+func valueColumns(values map[string]string) []string {
+	columns := []string{}
+	for column := range values {
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// invalidateResults drops the cached results reading a storage unit after it is written to.
+func (g *guardedPlugin) invalidateResults(config *PluginConfig, storageUnit string) {
+	if g.engine.resultCache != nil {
+		g.engine.resultCache.Invalidate(g.databaseType, config, []string{strings.ToLower(storageUnit)})
+	}
+}
+
+func (g *guardedPlugin) SearchStorageUnits(config *PluginConfig, schema string, search string, limit int) (hits []SearchHit, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("SearchStorageUnits", &err)
+	return g.functions.SearchStorageUnits(config, schema, search, limit)
+}
+
+func (g *guardedPlugin) EstimateRowCount(config *PluginConfig, schema string, storageUnit string) (count int64, err error) {
+	if err := g.allow(); err != nil {
+		return 0, err
+	}
+	defer g.recoverPanic("EstimateRowCount", &err)
+	return g.functions.EstimateRowCount(config, schema, storageUnit)
+}
+
+func (g *guardedPlugin) CountRows(config *PluginConfig, schema string, storageUnit string, where string) (count int64, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return 0, err
+	}
+	after, err := g.runHooks(config, selectStatement(schema, storageUnit, "COUNT(*)", where))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { after(nil, err) }()
+	defer g.observe(config, "CountRows", describeRows(schema, storageUnit, where), time.Now())
+	defer g.measure("CountRows", g.queryStarted(), &err)
+	defer g.recoverPanic("CountRows", &err)
+	return g.functions.CountRows(config, schema, storageUnit, where)
+}
+
+func (g *guardedPlugin) GetStorageUnitStats(config *PluginConfig, schema string) (stats map[string][]Record, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetStorageUnitStats", &err)
+	return g.functions.GetStorageUnitStats(config, schema)
+}
+
+func (g *guardedPlugin) GetRoutines(config *PluginConfig, schema string) (routines []Routine, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetRoutines", &err)
+	return g.functions.GetRoutines(config, schema)
+}
+
+func (g *guardedPlugin) ExecuteRoutine(config *PluginConfig, schema string, routine string, arguments []string) (result *GetRowsResult, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	after, err := g.runHooks(config, callStatement(schema, routine, arguments))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { after(result, err) }()
+	defer g.observe(config, "ExecuteRoutine", fmt.Sprintf("%v.%v(%v)", schema, routine, strings.Join(arguments, ", ")), time.Now())
+	defer g.measure("ExecuteRoutine", g.queryStarted(), &err)
+	defer g.recoverPanic("ExecuteRoutine", &err)
+	// Routines can write to any table.
+	if g.engine.resultCache != nil {
+		defer g.engine.resultCache.Invalidate(g.databaseType, config, nil)
+	}
+	result, err = g.functions.ExecuteRoutine(config, schema, routine, arguments)
+	g.recordRows(config, result)
+	return result, err
+}
+
+func (g *guardedPlugin) GetColumnConstraints(config *PluginConfig, schema string, storageUnit string) (constraints []ColumnConstraint, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetColumnConstraints", &err)
+	return g.functions.GetColumnConstraints(config, schema, storageUnit)
+}
+
+func (g *guardedPlugin) GetRowsAfter(config *PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (result *GetRowsResult, lastKey string, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, "", err
+	}
+	after, err := g.runHooks(config, selectStatement(schema, storageUnit, "*", where))
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { after(result, err) }()
+	defer g.observe(config, "GetRowsAfter", describeRows(schema, storageUnit, where), time.Now())
+	defer g.measure("GetRowsAfter", g.queryStarted(), &err)
+	defer g.recoverPanic("GetRowsAfter", &err)
+	result, lastKey, err = g.functions.GetRowsAfter(config, schema, storageUnit, where, afterKey, pageSize)
+	g.recordRows(config, result)
+	return result, lastKey, err
+}
+
+func (g *guardedPlugin) GetViewDefinition(config *PluginConfig, schema string, view string) (definition *ViewDefinition, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetViewDefinition", &err)
+	return g.functions.GetViewDefinition(config, schema, view)
+}
+
+func (g *guardedPlugin) GetForeignKeys(config *PluginConfig, schema string) (foreignKeys []ForeignKey, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetForeignKeys", &err)
+	return g.functions.GetForeignKeys(config, schema)
+}
+
+func (g *guardedPlugin) CreateDatabase(config *PluginConfig, name string) (err error) {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	defer g.recoverPanic("CreateDatabase", &err)
+	return g.functions.CreateDatabase(config, name)
+}
+
+func (g *guardedPlugin) RunMaintenance(config *PluginConfig, action MaintenanceAction) (messages []string, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	defer g.observe(config, "RunMaintenance", string(action), time.Now())
+	defer g.measure("RunMaintenance", g.queryStarted(), &err)
+	defer g.recoverPanic("RunMaintenance", &err)
+	return g.functions.RunMaintenance(config, action)
+}
+
+func (g *guardedPlugin) GetActivity(config *PluginConfig) (activity *Activity, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetActivity", &err)
+	return g.functions.GetActivity(config)
+}
+
+func (g *guardedPlugin) KillSession(config *PluginConfig, id string) (err error) {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	defer g.recoverPanic("KillSession", &err)
+	return g.functions.KillSession(config, id)
+}
+
+func (g *guardedPlugin) GetMaterializedViews(config *PluginConfig, schema string) (views []MaterializedView, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetMaterializedViews", &err)
+	return g.functions.GetMaterializedViews(config, schema)
+}
+
+func (g *guardedPlugin) RefreshMaterializedView(config *PluginConfig, schema string, view string) (err error) {
+	if err := g.allowQuery(config); err != nil {
+		return err
+	}
+	defer g.observe(config, "RefreshMaterializedView", view, time.Now())
+	defer g.measure("RefreshMaterializedView", g.queryStarted(), &err)
+	defer g.recoverPanic("RefreshMaterializedView", &err)
+	defer g.invalidateResults(config, view)
+	return g.functions.RefreshMaterializedView(config, schema, view)
+}
+
+func (g *guardedPlugin) GetDDL(config *PluginConfig, schema string, storageUnit string) (ddl string, err error) {
+	if err := g.allow(); err != nil {
+		return "", err
+	}
+	defer g.recoverPanic("GetDDL", &err)
+	return g.functions.GetDDL(config, schema, storageUnit)
+}
+
+func (g *guardedPlugin) ExpireKeys(config *PluginConfig, pattern string, ttl time.Duration, dryRun bool) (keys []string, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("ExpireKeys", &err)
+	return g.functions.ExpireKeys(config, pattern, ttl, dryRun)
+}
+
+func (g *guardedPlugin) GetDatabaseUsers(config *PluginConfig) (users []DatabaseUser, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetDatabaseUsers", &err)
+	return g.functions.GetDatabaseUsers(config)
+}
+
+func (g *guardedPlugin) CreateDatabaseUser(config *PluginConfig, name string, password string) (err error) {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	defer g.recoverPanic("CreateDatabaseUser", &err)
+	return g.functions.CreateDatabaseUser(config, name, password)
+}
+
+func (g *guardedPlugin) GrantPrivilege(config *PluginConfig, user string, grant Grant) (err error) {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	defer g.recoverPanic("GrantPrivilege", &err)
+	return g.functions.GrantPrivilege(config, user, grant)
+}
+
+func (g *guardedPlugin) RevokePrivilege(config *PluginConfig, user string, grant Grant) (err error) {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	defer g.recoverPanic("RevokePrivilege", &err)
+	return g.functions.RevokePrivilege(config, user, grant)
+}
+
+func (g *guardedPlugin) ReadBlob(config *PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (written int64, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return 0, err
+	}
+	after, err := g.runHooks(config, selectStatement(schema, storageUnit, quoteHookIdentifier(column), ""))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { after(nil, err) }()
+	defer g.recoverPanic("ReadBlob", &err)
+	return g.functions.ReadBlob(config, schema, storageUnit, column, key, w)
+}
+
+func (g *guardedPlugin) WriteBlob(config *PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (written int64, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return 0, err
+	}
+	after, err := g.runHooks(config, updateStatement(schema, storageUnit, []string{column}, ""))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { after(nil, err) }()
+	defer g.recoverPanic("WriteBlob", &err)
+	defer g.invalidateResults(config, storageUnit)
+	return g.functions.WriteBlob(config, schema, storageUnit, column, key, r)
+}
+
+func (g *guardedPlugin) InferSchema(config *PluginConfig, schema string, storageUnit string, sampleSize int) (fields []InferredField, err error) {
+	if err := g.allowQuery(config); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("InferSchema", &err)
+	return g.functions.InferSchema(config, schema, storageUnit, sampleSize)
+}
+
+func (g *guardedPlugin) GetIndexes(config *PluginConfig, schema string) (indexes []Index, err error) {
+	if err := g.allow(); err != nil {
+		return nil, err
+	}
+	defer g.recoverPanic("GetIndexes", &err)
+	return g.functions.GetIndexes(config, schema)
+}
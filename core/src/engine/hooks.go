@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrBlockedByHook = errors.New("blocked by hook")
+
+// QueryHooks run around every raw statement. Before may rewrite the statement or reject it
+// with an error wrapping ErrBlockedByHook; After is told how it went.
+// Reads, edits, routines and blobs are built by the plugins, so the hooks are run on a
+// statement standing for them instead, which they can reject but not rewrite.
+type QueryHooks interface {
+	Before(databaseType DatabaseType, config *PluginConfig, query string) (string, error)
+	After(databaseType DatabaseType, config *PluginConfig, query string, result *GetRowsResult, err error, duration time.Duration)
+}
+
+// AddQueryHooks runs queryHooks after the ones added before it.
+func (e *Engine) AddQueryHooks(queryHooks QueryHooks) {
+	e.queryHooks = append(e.queryHooks, queryHooks)
+}
+
+// runHooks runs the hooks before a statement built by the plugin, and returns the function
+// telling the hooks that let it through how it went.
+func (g *guardedPlugin) runHooks(config *PluginConfig, statement string) (func(result *GetRowsResult, err error), error) {
+	startedAt := time.Now()
+	passed := []QueryHooks{}
+	after := func(result *GetRowsResult, err error) {
+		for _, queryHooks := range passed {
+			queryHooks.After(g.databaseType, config, statement, result, err, time.Since(startedAt))
+		}
+	}
+	for _, queryHooks := range g.engine.queryHooks {
+		if _, err := queryHooks.Before(g.databaseType, config, statement); err != nil {
+			after(nil, err)
+			return nil, err
+		}
+		passed = append(passed, queryHooks)
+	}
+	return after, nil
+}
+
+func hookTable(schema string, storageUnit string) string {
+	table := quoteHookIdentifier(storageUnit)
+	if len(schema) > 0 {
+		table = quoteHookIdentifier(schema) + "." + table
+	}
+	return table
+}
+
+func quoteHookIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func withWhere(statement string, where string) string {
+	if len(where) == 0 {
+		return statement
+	}
+	return fmt.Sprintf("%v WHERE %v", statement, where)
+}
+
+func selectStatement(schema string, storageUnit string, columns string, where string) string {
+	return withWhere(fmt.Sprintf("SELECT %v FROM %v", columns, hookTable(schema, storageUnit)), where)
+}
+
+func updateStatement(schema string, storageUnit string, columns []string, where string) string {
+	sort.Strings(columns)
+	assignments := []string{}
+	for _, column := range columns {
+		assignments = append(assignments, fmt.Sprintf("%v = ?", quoteHookIdentifier(column)))
+	}
+	return withWhere(fmt.Sprintf("UPDATE %v SET %v", hookTable(schema, storageUnit), strings.Join(assignments, ", ")), where)
+}
+
+func callStatement(schema string, routine string, arguments []string) string {
+	placeholders := make([]string, len(arguments))
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("CALL %v(%v)", hookTable(schema, routine), strings.Join(placeholders, ", "))
+}
@@ -0,0 +1,29 @@
+package engine
+
+import "time"
+
+// Metrics is told about the queries plugins run and the lookups of the result cache.
+type Metrics interface {
+	QueryStarted(databaseType DatabaseType)
+	QueryFinished(databaseType DatabaseType, method string, duration time.Duration, err error)
+	CacheLookup(cache string, hit bool)
+}
+
+func (e *Engine) SetMetrics(metrics Metrics) {
+	e.metrics = metrics
+}
+
+// queryStarted is evaluated when measure is deferred, so that the query is counted as
+// running until the call returns.
+func (g *guardedPlugin) queryStarted() time.Time {
+	if g.engine.metrics != nil {
+		g.engine.metrics.QueryStarted(g.databaseType)
+	}
+	return time.Now()
+}
+
+func (g *guardedPlugin) measure(method string, startedAt time.Time, err *error) {
+	if g.engine.metrics != nil {
+		g.engine.metrics.QueryFinished(g.databaseType, method, time.Since(startedAt), *err)
+	}
+}
@@ -1,14 +1,74 @@
 package engine
 
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
 type Credentials struct {
 	Hostname string
 	Username string
 	Password string
 	Database string
+	Advanced []Record
+	// Tenant is the tenant the connection is used by. It is set by the server from the
+	// user's identity and never read from the client.
+	Tenant string `json:"-"`
+	// Subject identifies the user the connection is used by, when users are identified. It is
+	// set by the server from the user's identity and never read from the client.
+	Subject string `json:"-"`
+}
+
+// GetAdvanced returns the value of an advanced connection option, or defaultValue when it is not set.
+func (c *Credentials) GetAdvanced(key string, defaultValue string) string {
+	for _, record := range c.Advanced {
+		if record.Key == key && len(record.Value) > 0 {
+			return record.Value
+		}
+	}
+	return defaultValue
 }
 
+// RowStream is handed the rows of a result one at a time; returning an error stops the query.
+type RowStream func(columns []Column, row []string, nulls []bool) error
+
 type PluginConfig struct {
 	Credentials *Credentials
+	// QueryTimeout bounds how long a single query may run; zero means no limit.
+	QueryTimeout time.Duration
+	// MaxRows caps the number of rows a query returns; zero means no limit.
+	MaxRows int
+	// MaxResultSize caps the bytes of memory the rows of a result take; zero means no limit.
+	MaxResultSize int64
+	// SlowQueryThreshold is how long a query may run before it is logged as slow; zero disables the log.
+	SlowQueryThreshold time.Duration
+	// ResultCacheTTL is how long the results of raw read queries are cached; zero disables the cache.
+	ResultCacheTTL time.Duration
+	// ReadOnly runs raw queries in a read-only transaction, for users who may only read.
+	ReadOnly bool
+	// StreamRows, when set, is handed the rows of raw queries as they are read, instead of
+	// them being kept in the result, which then only has the columns.
+	StreamRows RowStream
+	// Caller identifies the interface issuing queries when it is not the GraphQL API.
+	Caller string
+	// Context is the context of the request queries run for, which cancels them when the
+	// request is cancelled; nil runs them until they finish or time out.
+	Context context.Context
+}
+
+// QueryContext returns the context queries should run with, honoring QueryTimeout and the
+// cancellation of Context.
+func (c *PluginConfig) QueryContext() (context.Context, context.CancelFunc) {
+	parent := c.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if c.QueryTimeout > 0 {
+		return context.WithTimeout(parent, c.QueryTimeout)
+	}
+	return context.WithCancel(parent)
 }
 
 type Record struct {
@@ -30,6 +90,148 @@ type GetRowsResult struct {
 	Columns       []Column
 	Rows          [][]string
 	DisableUpdate bool
+	// Warnings are shown along with the rows, e.g. how much data a query scanned.
+	Warnings []string
+	// Nulls is parallel to Rows when the plugin tells NULLs apart from empty strings, and nil otherwise.
+	Nulls [][]bool
+}
+
+// RowNulls returns which cells of a row are NULL, or nil when the plugin does not say.
+func (r *GetRowsResult) RowNulls(row int) []bool {
+	if row >= len(r.Nulls) {
+		return nil
+	}
+	return r.Nulls[row]
+}
+
+type SortDirection string
+
+const (
+	SortDirection_Asc  SortDirection = "ASC"
+	SortDirection_Desc SortDirection = "DESC"
+)
+
+// NullsOrder places NULLs before or after the other values; empty keeps the database's default.
+type NullsOrder string
+
+const (
+	NullsOrder_First NullsOrder = "FIRST"
+	NullsOrder_Last  NullsOrder = "LAST"
+)
+
+// SortFunction is a function applied to a column before sorting on it. Only these are allowed
+// so that sorting cannot be used to run arbitrary SQL.
+type SortFunction string
+
+const (
+	SortFunction_Lower  SortFunction = "LOWER"
+	SortFunction_Upper  SortFunction = "UPPER"
+	SortFunction_Length SortFunction = "LENGTH"
+	SortFunction_Abs    SortFunction = "ABS"
+	SortFunction_Trim   SortFunction = "TRIM"
+)
+
+// SortCondition orders rows by a column, or by Function(column) when Function is set.
+// Conditions apply in order, the first one taking precedence.
+type SortCondition struct {
+	Column    string
+	Function  SortFunction
+	Direction SortDirection
+	Nulls     NullsOrder
+}
+
+type SearchHit struct {
+	StorageUnit string
+	Column      string
+	Row         []Record
+}
+
+// Attributes reported by GetStorageUnitStats. Values are only hints: they come from
+// catalog statistics and may lag behind the actual data.
+const (
+	StatsKey_EstimatedRows        = "Estimated Rows"
+	StatsKey_LastModified         = "Last Modified"
+	StatsKey_LastInserted         = "Last Inserted"
+	StatsKey_LastAnalyzed         = "Last Analyzed"
+	StatsKey_ModifiedSinceAnalyze = "Modified Since Analyze"
+)
+
+const (
+	RoutineType_Procedure = "PROCEDURE"
+	RoutineType_Function  = "FUNCTION"
+)
+
+type RoutineArgument struct {
+	Name string
+	Type string
+	// Mode is IN, OUT or INOUT.
+	Mode string
+}
+
+// Routine is a stored procedure or function.
+type Routine struct {
+	Name       string
+	Type       string
+	ReturnType string
+	Arguments  []RoutineArgument
+	Definition string
+}
+
+// ColumnConstraint describes the values a column accepts, so that edits can be checked
+// before they reach the database.
+type ColumnConstraint struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// MaxLength is the most characters the column holds; zero means unbounded.
+	MaxLength int
+	// Values lists the accepted values of enum columns.
+	Values []string
+}
+
+// ForeignKey is a foreign key of Table; Columns and ReferencedColumns pair up in order.
+type ForeignKey struct {
+	Name              string
+	Schema            string
+	Table             string
+	Columns           []string
+	ReferencedSchema  string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// Index is an index of Table, listing its columns in order; expressions are written out.
+// Primary is set on the index backing the primary key.
+type Index struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+var (
+	ErrNotAView            = errors.New("storage unit is not a view")
+	ErrStorageUnitNotFound = errors.New("storage unit not found")
+)
+
+// ViewDefinition is the query a view is defined by, along with the names of its columns.
+type ViewDefinition struct {
+	Definition string
+	Columns    []string
+}
+
+// ColumnSource is a column of a base table.
+type ColumnSource struct {
+	Schema string
+	Table  string
+	Column string
+}
+
+// ColumnLineage lists the base table columns a view column is computed from.
+type ColumnLineage struct {
+	Column  string
+	Sources []ColumnSource
 }
 
 type GraphUnitRelationshipType string
@@ -52,15 +254,145 @@ type GraphUnit struct {
 	Relations []GraphUnitRelationship
 }
 
+type MaintenanceAction string
+
+const (
+	// MaintenanceAction_Vacuum rebuilds the database to reclaim the space of deleted rows.
+	MaintenanceAction_Vacuum MaintenanceAction = "Vacuum"
+	// MaintenanceAction_IntegrityCheck reports the problems found in the database, or "ok".
+	MaintenanceAction_IntegrityCheck MaintenanceAction = "IntegrityCheck"
+)
+
+// Session is a connection to the database server and what it is doing.
+type Session struct {
+	ID       string
+	User     string
+	Database string
+	Client   string
+	State    string
+	Query    string
+	// TransactionStart is when its open transaction began; zero when it has none.
+	TransactionStart time.Time
+	// QueryStart is when its current or last query began; zero when unknown.
+	QueryStart time.Time
+	// BlockedBy lists the sessions holding locks it waits for.
+	BlockedBy []string
+}
+
+// Lock is a lock held, or waited for when not Granted, by a session.
+type Lock struct {
+	SessionID string
+	Type      string
+	// Object is the table locked, qualified by its schema; empty for other kinds of locks.
+	Object  string
+	Mode    string
+	Granted bool
+}
+
+// Activity is what the sessions of a database server are running and locking, other than
+// the session reading it.
+type Activity struct {
+	Sessions []Session
+	Locks    []Lock
+}
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// MaterializedView is a view whose rows are stored until it is refreshed.
+type MaterializedView struct {
+	Name string
+	// LastRefresh is when its rows were last refreshed; zero when the database does not track it.
+	LastRefresh time.Time
+	// Populated is false while it has never been refreshed and cannot be read.
+	Populated bool
+	// Concurrent reports whether it can be refreshed without blocking its readers.
+	Concurrent bool
+}
+
+// InferredField is a field found in a sample of the documents of a collection, whose
+// documents need not share a schema.
+type InferredField struct {
+	// Path is the dotted path of the field, e.g. address.city. Fields of documents held in
+	// arrays are listed under the path of the array, the way queries reach them.
+	Path string
+	// Types lists the types the field was found with, the most frequent first.
+	Types []string
+	// Occurrence is the percentage of the sampled documents holding the field.
+	Occurrence float64
+}
+
+// DatabaseUser is a user or role of the database server.
+type DatabaseUser struct {
+	Name string
+	// Host is where a MySQL account connects from; Postgres roles have none.
+	Host      string
+	CanLogin  bool
+	Superuser bool
+	// MemberOf lists the roles whose privileges the user is granted.
+	MemberOf []string
+	Grants   []Grant
+}
+
+// Grant is a privilege on a table or view. Object is empty for a privilege on the whole
+// schema, and Schema too for one on the whole database or server. An Object of "*" stands
+// for every table of the schema.
+type Grant struct {
+	Privilege string
+	Schema    string
+	Object    string
+	// Grantable reports whether the user may grant the privilege to others.
+	Grantable bool
+}
+
+var (
+	ErrRowNotFound = errors.New("row not found")
+	ErrNullValue   = errors.New("value is NULL")
+	// ErrReadOnlyUnsupported is returned for raw queries that must run read-only on
+	// databases without read-only transactions.
+	ErrReadOnlyUnsupported = errors.New("queries cannot be restricted to reads on this database")
+)
+
+// BlobChunkSize is how many bytes of a binary value are read or written at once, so that
+// large values are never held in memory whole. It stays well under the 4 MiB packets
+// MySQL 5.7 accepts by default.
+const BlobChunkSize = 1 << 20
+
 type PluginFunctions interface {
 	GetDatabases() ([]string, error)
 	IsAvailable(config *PluginConfig) bool
 	GetSchema(config *PluginConfig) ([]string, error)
 	GetStorageUnits(config *PluginConfig, schema string) ([]StorageUnit, error)
 	UpdateStorageUnit(config *PluginConfig, schema string, storageUnit string, values map[string]string) (bool, error)
-	GetRows(config *PluginConfig, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*GetRowsResult, error)
+	BatchUpdateStorageUnit(config *PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error)
+	GetRows(config *PluginConfig, schema string, storageUnit string, where string, sort []SortCondition, pageSize int, pageOffset int) (*GetRowsResult, error)
 	GetGraph(config *PluginConfig, schema string) ([]GraphUnit, error)
 	RawExecute(config *PluginConfig, query string) (*GetRowsResult, error)
+	SearchStorageUnits(config *PluginConfig, schema string, search string, limit int) ([]SearchHit, error)
+	EstimateRowCount(config *PluginConfig, schema string, storageUnit string) (int64, error)
+	CountRows(config *PluginConfig, schema string, storageUnit string, where string) (int64, error)
+	GetStorageUnitStats(config *PluginConfig, schema string) (map[string][]Record, error)
+	GetRoutines(config *PluginConfig, schema string) ([]Routine, error)
+	ExecuteRoutine(config *PluginConfig, schema string, routine string, arguments []string) (*GetRowsResult, error)
+	GetColumnConstraints(config *PluginConfig, schema string, storageUnit string) ([]ColumnConstraint, error)
+	GetRowsAfter(config *PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*GetRowsResult, string, error)
+	GetViewDefinition(config *PluginConfig, schema string, view string) (*ViewDefinition, error)
+	GetForeignKeys(config *PluginConfig, schema string) ([]ForeignKey, error)
+	GetIndexes(config *PluginConfig, schema string) ([]Index, error)
+	CreateDatabase(config *PluginConfig, name string) error
+	RunMaintenance(config *PluginConfig, action MaintenanceAction) ([]string, error)
+	GetActivity(config *PluginConfig) (*Activity, error)
+	KillSession(config *PluginConfig, id string) error
+	GetMaterializedViews(config *PluginConfig, schema string) ([]MaterializedView, error)
+	RefreshMaterializedView(config *PluginConfig, schema string, view string) error
+	GetDDL(config *PluginConfig, schema string, storageUnit string) (string, error)
+	ExpireKeys(config *PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error)
+	GetDatabaseUsers(config *PluginConfig) ([]DatabaseUser, error)
+	CreateDatabaseUser(config *PluginConfig, name string, password string) error
+	GrantPrivilege(config *PluginConfig, user string, grant Grant) error
+	RevokePrivilege(config *PluginConfig, user string, grant Grant) error
+	ReadBlob(config *PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error)
+	WriteBlob(config *PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error)
+	InferSchema(config *PluginConfig, schema string, storageUnit string, sampleSize int) ([]InferredField, error)
 }
 
 type Plugin struct {
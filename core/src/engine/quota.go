@@ -0,0 +1,39 @@
+package engine
+
+import "errors"
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaEnforcer is consulted before every query run on behalf of a tenant and told how
+// many rows it returned, so that one tenant cannot starve the others.
+type QuotaEnforcer interface {
+	AllowQuery(tenant string) error
+	RecordRows(tenant string, rows int)
+}
+
+func tenantOf(config *PluginConfig) string {
+	if config == nil || config.Credentials == nil {
+		return ""
+	}
+	return config.Credentials.Tenant
+}
+
+// allowQuery checks the tenant's quota on top of the circuit breaker for calls that query data.
+func (g *guardedPlugin) allowQuery(config *PluginConfig) error {
+	if err := g.allow(); err != nil {
+		return err
+	}
+	tenant := tenantOf(config)
+	if g.engine.quotaEnforcer == nil || len(tenant) == 0 {
+		return nil
+	}
+	return g.engine.quotaEnforcer.AllowQuery(tenant)
+}
+
+func (g *guardedPlugin) recordRows(config *PluginConfig, result *GetRowsResult) {
+	tenant := tenantOf(config)
+	if g.engine.quotaEnforcer == nil || len(tenant) == 0 || result == nil {
+		return
+	}
+	g.engine.quotaEnforcer.RecordRows(tenant, len(result.Rows))
+}
@@ -0,0 +1,41 @@
+package engine
+
+const (
+	AdvancedKey_ReadReplicaHost = "Read Replica Host"
+	AdvancedKey_ReadReplicaPort = "Read Replica Port"
+)
+
+// ReadReplica returns the credentials of the connection's read replica, reusing everything
+// but the hostname and port from the primary, or nil when no replica is configured.
+func ReadReplica(credentials *Credentials) *Credentials {
+	host := credentials.GetAdvanced(AdvancedKey_ReadReplicaHost, "")
+	if len(host) == 0 {
+		return nil
+	}
+	replica := *credentials
+	replica.Hostname = host
+	replica.Advanced = []Record{}
+	for _, record := range credentials.Advanced {
+		if record.Key != AdvancedKey_Port {
+			replica.Advanced = append(replica.Advanced, record)
+		}
+	}
+	if port := credentials.GetAdvanced(AdvancedKey_ReadReplicaPort, ""); len(port) > 0 {
+		replica.Advanced = append(replica.Advanced, Record{Key: AdvancedKey_Port, Value: port})
+	} else if port := credentials.GetAdvanced(AdvancedKey_Port, ""); len(port) > 0 {
+		replica.Advanced = append(replica.Advanced, Record{Key: AdvancedKey_Port, Value: port})
+	}
+	return &replica
+}
+
+// ReadConfig returns the config reads should use: the read replica's when one is configured,
+// otherwise config itself.
+func ReadConfig(config *PluginConfig) *PluginConfig {
+	replica := ReadReplica(config.Credentials)
+	if replica == nil {
+		return config
+	}
+	readConfig := *config
+	readConfig.Credentials = replica
+	return &readConfig
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+// maxCachedRows keeps large results out of the cache, which is meant for expensive
+// aggregates rather than bulk reads.
+const maxCachedRows = 10000
+
+// ResultCache keeps the results of raw read queries so that running an expensive query
+// again within the connection's ResultCacheTTL does not hit the database. Entries are keyed
+// by connection, query and row limit, and the least recently used go first once it is full.
+// Writes made through WhoDB invalidate the entries reading the tables they touch, on every
+// server when a shared cache backend is set; writes made elsewhere are only picked up once
+// entries expire.
+type ResultCache struct {
+	mutex    sync.Mutex
+	capacity int
+	// tables returns the tables a query references, lower cased; nil when unknown.
+	tables  func(query string) []string
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type cachedResult struct {
+	key        string
+	connection string
+	tables     []string
+	result     *GetRowsResult
+	// revisions are the shared revisions of the tables read when the result was cached.
+	revisions map[string]int64
+	cachedAt  time.Time
+	expires   time.Time
+}
+
+func NewResultCache(capacity int, tables func(query string) []string) *ResultCache {
+	return &ResultCache{
+		capacity: capacity,
+		tables:   tables,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// SetResultCache enables caching of raw read queries for connections with a ResultCacheTTL.
+func (e *Engine) SetResultCache(resultCache *ResultCache) {
+	e.resultCache = resultCache
+}
+
+// cacheConnection identifies the connection results are cached for, the same way settings do.
+func cacheConnection(databaseType DatabaseType, config *PluginConfig) string {
+	credentials := config.Credentials
+	if credentials == nil {
+		return string(databaseType)
+	}
+	return fmt.Sprintf("%v/%v://%v@%v/%v", credentials.Tenant, databaseType, credentials.Username, credentials.Hostname, credentials.Database)
+}
+
+func cacheKey(connection string, config *PluginConfig, query string) string {
+	return fmt.Sprintf("%v\x00%v\x00%v", connection, config.MaxRows, query)
+}
+
+// Get returns a cached result, with a warning saying when it was cached.
+func (c *ResultCache) Get(databaseType DatabaseType, config *PluginConfig, query string) (*GetRowsResult, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[cacheKey(cacheConnection(databaseType, config), config, query)]
+	if !ok {
+		return nil, false
+	}
+	cached := element.Value.(*cachedResult)
+	if time.Now().After(cached.expires) || !isCurrent(cached.revisions) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	result := *cached.result
+	result.Warnings = append(append([]string{}, result.Warnings...),
+		fmt.Sprintf("Result cached at %v; bypass the cache to run the query again", cached.cachedAt.UTC().Format(time.RFC3339)))
+	return &result, true
+}
+
+func (c *ResultCache) Set(databaseType DatabaseType, config *PluginConfig, query string, result *GetRowsResult) {
+	if result == nil || len(result.Rows) > maxCachedRows {
+		return
+	}
+	connection := cacheConnection(databaseType, config)
+	key := cacheKey(connection, config, query)
+	tables := c.tables(query)
+	revisions, err := currentRevisions(connection, tables)
+	if err != nil {
+		log.Logger.Warnf("Unable to cache a result without the shared cache: %v", err)
+		return
+	}
+	now := time.Now()
+	cached := &cachedResult{
+		key:        key,
+		connection: connection,
+		tables:     tables,
+		result:     result,
+		revisions:  revisions,
+		cachedAt:   now,
+		expires:    now.Add(config.ResultCacheTTL),
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.order.PushFront(cached)
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate drops the cached results of the connection that reference any of the tables,
+// or all of them when the tables written to are unknown.
+func (c *ResultCache) Invalidate(databaseType DatabaseType, config *PluginConfig, tables []string) {
+	connection := cacheConnection(databaseType, config)
+	if err := touchRevisions(connection, tables); err != nil {
+		log.Logger.Warnf("Unable to invalidate results cached by other servers: %v", err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		cached := element.Value.(*cachedResult)
+		if cached.connection == connection && (len(tables) == 0 || cached.tables == nil || referencesAny(cached.tables, tables)) {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// InvalidateQuery invalidates the results reading the tables a write query references.
+func (c *ResultCache) InvalidateQuery(databaseType DatabaseType, config *PluginConfig, query string) {
+	c.Invalidate(databaseType, config, c.tables(query))
+}
+
+func (c *ResultCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cachedResult).key)
+}
+
+// The results a server caches are invalidated on the others through revisions of the shared
+// cache: one for any write to the connection, one for writes to unknown tables and one per
+// table written. A result is current while the revisions of what it read have not changed.
+func resultRevisions(connection string, tables []string) []string {
+	if tables == nil {
+		return []string{"results:" + connection}
+	}
+	names := []string{"results:" + connection + ":*"}
+	for _, table := range tables {
+		names = append(names, "results:"+connection+":"+table)
+	}
+	return names
+}
+
+func currentRevisions(connection string, tables []string) (map[string]int64, error) {
+	if cache.GetBackend() == nil {
+		return nil, nil
+	}
+	revisions := map[string]int64{}
+	for _, name := range resultRevisions(connection, tables) {
+		revision, err := cache.Revision(name)
+		if err != nil {
+			return nil, err
+		}
+		revisions[name] = revision
+	}
+	return revisions, nil
+}
+
+func isCurrent(revisions map[string]int64) bool {
+	for name, cachedRevision := range revisions {
+		revision, err := cache.Revision(name)
+		if err != nil || revision != cachedRevision {
+			return false
+		}
+	}
+	return true
+}
+
+func touchRevisions(connection string, written []string) error {
+	if cache.GetBackend() == nil {
+		return nil
+	}
+	names := []string{"results:" + connection}
+	if len(written) == 0 {
+		names = append(names, "results:"+connection+":*")
+	}
+	for _, table := range written {
+		names = append(names, "results:"+connection+":"+table)
+	}
+	for _, name := range names {
+		if err := cache.Touch(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func referencesAny(tables []string, written []string) bool {
+	for _, table := range tables {
+		for _, other := range written {
+			if strings.EqualFold(table, other) {
+				return true
+			}
+		}
+	}
+	return false
+}
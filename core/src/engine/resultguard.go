@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrResultTooLarge = errors.New("result is too large")
+
+// Go strings and slices carry a header besides their contents, which matters for results
+// made of many small values.
+const (
+	valueOverhead = 16
+	rowOverhead   = 24
+)
+
+// ResultGuard tracks how much memory the rows of a result take while they are converted,
+// so that a single query cannot exhaust the server's memory.
+type ResultGuard struct {
+	limit   int64
+	size    int64
+	maxRows int
+	stream  RowStream
+}
+
+// ResultGuard returns a guard enforcing MaxResultSize and MaxRows for one result.
+func (c *PluginConfig) ResultGuard() *ResultGuard {
+	return &ResultGuard{limit: c.MaxResultSize, maxRows: c.MaxRows, stream: c.StreamRows}
+}
+
+// Limit reports whether result already holds MaxRows rows, in which case the row at hand is
+// left out and a warning says that the result was cut short.
+func (g *ResultGuard) Limit(result *GetRowsResult) bool {
+	if g.maxRows <= 0 || len(result.Rows) < g.maxRows {
+		return false
+	}
+	result.Warnings = append(result.Warnings, fmt.Sprintf("Only the first %v rows are shown, as the query returned more than the row limit allows.", g.maxRows))
+	return true
+}
+
+// Add accounts for a converted row and fails once the result outgrows the limit.
+func (g *ResultGuard) Add(row []string) error {
+	if g.limit <= 0 {
+		return nil
+	}
+	g.size += rowOverhead
+	for _, value := range row {
+		g.size += int64(len(value)) + valueOverhead
+	}
+	if g.size > g.limit {
+		return fmt.Errorf("%w: its rows take more than %v MiB of memory, narrow the query or use the export API to download it", ErrResultTooLarge, g.limit>>20)
+	}
+	return nil
+}
+
+// Append adds a converted row to result, or hands it to StreamRows when rows are streamed,
+// in which case it is not kept and the limit does not apply.
+func (g *ResultGuard) Append(result *GetRowsResult, row []string, nulls []bool) error {
+	if g.stream != nil {
+		return g.stream(result.Columns, row, nulls)
+	}
+	if err := g.Add(row); err != nil {
+		return err
+	}
+	result.Rows = append(result.Rows, row)
+	result.Nulls = append(result.Nulls, nulls)
+	return nil
+}
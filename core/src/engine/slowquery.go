@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+const (
+	Caller_Export    = "Export"
+	Caller_Scheduler = "Scheduler"
+
+	slowQueryLogSize = 100
+	planTimeout      = 5 * time.Second
+)
+
+// SlowQuery is a call that took longer than the connection's slow query threshold.
+type SlowQuery struct {
+	StartedAt    time.Time
+	Duration     time.Duration
+	DatabaseType DatabaseType
+	Hostname     string
+	Database     string
+	Username     string
+	Tenant       string
+	// Caller is the interface that issued the query, empty for the GraphQL API.
+	Caller    string
+	Method    string
+	Statement string
+	// Plan is only captured for raw read queries, where an EXPLAIN is cheap and safe.
+	Plan string
+}
+
+// SlowQueryLog keeps the most recent slow queries in memory.
+type SlowQueryLog struct {
+	mutex   sync.Mutex
+	queries []*SlowQuery
+	size    int
+}
+
+func NewSlowQueryLog(size int) *SlowQueryLog {
+	return &SlowQueryLog{size: size}
+}
+
+func (l *SlowQueryLog) add(query *SlowQuery) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.queries = append(l.queries, query)
+	if len(l.queries) > l.size {
+		l.queries = l.queries[len(l.queries)-l.size:]
+	}
+}
+
+func (l *SlowQueryLog) setPlan(query *SlowQuery, plan string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	query.Plan = plan
+}
+
+// Recent returns copies of the slow queries matching the filter, most recent first.
+func (l *SlowQueryLog) Recent(filter func(query SlowQuery) bool) []SlowQuery {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	recent := []SlowQuery{}
+	for i := len(l.queries) - 1; i >= 0; i-- {
+		if filter(*l.queries[i]) {
+			recent = append(recent, *l.queries[i])
+		}
+	}
+	return recent
+}
+
+func (e *Engine) SlowQueries() *SlowQueryLog {
+	e.slowQueriesOnce.Do(func() {
+		if e.slowQueries == nil {
+			e.slowQueries = NewSlowQueryLog(slowQueryLogSize)
+		}
+	})
+	return e.slowQueries
+}
+
+var explainPrefixes = map[DatabaseType]string{
+	DatabaseType_Postgres:  "EXPLAIN ",
+	DatabaseType_MySQL:     "EXPLAIN ",
+	DatabaseType_Sqlite3:   "EXPLAIN QUERY PLAN ",
+	DatabaseType_Snowflake: "EXPLAIN ",
+}
+
+// observe records the call as a slow query when it ran longer than the connection's threshold.
+func (g *guardedPlugin) observe(config *PluginConfig, method string, statement string, startedAt time.Time) {
+	duration := time.Since(startedAt)
+	if config == nil || config.Credentials == nil || config.SlowQueryThreshold <= 0 || duration < config.SlowQueryThreshold {
+		return
+	}
+	query := &SlowQuery{
+		StartedAt:    startedAt,
+		Duration:     duration,
+		DatabaseType: g.databaseType,
+		Hostname:     config.Credentials.Hostname,
+		Database:     config.Credentials.Database,
+		Username:     config.Credentials.Username,
+		Tenant:       config.Credentials.Tenant,
+		Caller:       config.Caller,
+		Method:       method,
+		Statement:    statement,
+	}
+	log.LogFields(log.Fields{
+		"type":     g.databaseType,
+		"method":   method,
+		"caller":   config.Caller,
+		"duration": duration.String(),
+	}).Warnf("Slow query: %v", statement)
+	slowQueries := g.engine.SlowQueries()
+	slowQueries.add(query)
+
+	prefix, ok := explainPrefixes[g.databaseType]
+	if !ok || method != "RawExecute" || !common.IsReadOnlyQuery(statement) {
+		return
+	}
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Logger.Warnf("Unable to explain slow query: %v", recovered)
+			}
+		}()
+		planConfig := *config
+		planConfig.QueryTimeout = planTimeout
+		// The plan is read after the request that ran the query is done.
+		planConfig.Context = nil
+		planConfig.MaxRows = 0
+		planConfig.SlowQueryThreshold = 0
+		planConfig.StreamRows = nil
+		result, err := g.functions.RawExecute(&planConfig, prefix+statement)
+		if err != nil {
+			return
+		}
+		slowQueries.setPlan(query, formatPlan(result))
+	}()
+}
+
+func formatPlan(result *GetRowsResult) string {
+	lines := []string{}
+	for _, row := range result.Rows {
+		lines = append(lines, strings.Join(row, " | "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeRows(schema string, storageUnit string, where string) string {
+	statement := fmt.Sprintf("%v.%v", schema, storageUnit)
+	if len(where) > 0 {
+		statement = fmt.Sprintf("%v WHERE %v", statement, where)
+	}
+	return statement
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/clidey/whodb/core/src/env"
+)
+
+const (
+	AdvancedKey_TLSMode       = "TLS Mode"
+	AdvancedKey_TLSCAPath     = "TLS CA Path"
+	AdvancedKey_TLSCertPath   = "TLS Client Cert Path"
+	AdvancedKey_TLSKeyPath    = "TLS Client Key Path"
+	AdvancedKey_TLSSkipVerify = "TLS Skip Verification"
+)
+
+// TLS modes, named after Postgres' sslmode.
+const (
+	TLSMode_Disable = "disable"
+	// TLSMode_Prefer uses TLS when the server supports it, without verifying the server.
+	TLSMode_Prefer = "prefer"
+	// TLSMode_Require encrypts the connection without verifying the server.
+	TLSMode_Require = "require"
+	// TLSMode_VerifyCA checks that the server's certificate is signed by a trusted CA.
+	TLSMode_VerifyCA = "verify-ca"
+	// TLSMode_VerifyFull also checks that the certificate names the host connected to.
+	TLSMode_VerifyFull = "verify-full"
+)
+
+// TLSOptions are the TLS settings of a connection. Mode is empty when the driver's default
+// is kept.
+type TLSOptions struct {
+	Mode     string
+	CAPath   string
+	CertPath string
+	KeyPath  string
+}
+
+// GetTLSOptions reads the TLS settings of a connection. Skipping verification turns the
+// verifying modes into require, and requires TLS when no mode is set. The CA, certificate
+// and key are named within the operator's DatabaseTLSDirectory, and resolved to their paths.
+func GetTLSOptions(credentials *Credentials) (TLSOptions, error) {
+	options := TLSOptions{
+		Mode:     credentials.GetAdvanced(AdvancedKey_TLSMode, ""),
+		CAPath:   credentials.GetAdvanced(AdvancedKey_TLSCAPath, ""),
+		CertPath: credentials.GetAdvanced(AdvancedKey_TLSCertPath, ""),
+		KeyPath:  credentials.GetAdvanced(AdvancedKey_TLSKeyPath, ""),
+	}
+	switch options.Mode {
+	case "", TLSMode_Disable, TLSMode_Prefer, TLSMode_Require, TLSMode_VerifyCA, TLSMode_VerifyFull:
+	default:
+		return options, fmt.Errorf("invalid TLS mode %q: use disable, prefer, require, verify-ca or verify-full", options.Mode)
+	}
+	if (len(options.CertPath) > 0) != (len(options.KeyPath) > 0) {
+		return options, errors.New("a TLS client certificate needs its key, and the other way around")
+	}
+	for _, path := range []*string{&options.CAPath, &options.CertPath, &options.KeyPath} {
+		if len(*path) == 0 {
+			continue
+		}
+		resolved, err := OperatorFile(env.DatabaseTLSDirectory, *path)
+		if err != nil {
+			return options, fmt.Errorf("TLS file %q is not available", *path)
+		}
+		*path = resolved
+	}
+	if credentials.GetAdvanced(AdvancedKey_TLSSkipVerify, "false") == "true" && options.Mode != TLSMode_Disable && options.Mode != TLSMode_Prefer {
+		options.Mode = TLSMode_Require
+	}
+	return options, nil
+}
+
+// Config builds the TLS config of drivers that take one. serverName is the host the
+// certificate must name in verify-full mode.
+func (o TLSOptions) Config(serverName string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: serverName}
+	if len(o.CertPath) > 0 {
+		certificate, err := tls.LoadX509KeyPair(o.CertPath, o.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if len(o.CAPath) > 0 {
+		content, err := os.ReadFile(o.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the TLS CA: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(content) {
+			return nil, errors.New("the TLS CA file has no PEM certificate")
+		}
+	}
+
+	switch o.Mode {
+	case TLSMode_VerifyFull:
+	case TLSMode_VerifyCA:
+		// The chain is verified below, as crypto/tls always checks the host name otherwise.
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("the server sent no TLS certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, certificate := range state.PeerCertificates[1:] {
+				intermediates.AddCert(certificate)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: config.RootCAs, Intermediates: intermediates})
+			return err
+		}
+	default:
+		config.InsecureSkipVerify = true
+	}
+	return config, nil
+}
@@ -0,0 +1,407 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/log"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	AdvancedKey_Port                = "Port"
+	AdvancedKey_SSHHost             = "SSH Host"
+	AdvancedKey_SSHPort             = "SSH Port"
+	AdvancedKey_SSHUser             = "SSH User"
+	AdvancedKey_SSHPassword         = "SSH Password"
+	AdvancedKey_SSHKeyPath          = "SSH Key Path"
+	AdvancedKey_SSHUseAgent         = "SSH Use Agent"
+	AdvancedKey_SSHJumpHost         = "SSH Jump Host"
+	AdvancedKey_SSHJumpUser         = "SSH Jump User"
+	AdvancedKey_SSHJumpPassword     = "SSH Jump Password"
+	AdvancedKey_SSHJumpKeyPath      = "SSH Jump Key Path"
+	AdvancedKey_SSHSkipHostKeyCheck = "SSH Skip Host Key Verification"
+	defaultSSHPort                  = 22
+	tunnelIdleTimeout               = 10 * time.Minute
+	tunnelDialTimeout               = 15 * time.Second
+)
+
+type sshTunnel struct {
+	key       string
+	client    *ssh.Client
+	jump      *ssh.Client
+	agent     net.Conn
+	listener  net.Listener
+	target    string
+	lastUsed  time.Time
+	localPort int
+	active    int
+	evicted   bool
+}
+
+var (
+	tunnelsMutex sync.Mutex
+	tunnels      = map[string]*sshTunnel{}
+	reaperOnce   sync.Once
+)
+
+// ResolveAddress returns the host and port a plugin should connect to. When the credentials
+// carry SSH settings, a tunnel to the database is opened (or reused) and its local end is returned.
+func ResolveAddress(credentials *Credentials, defaultPort int) (string, int, error) {
+	port, err := strconv.Atoi(credentials.GetAdvanced(AdvancedKey_Port, strconv.Itoa(defaultPort)))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port: %v", err)
+	}
+	sshHost := credentials.GetAdvanced(AdvancedKey_SSHHost, "")
+	if len(sshHost) == 0 {
+		return credentials.Hostname, port, nil
+	}
+	localPort, err := openTunnel(credentials, net.JoinHostPort(credentials.Hostname, strconv.Itoa(port)))
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to open SSH tunnel: %w", err)
+	}
+	return "127.0.0.1", localPort, nil
+}
+
+func openTunnel(credentials *Credentials, target string) (int, error) {
+	reaperOnce.Do(func() {
+		go reapIdleTunnels()
+	})
+
+	sshPort := credentials.GetAdvanced(AdvancedKey_SSHPort, strconv.Itoa(defaultSSHPort))
+	sshAddress := net.JoinHostPort(credentials.GetAdvanced(AdvancedKey_SSHHost, ""), sshPort)
+	sshUser := credentials.GetAdvanced(AdvancedKey_SSHUser, "")
+	jumpHost := credentials.GetAdvanced(AdvancedKey_SSHJumpHost, "")
+	jumpUser := credentials.GetAdvanced(AdvancedKey_SSHJumpUser, "")
+	key := fmt.Sprintf("%v@%v|%v@%v|%v|%v", sshUser, sshAddress, jumpUser, jumpHost, target, sshAuthHash(credentials))
+
+	tunnelsMutex.Lock()
+	if tunnel, ok := tunnels[key]; ok {
+		tunnel.lastUsed = time.Now()
+		tunnelsMutex.Unlock()
+		return tunnel.localPort, nil
+	}
+	tunnelsMutex.Unlock()
+
+	// The SSH servers are dialed without holding the lock, so that a slow or unreachable one
+	// does not hold up the other connections.
+	tunnel := &sshTunnel{key: key, target: target, lastUsed: time.Now()}
+	err := tunnel.connect(credentials, sshAddress, sshUser, jumpHost, jumpUser)
+	if err != nil {
+		tunnel.close()
+		return 0, err
+	}
+	tunnel.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tunnel.close()
+		return 0, err
+	}
+	tunnel.localPort = tunnel.listener.Addr().(*net.TCPAddr).Port
+
+	tunnelsMutex.Lock()
+	// Another request may have opened the same tunnel in the meantime, which is kept.
+	if existing, ok := tunnels[key]; ok {
+		existing.lastUsed = time.Now()
+		tunnelsMutex.Unlock()
+		tunnel.close()
+		return existing.localPort, nil
+	}
+	tunnels[key] = tunnel
+	tunnelsMutex.Unlock()
+	go tunnel.serve()
+
+	log.LogFields(log.Fields{
+		"ssh":    sshAddress,
+		"jump":   jumpHost,
+		"target": target,
+		"port":   tunnel.localPort,
+	}).Info("Opened SSH tunnel")
+	return tunnel.localPort, nil
+}
+
+// connect opens the SSH client of the tunnel, through the jump host if there is one. The
+// jump host is authenticated with its own user, password and key, and never with those of
+// the SSH server behind it.
+func (t *sshTunnel) connect(credentials *Credentials, sshAddress string, sshUser string, jumpHost string, jumpUser string) error {
+	var agentClient agent.ExtendedAgent
+	if credentials.GetAdvanced(AdvancedKey_SSHUseAgent, "false") == "true" {
+		if !env.SSHAgent {
+			return errors.New("the SSH agent is not enabled on this server")
+		}
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if len(socket) == 0 {
+			return errors.New("SSH agent requested but SSH_AUTH_SOCK is not set")
+		}
+		var err error
+		t.agent, err = net.Dial("unix", socket)
+		if err != nil {
+			return fmt.Errorf("unable to connect to SSH agent: %w", err)
+		}
+		agentClient = agent.NewClient(t.agent)
+	}
+
+	config, err := sshClientConfig(credentials, sshUser, credentials.GetAdvanced(AdvancedKey_SSHPassword, ""), credentials.GetAdvanced(AdvancedKey_SSHKeyPath, ""), agentClient)
+	if err != nil {
+		return err
+	}
+	if len(jumpHost) == 0 {
+		t.client, err = ssh.Dial("tcp", sshAddress, config)
+		return err
+	}
+
+	if len(jumpUser) == 0 {
+		return fmt.Errorf("%q is required with %q", AdvancedKey_SSHJumpUser, AdvancedKey_SSHJumpHost)
+	}
+	jumpConfig, err := sshClientConfig(credentials, jumpUser, credentials.GetAdvanced(AdvancedKey_SSHJumpPassword, ""), credentials.GetAdvanced(AdvancedKey_SSHJumpKeyPath, ""), agentClient)
+	if err != nil {
+		return fmt.Errorf("jump host: %w", err)
+	}
+	if _, _, err := net.SplitHostPort(jumpHost); err != nil {
+		jumpHost = net.JoinHostPort(jumpHost, strconv.Itoa(defaultSSHPort))
+	}
+	t.jump, err = ssh.Dial("tcp", jumpHost, jumpConfig)
+	if err != nil {
+		return fmt.Errorf("unable to connect to jump host: %w", err)
+	}
+	conn, err := t.jump.Dial("tcp", sshAddress)
+	if err != nil {
+		return err
+	}
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, sshAddress, config)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	t.client = ssh.NewClient(clientConn, channels, requests)
+	return nil
+}
+
+// sshAuthHash identifies the SSH credentials of a connection, so that a tunnel is only
+// reused by connections that would have been able to open it themselves.
+func sshAuthHash(credentials *Credentials) string {
+	hash := sha256.New()
+	for _, key := range []string{AdvancedKey_SSHPassword, AdvancedKey_SSHKeyPath, AdvancedKey_SSHUseAgent, AdvancedKey_SSHJumpPassword, AdvancedKey_SSHJumpKeyPath, AdvancedKey_SSHSkipHostKeyCheck} {
+		fmt.Fprintf(hash, "%v\x00", credentials.GetAdvanced(key, ""))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func sshClientConfig(credentials *Credentials, user string, password string, keyPath string, agentClient agent.ExtendedAgent) (*ssh.ClientConfig, error) {
+	authMethods := []ssh.AuthMethod{}
+	if len(keyPath) > 0 {
+		path, err := OperatorFile(env.SSHKeyDirectory, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("SSH key %q is not available", keyPath)
+		}
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("SSH key %q is not available", keyPath)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse SSH key %q: %w", keyPath, err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+	if agentClient != nil {
+		authMethods = append(authMethods, ssh.PublicKeysCallback(agentClient.Signers))
+	}
+	if len(password) > 0 {
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+	if len(authMethods) == 0 {
+		return nil, errors.New("no SSH authentication method configured")
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         tunnelDialTimeout,
+	}, nil
+}
+
+func sshHostKeyCallback(credentials *Credentials) (ssh.HostKeyCallback, error) {
+	if credentials.GetAdvanced(AdvancedKey_SSHSkipHostKeyCheck, "false") == "true" {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	knownHostsPath := env.SSHKnownHostsFile
+	if len(knownHostsPath) == 0 {
+		knownHostsPath = "~/.ssh/known_hosts"
+	}
+	callback, err := knownhosts.New(expandHome(knownHostsPath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load known hosts (set %q to skip verification): %w", AdvancedKey_SSHSkipHostKeyCheck, err)
+	}
+	return callback, nil
+}
+
+// OperatorFile resolves a file named by a connection within a directory configured by the
+// operator, so that connections cannot read other files of the server. The name may not
+// leave the directory, and nothing can be read when the directory is not configured.
+func OperatorFile(directory string, name string) (string, error) {
+	if len(directory) == 0 {
+		return "", errors.New("no directory is configured for this file")
+	}
+	root, err := filepath.Abs(expandHome(directory))
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(root, name)
+	if filepath.IsAbs(name) {
+		path = filepath.Clean(name)
+	}
+	relative, err := filepath.Rel(root, path)
+	if err != nil || relative == "." || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is not within %v", name, directory)
+	}
+	// Symbolic links are resolved too, so that a link in the directory cannot point out of it.
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	relative, err = filepath.Rel(resolvedRoot, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is not within %v", name, directory)
+	}
+	return resolved, nil
+}
+
+func expandHome(path string) string {
+	if len(path) < 2 || path[:2] != "~/" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+func (t *sshTunnel) serve() {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+func (t *sshTunnel) forward(local net.Conn) {
+	defer local.Close()
+	tunnelsMutex.Lock()
+	t.active++
+	tunnelsMutex.Unlock()
+
+	remote, err := t.client.Dial("tcp", t.target)
+	tunnelsMutex.Lock()
+	if err != nil {
+		// The tunnel is dropped so that the next connection opens a new one, rather than
+		// being kept alive by connections that keep failing.
+		t.evict()
+	}
+	tunnelsMutex.Unlock()
+	defer func() {
+		tunnelsMutex.Lock()
+		t.active--
+		if t.evicted {
+			if t.active == 0 {
+				t.close()
+			}
+		} else {
+			t.lastUsed = time.Now()
+		}
+		tunnelsMutex.Unlock()
+	}()
+	if err != nil {
+		log.Logger.Errorf("SSH tunnel unable to reach %v: %v", t.target, err)
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// evict removes the tunnel from the cache and stops accepting connections on it. Connections
+// already forwarded are left to finish, the last one closing the tunnel. The caller holds
+// tunnelsMutex.
+func (t *sshTunnel) evict() {
+	if t.evicted {
+		return
+	}
+	t.evicted = true
+	if tunnels[t.key] == t {
+		delete(tunnels, t.key)
+	}
+	if t.listener != nil {
+		t.listener.Close()
+	}
+	log.LogFields(log.Fields{
+		"target": t.target,
+	}).Info("Dropped failing SSH tunnel")
+}
+
+func (t *sshTunnel) close() {
+	if t.listener != nil {
+		t.listener.Close()
+	}
+	if t.client != nil {
+		t.client.Close()
+	}
+	if t.jump != nil {
+		t.jump.Close()
+	}
+	if t.agent != nil {
+		t.agent.Close()
+	}
+}
+
+func reapIdleTunnels() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		tunnelsMutex.Lock()
+		for key, tunnel := range tunnels {
+			if tunnel.active == 0 && time.Since(tunnel.lastUsed) > tunnelIdleTimeout {
+				tunnel.close()
+				delete(tunnels, key)
+				log.LogFields(log.Fields{
+					"target": tunnel.target,
+				}).Info("Closed idle SSH tunnel")
+			}
+		}
+		tunnelsMutex.Unlock()
+	}
+}
@@ -3,3 +3,120 @@ package env
 import "os"
 
 var IsDevelopment = os.Getenv("ENVIRONMENT") == "dev"
+
+// SchedulerStorePath is the file scheduled job definitions are persisted to.
+// When empty, jobs only live for the lifetime of the process.
+var SchedulerStorePath = os.Getenv("WHODB_SCHEDULER_STORE")
+
+// SnapshotStorePath is the directory scheduled query snapshots are persisted to.
+// When empty, snapshots only live for the lifetime of the process.
+var SnapshotStorePath = os.Getenv("WHODB_SNAPSHOT_STORE")
+
+// RecycleBinStorePath is the file removed scheduled queries are kept in until they expire.
+// When empty, they only live for the lifetime of the process.
+var RecycleBinStorePath = os.Getenv("WHODB_RECYCLE_BIN_STORE")
+
+// SettingsStorePath is the file setting overrides are persisted to.
+// When empty, overrides only live for the lifetime of the process.
+var SettingsStorePath = os.Getenv("WHODB_SETTINGS_STORE")
+
+// SessionSecret signs the identity cookies issued by the server. When empty, a random
+// secret is generated on startup and sessions do not survive a restart.
+var SessionSecret = os.Getenv("WHODB_SESSION_SECRET")
+
+var (
+	OIDCIssuer       = os.Getenv("WHODB_OIDC_ISSUER")
+	OIDCClientID     = os.Getenv("WHODB_OIDC_CLIENT_ID")
+	OIDCClientSecret = os.Getenv("WHODB_OIDC_CLIENT_SECRET")
+	OIDCRedirectURL  = os.Getenv("WHODB_OIDC_REDIRECT_URL")
+	OIDCRolesClaim   = os.Getenv("WHODB_OIDC_ROLES_CLAIM")
+)
+
+// Authenticators lists, in order of precedence, the ways users can prove who they are:
+// session, bearer, mtls and header. When empty, users are not identified at all unless
+// OIDC is configured, in which case session and bearer are used.
+var Authenticators = os.Getenv("WHODB_AUTHENTICATORS")
+
+// AuthPolicyFile maps the roles of identified users to the connections they can use.
+// OIDCPolicyFile is its former name, still read when AuthPolicyFile is not set.
+var (
+	AuthPolicyFile = os.Getenv("WHODB_AUTH_POLICY_FILE")
+	OIDCPolicyFile = os.Getenv("WHODB_OIDC_POLICY_FILE")
+)
+
+// TenantsFile defines the tenants identified users are split into, each with its own
+// connections, saved assets and quotas.
+var TenantsFile = os.Getenv("WHODB_TENANTS_FILE")
+
+// The header authenticator trusts identity headers set by a reverse proxy such as
+// oauth2-proxy, but only on requests coming from one of the TrustedProxies.
+var (
+	TrustedProxies      = os.Getenv("WHODB_TRUSTED_PROXIES")
+	TrustedUserHeader   = os.Getenv("WHODB_TRUSTED_USER_HEADER")
+	TrustedEmailHeader  = os.Getenv("WHODB_TRUSTED_EMAIL_HEADER")
+	TrustedGroupsHeader = os.Getenv("WHODB_TRUSTED_GROUPS_HEADER")
+)
+
+// When TLSCertFile and TLSKeyFile are set the server only accepts HTTPS. TLSClientCAFile
+// enables client certificates signed by that CA, as used by the mtls authenticator.
+var (
+	TLSCertFile     = os.Getenv("WHODB_TLS_CERT")
+	TLSKeyFile      = os.Getenv("WHODB_TLS_KEY")
+	TLSClientCAFile = os.Getenv("WHODB_TLS_CLIENT_CA")
+)
+
+// SSH tunnels only read keys from SSHKeyDirectory, which connections name a key relative
+// to, and only verify servers against SSHKnownHostsFile (~/.ssh/known_hosts when empty).
+// With SSHAgent, connections can also authenticate with the agent at SSH_AUTH_SOCK.
+var (
+	SSHKeyDirectory   = os.Getenv("WHODB_SSH_KEY_DIR")
+	SSHKnownHostsFile = os.Getenv("WHODB_SSH_KNOWN_HOSTS")
+	SSHAgent          = os.Getenv("WHODB_SSH_AGENT") == "true"
+)
+
+// DatabaseTLSDirectory holds the CA certificates, client certificates and keys connections
+// can use for TLS to their database, named relative to it. When empty, none can be used.
+var DatabaseTLSDirectory = os.Getenv("WHODB_DATABASE_TLS_DIR")
+
+// WebhookAllowedHosts lists the hosts scheduled queries may send webhooks to, separated
+// by commas and with path.Match wildcards. When empty, any public address may be notified.
+var WebhookAllowedHosts = os.Getenv("WHODB_WEBHOOK_ALLOWED_HOSTS")
+
+// MetricsEnabled exposes Prometheus metrics on /metrics. They are served without
+// authentication, so the endpoint should only be reachable from the monitoring system.
+var MetricsEnabled = os.Getenv("WHODB_METRICS") == "true"
+
+// HooksFile defines the hooks run before and after raw statements, such as blocking DDL
+// or notifying a webhook.
+var HooksFile = os.Getenv("WHODB_HOOKS_FILE")
+
+// AssertionsFile defines the data quality rules the rows of tables are checked against.
+var AssertionsFile = os.Getenv("WHODB_ASSERTIONS_FILE")
+
+// ExportDirectory is where chunked exports write their files and manifests. When empty, a
+// directory under the system's temporary directory is used.
+var ExportDirectory = os.Getenv("WHODB_EXPORT_DIR")
+
+// PersistedQueriesFile maps the hashes of the operations the frontend was built with to
+// their text, served to automatic persisted query requests. With PersistedQueriesOnly, no
+// other operation is executed.
+var (
+	PersistedQueriesFile = os.Getenv("WHODB_PERSISTED_QUERIES_FILE")
+	PersistedQueriesOnly = os.Getenv("WHODB_PERSISTED_QUERIES_ONLY") == "true"
+)
+
+// UpdateCheck looks for newer WhoDB releases on GitHub once a day.
+var UpdateCheck = os.Getenv("WHODB_UPDATE_CHECK") == "true"
+
+// OpenLineageURL is the endpoint OpenLineage run events for raw statements and exports are
+// posted to, e.g. http://marquez:5000/api/v1/lineage. Jobs are named in OpenLineageNamespace.
+var (
+	OpenLineageURL       = os.Getenv("WHODB_OPENLINEAGE_URL")
+	OpenLineageAPIKey    = os.Getenv("WHODB_OPENLINEAGE_API_KEY")
+	OpenLineageNamespace = os.Getenv("WHODB_OPENLINEAGE_NAMESPACE")
+)
+
+// CacheRedisURL moves caches and tenant quota counters to Redis, e.g.
+// redis://:password@host:6379/0, so that servers behind a load balancer share them, and
+// keeps their settings, scheduled jobs and cached results in step.
+var CacheRedisURL = os.Getenv("WHODB_CACHE_REDIS_URL")
@@ -0,0 +1,126 @@
+package erd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+type Format string
+
+const (
+	Format_DOT     Format = "DOT"
+	Format_Mermaid Format = "Mermaid"
+)
+
+type edge struct {
+	from         string
+	to           string
+	relationship engine.GraphUnitRelationshipType
+}
+
+var mermaidCardinalities = map[engine.GraphUnitRelationshipType]string{
+	engine.GraphUnitRelationshipType_OneToOne:   "||--||",
+	engine.GraphUnitRelationshipType_OneToMany:  "||--o{",
+	engine.GraphUnitRelationshipType_ManyToOne:  "}o--||",
+	engine.GraphUnitRelationshipType_ManyToMany: "}o--o{",
+}
+
+var invalidMermaidCharacters = regexp.MustCompile(`[^A-Za-z0-9_\-]`)
+
+// Render serializes the entity-relationship graph of a schema as DOT or Mermaid text.
+// Output is sorted so that diagrams of an unchanged schema are identical.
+func Render(units []engine.GraphUnit, format Format) (string, error) {
+	entities, edges := collect(units)
+	switch format {
+	case Format_DOT:
+		return renderDOT(entities, edges), nil
+	case Format_Mermaid:
+		return renderMermaid(entities, edges), nil
+	}
+	return "", fmt.Errorf("unsupported diagram format: %v", format)
+}
+
+func collect(units []engine.GraphUnit) ([]string, []edge) {
+	entitySet := map[string]bool{}
+	edgeSet := map[edge]bool{}
+	for _, unit := range units {
+		entitySet[unit.Unit.Name] = true
+		for _, relation := range unit.Relations {
+			entitySet[relation.Name] = true
+			edgeSet[edge{from: unit.Unit.Name, to: relation.Name, relationship: relation.RelationshipType}] = true
+		}
+	}
+
+	entities := []string{}
+	for entity := range entitySet {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+
+	edges := []edge{}
+	for e := range edgeSet {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		if edges[i].to != edges[j].to {
+			return edges[i].to < edges[j].to
+		}
+		return edges[i].relationship < edges[j].relationship
+	})
+	return entities, edges
+}
+
+func renderDOT(entities []string, edges []edge) string {
+	builder := strings.Builder{}
+	builder.WriteString("digraph ERD {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  node [shape=box];\n")
+	for _, entity := range entities {
+		builder.WriteString(fmt.Sprintf("  %v;\n", quoteDOT(entity)))
+	}
+	for _, e := range edges {
+		builder.WriteString(fmt.Sprintf("  %v -> %v [label=%v];\n", quoteDOT(e.from), quoteDOT(e.to), quoteDOT(string(e.relationship))))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+func quoteDOT(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func renderMermaid(entities []string, edges []edge) string {
+	builder := strings.Builder{}
+	builder.WriteString("erDiagram\n")
+	// Entities that take part in a relationship are declared by it.
+	related := map[string]bool{}
+	for _, e := range edges {
+		related[e.from] = true
+		related[e.to] = true
+	}
+	for _, entity := range entities {
+		if !related[entity] {
+			builder.WriteString(fmt.Sprintf("  %v\n", mermaidName(entity)))
+		}
+	}
+	for _, e := range edges {
+		cardinality, ok := mermaidCardinalities[e.relationship]
+		if !ok {
+			cardinality = "}o..o{"
+		}
+		builder.WriteString(fmt.Sprintf("  %v %v %v : %q\n", mermaidName(e.from), cardinality, mermaidName(e.to), string(e.relationship)))
+	}
+	return builder.String()
+}
+
+// mermaidName replaces the characters Mermaid does not accept in entity names.
+func mermaidName(name string) string {
+	return invalidMermaidCharacters.ReplaceAllString(name, "_")
+}
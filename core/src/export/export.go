@@ -0,0 +1,305 @@
+package export
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+const (
+	Format_NDJSON = "ndjson"
+	Format_CSV    = "csv"
+	ChunkSize     = 1000
+)
+
+// Attempts bounds how many times a chunk is fetched before the export gives up.
+const Attempts = 3
+
+var retryDelay = time.Second
+
+var ErrUnknownColumn = errors.New("column does not exist")
+
+// RowWriter is called once per exported row; returning an error stops the export. nulls
+// tells which cells are NULL, and is nil when the plugin does not tell them apart.
+type RowWriter func(columns []engine.Column, row []string, nulls []bool) error
+
+// Project narrows the rows passed to write down to columns, in that order, leaving them
+// untouched when no columns are given.
+func Project(columns []string, write RowWriter) RowWriter {
+	if len(columns) == 0 {
+		return write
+	}
+	var indexes []int
+	var projected []engine.Column
+	return func(all []engine.Column, row []string, nulls []bool) error {
+		// Every chunk of an export has the same columns, so they are only looked up once.
+		if indexes == nil {
+			for _, name := range columns {
+				index := slices.IndexFunc(all, func(column engine.Column) bool { return column.Name == name })
+				if index < 0 {
+					return fmt.Errorf("%w: %v", ErrUnknownColumn, name)
+				}
+				indexes = append(indexes, index)
+				projected = append(projected, all[index])
+			}
+		}
+		projectedRow := make([]string, len(indexes))
+		var projectedNulls []bool
+		if nulls != nil {
+			projectedNulls = make([]bool, len(indexes))
+		}
+		for i, index := range indexes {
+			if index < len(row) {
+				projectedRow[i] = row[index]
+			}
+			if nulls != nil && index < len(nulls) {
+				projectedNulls[i] = nulls[index]
+			}
+		}
+		return write(projected, projectedRow, projectedNulls)
+	}
+}
+
+// Cursor is the position of an export: the rows written so far and, when the storage unit
+// can be read in key order, the key of the last one.
+type Cursor struct {
+	Offset int
+	Key    string
+}
+
+// StorageUnit exports a storage unit from cursor onwards, keeping cursor up to date after
+// every chunk and calling afterChunk once it has been written. Chunks are read by key
+// whenever the plugin supports it, so resuming does not depend on rows keeping their place,
+// and each one is retried when the connection times out or drops.
+func StorageUnit(plugin *engine.Plugin, config *engine.PluginConfig, schema string, storageUnit string, where string, cursor *Cursor, write RowWriter, afterChunk func() error) error {
+	// Chunks already bound the amount of rows fetched at once, so the row limit does not apply.
+	config.MaxRows = 0
+	// Keys can only be used from the start or from a key, never from an offset alone.
+	useKeys := cursor.Offset == 0 || len(cursor.Key) > 0
+	var order []engine.SortCondition
+	for {
+		var rows *engine.GetRowsResult
+		lastKey := ""
+		err := withRetry(func() error {
+			var err error
+			if useKeys {
+				rows, lastKey, err = plugin.GetRowsAfter(config, schema, storageUnit, where, cursor.Key, ChunkSize)
+				if errors.Is(err, errors.ErrUnsupported) && len(cursor.Key) == 0 {
+					useKeys = false
+				} else {
+					return err
+				}
+			}
+			if order == nil {
+				order, err = stableOrder(plugin, config, schema, storageUnit)
+				if err != nil {
+					return err
+				}
+			}
+			rows, err = plugin.GetRows(config, schema, storageUnit, where, order, ChunkSize, cursor.Offset)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for i, row := range rows.Rows {
+			if err := write(rows.Columns, row, rows.RowNulls(i)); err != nil {
+				return err
+			}
+		}
+		cursor.Offset += len(rows.Rows)
+		if useKeys && len(lastKey) > 0 {
+			cursor.Key = lastKey
+		}
+		if afterChunk != nil {
+			if err := afterChunk(); err != nil {
+				return err
+			}
+		}
+		// Redis keys are always read whole, so there is no next chunk to fetch.
+		if len(rows.Rows) < ChunkSize || plugin.Type == engine.DatabaseType_Redis {
+			return nil
+		}
+	}
+}
+
+// stableOrder returns the order to read chunks by offset in, so that rows keep their place
+// from one chunk to the next: the primary key, a unique index, or the columns of tables
+// without either. Storage units whose columns are not known are read in the database's order.
+func stableOrder(plugin *engine.Plugin, config *engine.PluginConfig, schema string, storageUnit string) ([]engine.SortCondition, error) {
+	order := []engine.SortCondition{}
+	indexes, err := plugin.GetIndexes(config, schema)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return nil, err
+	}
+	// Primary keys first.
+	slices.SortStableFunc(indexes, func(a engine.Index, b engine.Index) int {
+		switch {
+		case a.Primary == b.Primary:
+			return 0
+		case a.Primary:
+			return -1
+		}
+		return 1
+	})
+	for _, index := range indexes {
+		if index.Table == storageUnit && (index.Primary || index.Unique) {
+			for _, column := range index.Columns {
+				order = append(order, engine.SortCondition{Column: column})
+			}
+			return order, nil
+		}
+	}
+	constraints, err := plugin.GetColumnConstraints(config, schema, storageUnit)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return order, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, constraint := range constraints {
+		if isOrderable(constraint.Type) {
+			order = append(order, engine.SortCondition{Column: constraint.Name})
+		}
+	}
+	return order, nil
+}
+
+// isOrderable reports whether a column of this type can be sorted on; JSON, large objects,
+// XML and spatial types cannot on some databases.
+func isOrderable(columnType string) bool {
+	columnType = strings.ToLower(columnType)
+	for _, unorderable := range []string{"json", "lob", "xml", "geometry", "geography"} {
+		if strings.Contains(columnType, unorderable) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query exports the result of a raw query, skipping the rows before offset. Rows are
+// written as the database returns them, so resuming runs the query again. It is not retried
+// once rows have been written, as they would be written twice.
+func Query(plugin *engine.Plugin, config *engine.PluginConfig, query string, offset int, write RowWriter) error {
+	streamConfig := *config
+	// The export writes rows as they come, so neither limit applies.
+	streamConfig.MaxRows = 0
+	streamConfig.MaxResultSize = 0
+	read := 0
+	streamConfig.StreamRows = func(columns []engine.Column, row []string, nulls []bool) error {
+		read++
+		if read <= offset {
+			return nil
+		}
+		return write(columns, row, nulls)
+	}
+	err := withRetry(func() error {
+		read = 0
+		_, err := plugin.RawExecute(&streamConfig, query)
+		if err != nil && read > offset {
+			return stopRetry{err}
+		}
+		return err
+	})
+	var stop stopRetry
+	if errors.As(err, &stop) {
+		return stop.err
+	}
+	if err != nil {
+		return err
+	}
+	if offset > read {
+		return errors.New("offset is past the end of the result")
+	}
+	return nil
+}
+
+// stopRetry is an error that is not retried, as it hides whether the error it holds is
+// transient.
+type stopRetry struct {
+	err error
+}
+
+func (e stopRetry) Error() string {
+	return e.err.Error()
+}
+
+// withRetry calls fetch until it succeeds, fails with an error that retrying cannot fix,
+// or runs out of attempts, doubling the delay between attempts.
+func withRetry(fetch func() error) error {
+	delay := retryDelay
+	var err error
+	for attempt := 1; attempt <= Attempts; attempt++ {
+		err = fetch()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+		if attempt < Attempts {
+			log.Logger.Warnf("Export chunk failed (attempt %v of %v), retrying in %v: %v", attempt, Attempts, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// isTransient reports whether err is a timeout or a dropped connection.
+func isTransient(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+// NewRowWriter writes rows to w as CSV or NDJSON. The CSV header is written before the
+// first row when includeHeader is set.
+func NewRowWriter(w io.Writer, format string, includeHeader bool) RowWriter {
+	if format == Format_CSV {
+		csvWriter := csv.NewWriter(w)
+		headerWritten := !includeHeader
+		return func(columns []engine.Column, row []string, nulls []bool) error {
+			if !headerWritten {
+				header := make([]string, len(columns))
+				for i, column := range columns {
+					header[i] = column.Name
+				}
+				if err := csvWriter.Write(header); err != nil {
+					return err
+				}
+				headerWritten = true
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	return func(columns []engine.Column, row []string, nulls []bool) error {
+		record := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(row) {
+				record[column.Name] = row[i]
+			}
+		}
+		return encoder.Encode(record)
+	}
+}
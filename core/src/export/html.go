@@ -0,0 +1,316 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+const Format_HTML = "html"
+
+// maxChartBars bounds the chart of a report, which is only meant to give an overview.
+const maxChartBars = 50
+
+// RenderOptions controls how values are shown in a report.
+type RenderOptions struct {
+	// NullDisplay replaces NULLs, which are otherwise shown empty.
+	NullDisplay string
+	// MaxColumnWidth is the widest a column may be in characters; zero means no limit.
+	MaxColumnWidth int
+	// Ellipsis cuts values wider than MaxColumnWidth instead of wrapping them.
+	Ellipsis bool
+	// AlignNumbersLeft aligns numeric columns like the others instead of to the right.
+	AlignNumbersLeft bool
+}
+
+// HTMLReport writes a standalone report of exported rows: the query, when and how long it
+// ran, a table that sorts by clicking its headers and, optionally, a bar chart. The page
+// has no external dependencies so it can be attached to an email or a ticket as is.
+type HTMLReport struct {
+	w            io.Writer
+	title        string
+	query        string
+	databaseType string
+	chartLabel   string
+	chartValue   string
+	options      RenderOptions
+	started      time.Time
+	rows         int
+	columns      []engine.Column
+	numeric      []bool
+	bars         []chartBar
+}
+
+type chartBar struct {
+	Label string
+	Value float64
+}
+
+// NewHTMLReport starts a report; chartLabel and chartValue name the columns to chart, or
+// are empty for a report without a chart.
+func NewHTMLReport(w io.Writer, title string, query string, databaseType string, chartLabel string, chartValue string, options RenderOptions) *HTMLReport {
+	return &HTMLReport{
+		w:            w,
+		title:        title,
+		query:        query,
+		databaseType: databaseType,
+		chartLabel:   chartLabel,
+		chartValue:   chartValue,
+		options:      options,
+		started:      time.Now(),
+	}
+}
+
+// WriteRow is the RowWriter of the report.
+func (r *HTMLReport) WriteRow(columns []engine.Column, row []string, nulls []bool) error {
+	if r.columns == nil {
+		r.columns = columns
+		r.numeric = make([]bool, len(columns))
+		for i, column := range columns {
+			r.numeric[i] = isNumericType(column.Type)
+		}
+		if err := r.writeHeader(); err != nil {
+			return err
+		}
+	}
+	r.rows++
+	r.addBar(row)
+	if _, err := io.WriteString(r.w, "<tr>"); err != nil {
+		return err
+	}
+	for i, value := range row {
+		if _, err := io.WriteString(r.w, r.cell(i, value, i < len(nulls) && nulls[i])); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(r.w, "</tr>\n")
+	return err
+}
+
+// cell renders a value. Cut values keep their full text in data-value, which the table
+// sorts on, and in the title so that it shows on hover.
+func (r *HTMLReport) cell(column int, value string, isNull bool) string {
+	classes := []string{}
+	if column < len(r.numeric) && r.numeric[column] && !r.options.AlignNumbersLeft {
+		classes = append(classes, "num")
+	}
+	if isNull {
+		classes = append(classes, "null")
+		value = r.options.NullDisplay
+	}
+	attributes := ""
+	if len(classes) > 0 {
+		attributes = fmt.Sprintf(` class="%v"`, strings.Join(classes, " "))
+	}
+	if isNull {
+		return fmt.Sprintf(`<td%v data-value="">%v</td>`, attributes, html.EscapeString(value))
+	}
+	if r.options.Ellipsis && r.options.MaxColumnWidth > 0 && utf8.RuneCountInString(value) > r.options.MaxColumnWidth {
+		cut := string([]rune(value)[:r.options.MaxColumnWidth]) + "…"
+		escaped := html.EscapeString(value)
+		return fmt.Sprintf(`<td%v data-value="%v" title="%v">%v</td>`, attributes, escaped, escaped, html.EscapeString(cut))
+	}
+	return fmt.Sprintf("<td%v>%v</td>", attributes, html.EscapeString(value))
+}
+
+// isNumericType reports whether a column holds numbers, judging by the name of its type
+// without size or modifiers, e.g. INT, BIGINT UNSIGNED, NUMERIC(10,2) or FLOAT64.
+func isNumericType(columnType string) bool {
+	name, _, _ := strings.Cut(strings.ToUpper(columnType), "(")
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.TrimRight(fields[0], "0123456789") {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "BYTEINT", "INTEGER", "SERIAL", "SMALLSERIAL", "BIGSERIAL",
+		"NUMERIC", "BIGNUMERIC", "NUMBER", "DECIMAL", "DEC", "FLOAT", "DOUBLE", "REAL", "MONEY":
+		return true
+	}
+	return false
+}
+
+// Close ends the report, noting exportErr when the export stopped before the last row.
+func (r *HTMLReport) Close(exportErr error) error {
+	if r.columns == nil {
+		if err := r.writeHeader(); err != nil {
+			return err
+		}
+	}
+	footer := reportFooter{
+		Rows:     r.rows,
+		Duration: time.Since(r.started).Round(time.Millisecond).String(),
+		Bars:     r.chart(),
+	}
+	footer.ChartHeight = len(footer.Bars) * barHeight
+	if exportErr != nil {
+		footer.Error = exportErr.Error()
+	}
+	return reportFooterTemplate.Execute(r.w, footer)
+}
+
+func (r *HTMLReport) writeHeader() error {
+	columns := []string{}
+	for _, column := range r.columns {
+		columns = append(columns, column.Name)
+	}
+	return reportHeaderTemplate.Execute(r.w, reportHeader{
+		Title:          r.title,
+		Query:          r.query,
+		DatabaseType:   r.databaseType,
+		GeneratedAt:    r.started.UTC().Format(time.RFC1123),
+		Columns:        columns,
+		MaxColumnWidth: r.options.MaxColumnWidth,
+	})
+}
+
+func (r *HTMLReport) addBar(row []string) {
+	if len(r.chartValue) == 0 || len(r.bars) >= maxChartBars {
+		return
+	}
+	label, value := "", ""
+	for i, column := range r.columns {
+		if i >= len(row) {
+			break
+		}
+		if column.Name == r.chartLabel {
+			label = row[i]
+		}
+		if column.Name == r.chartValue {
+			value = row[i]
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	r.bars = append(r.bars, chartBar{Label: label, Value: number})
+}
+
+type reportBar struct {
+	Label  string
+	Value  string
+	Y      int
+	Width  float64
+	ValueX float64
+}
+
+// Bars start after their label and are scaled so that the largest one is barWidth wide.
+const (
+	barLeft   = 200
+	barWidth  = 500
+	barHeight = 22
+)
+
+// chart lays the bars out horizontally, scaled to the largest value.
+func (r *HTMLReport) chart() []reportBar {
+	largest := 0.0
+	for _, bar := range r.bars {
+		if bar.Value > largest {
+			largest = bar.Value
+		}
+	}
+	bars := []reportBar{}
+	for i, bar := range r.bars {
+		width := 0.0
+		if largest > 0 && bar.Value > 0 {
+			width = bar.Value / largest * barWidth
+		}
+		bars = append(bars, reportBar{
+			Label:  bar.Label,
+			Value:  strconv.FormatFloat(bar.Value, 'f', -1, 64),
+			Y:      i * barHeight,
+			Width:  width,
+			ValueX: barLeft + width + 5,
+		})
+	}
+	return bars
+}
+
+type reportHeader struct {
+	Title          string
+	Query          string
+	DatabaseType   string
+	GeneratedAt    string
+	Columns        []string
+	MaxColumnWidth int
+}
+
+type reportFooter struct {
+	Rows        int
+	Duration    string
+	Error       string
+	Bars        []reportBar
+	ChartHeight int
+}
+
+var reportHeaderTemplate = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; color: #1f2937; margin: 2rem; }
+h1 { font-size: 1.4rem; margin-bottom: 0.25rem; }
+.meta { color: #6b7280; font-size: 0.85rem; margin-bottom: 1rem; }
+pre { background: #f3f4f6; padding: 0.75rem; border-radius: 6px; white-space: pre-wrap; }
+table { border-collapse: collapse; font-size: 0.85rem; width: 100%; }
+th, td { border: 1px solid #e5e7eb; padding: 0.35rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f9fafb; cursor: pointer; user-select: none; position: sticky; top: 0; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafafa; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+td.null { color: #9ca3af; font-style: italic; }
+{{if .MaxColumnWidth}}td { max-width: {{.MaxColumnWidth}}ch; overflow-wrap: anywhere; }
+{{end}}.error { color: #b91c1c; margin-top: 1rem; }
+svg text { font-size: 11px; fill: #374151; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">{{.DatabaseType}} &middot; generated {{.GeneratedAt}}</div>
+{{if .Query}}<pre>{{.Query}}</pre>{{end}}
+<table id="rows">
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+`))
+
+var reportFooterTemplate = template.Must(template.New("footer").Parse(`</tbody>
+</table>
+{{if .Error}}<p class="error">The export stopped early: {{.Error}}</p>{{end}}
+<p class="meta">{{.Rows}} rows in {{.Duration}}</p>
+{{if .Bars}}<svg width="800" height="{{.ChartHeight}}" role="img" aria-label="Chart">
+{{range .Bars}}<text x="0" y="{{.Y}}" dy="14">{{.Label}}</text>
+<rect x="200" y="{{.Y}}" width="{{printf "%.1f" .Width}}" height="18" fill="#6366f1"></rect>
+<text x="{{printf "%.1f" .ValueX}}" y="{{.Y}}" dy="14">{{.Value}}</text>
+{{end}}</svg>{{end}}
+<script>
+function cellValue(cell) {
+  return cell.hasAttribute("data-value") ? cell.getAttribute("data-value") : cell.textContent;
+}
+document.querySelectorAll("#rows th").forEach(function (header, index) {
+  header.addEventListener("click", function () {
+    var body = document.querySelector("#rows tbody");
+    var ascending = !header.classList.contains("asc");
+    document.querySelectorAll("#rows th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+    header.classList.add(ascending ? "asc" : "desc");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = cellValue(a.cells[index]), y = cellValue(b.cells[index]);
+      var result = x !== "" && y !== "" && !isNaN(x) && !isNaN(y) ? x - y : x.localeCompare(y);
+      return ascending ? result : -result;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
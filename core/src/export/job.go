@@ -0,0 +1,354 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+const (
+	Status_Running   = "running"
+	Status_Completed = "completed"
+	Status_Failed    = "failed"
+)
+
+// DefaultRowsPerPart is the size of each file of a chunked export unless requested otherwise.
+const DefaultRowsPerPart = 1000000
+
+const manifestFile = "manifest.json"
+
+// resumeClaimTTL keeps an export claimed by the server resuming it until it has saved its
+// manifest again, which changes the claim.
+const resumeClaimTTL = time.Hour
+
+var (
+	ErrJobNotFound      = errors.New("export not found")
+	ErrJobRunning       = errors.New("export is already running")
+	ErrJobNotResumable  = errors.New("only failed exports can be resumed")
+	ErrInvalidPartsSize = errors.New("rowsPerPart must be a multiple of 1000")
+)
+
+// Request describes the storage unit a chunked export reads.
+type Request struct {
+	Type        string
+	Schema      string
+	StorageUnit string
+	Where       string
+	// Columns limits the export to these columns, in this order; all of them when empty.
+	Columns     []string
+	Format      string
+	RowsPerPart int
+}
+
+// Part is one file of a chunked export. When rows are read in key order, it holds the rows
+// after AfterKey up to and including LastKey.
+type Part struct {
+	File     string
+	Rows     int
+	Bytes    int64
+	AfterKey string `json:",omitempty"`
+	LastKey  string `json:",omitempty"`
+}
+
+// Manifest records the progress of a chunked export. It is rewritten after every chunk, so
+// a failed export can continue from the last row that made it to disk.
+type Manifest struct {
+	ID string
+	Request
+	// Connection identifies the connection the export was started from, see settings.TargetFor.
+	Connection string
+	Status     string
+	Error      string `json:",omitempty"`
+	Rows       int
+	Cursor     Cursor
+	Parts      []Part
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Summary describes a finished export, successful or not.
+type Summary struct {
+	DatabaseType string
+	Schema       string
+	StorageUnit  string
+	// Query is the raw query exported, when it is not a storage unit.
+	Query string
+	// Destination names where the rows went, e.g. downloads/users.csv or exports/<id>.
+	Destination string
+	Format      string
+	Rows        int
+	StartedAt   time.Time
+	Error       error
+}
+
+// Observer is told about every export once it is over, e.g. to report lineage.
+type Observer func(config *engine.PluginConfig, summary Summary)
+
+// Manager runs chunked exports in the background, writing each one to its own directory.
+type Manager struct {
+	directory string
+	mutex     sync.Mutex
+	running   map[string]bool
+	observer  Observer
+}
+
+func NewManager(directory string) *Manager {
+	return &Manager{directory: directory, running: map[string]bool{}}
+}
+
+func (m *Manager) SetObserver(observer Observer) {
+	m.observer = observer
+}
+
+// Start begins exporting request through plugin and returns the initial manifest.
+func (m *Manager) Start(plugin *engine.Plugin, config *engine.PluginConfig, connection string, request Request) (*Manifest, error) {
+	if request.RowsPerPart == 0 {
+		request.RowsPerPart = DefaultRowsPerPart
+	}
+	// Parts only ever end on a chunk boundary, which keeps the manifest in step with the files.
+	if request.RowsPerPart < 0 || request.RowsPerPart%ChunkSize != 0 {
+		return nil, ErrInvalidPartsSize
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		ID:         id,
+		Request:    request,
+		Connection: connection,
+		Status:     Status_Running,
+		Parts:      []Part{},
+		StartedAt:  time.Now(),
+	}
+	if err := os.MkdirAll(m.path(id), 0o700); err != nil {
+		return nil, err
+	}
+	return m.run(plugin, config, manifest)
+}
+
+// Resume continues a failed export from the last row written to its files.
+func (m *Manager) Resume(plugin *engine.Plugin, config *engine.PluginConfig, id string) (*Manifest, error) {
+	manifest, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Status != Status_Failed {
+		return nil, ErrJobNotResumable
+	}
+	// Servers sharing the export directory all see the export failed; the first to resume it wins.
+	if backend := cache.GetBackend(); backend != nil {
+		claimed, err := backend.Claim(fmt.Sprintf("export:%v:%v", id, manifest.UpdatedAt.UnixNano()), resumeClaimTTL)
+		if err == nil && !claimed {
+			return nil, ErrJobRunning
+		}
+	}
+	manifest.Status = Status_Running
+	manifest.Error = ""
+	return m.run(plugin, config, manifest)
+}
+
+// Get returns the manifest of an export, whether or not it is still running.
+func (m *Manager) Get(id string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(m.path(id), manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// PartPath returns where a part of an export is stored.
+func (m *Manager) PartPath(id string, file string) (string, error) {
+	manifest, err := m.Get(id)
+	if err != nil {
+		return "", err
+	}
+	for _, part := range manifest.Parts {
+		if part.File == file {
+			return filepath.Join(m.path(id), part.File), nil
+		}
+	}
+	return "", ErrJobNotFound
+}
+
+func (m *Manager) path(id string) string {
+	return filepath.Join(m.directory, filepath.Base(id))
+}
+
+func (m *Manager) run(plugin *engine.Plugin, config *engine.PluginConfig, manifest *Manifest) (*Manifest, error) {
+	m.mutex.Lock()
+	if m.running[manifest.ID] {
+		m.mutex.Unlock()
+		return nil, ErrJobRunning
+	}
+	m.running[manifest.ID] = true
+	m.mutex.Unlock()
+
+	if err := m.save(manifest); err != nil {
+		m.finish(manifest.ID)
+		return nil, err
+	}
+	started := *manifest
+	started.Parts = append([]Part{}, manifest.Parts...)
+	go func() {
+		defer m.finish(manifest.ID)
+		startedAt := time.Now()
+		err := m.export(plugin, config, manifest)
+		if m.observer != nil {
+			m.observer(config, Summary{
+				DatabaseType: manifest.Type,
+				Schema:       manifest.Schema,
+				StorageUnit:  manifest.StorageUnit,
+				Destination:  fmt.Sprintf("exports/%v", manifest.ID),
+				Format:       manifest.Format,
+				Rows:         manifest.Rows,
+				StartedAt:    startedAt,
+				Error:        err,
+			})
+		}
+		if err != nil {
+			log.LogFields(log.Fields{
+				"export":      manifest.ID,
+				"storageUnit": manifest.StorageUnit,
+				"rows":        manifest.Rows,
+			}).Errorf("Export failed: %v", err)
+			// Only what was saved after the last complete chunk matches the files on disk.
+			if saved, loadErr := m.Get(manifest.ID); loadErr == nil {
+				manifest = saved
+			}
+			manifest.Status = Status_Failed
+			manifest.Error = err.Error()
+		} else {
+			manifest.Status = Status_Completed
+		}
+		if err := m.save(manifest); err != nil {
+			log.Logger.Errorf("Unable to save the manifest of export %v: %v", manifest.ID, err)
+		}
+	}()
+	return &started, nil
+}
+
+func (m *Manager) finish(id string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.running, id)
+}
+
+func (m *Manager) export(plugin *engine.Plugin, config *engine.PluginConfig, manifest *Manifest) error {
+	var file *os.File
+	var write RowWriter
+	var part *Part
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	// openPart continues the last part when it has room left, dropping whatever was written
+	// after the manifest was last saved, and starts a new one otherwise.
+	openPart := func() error {
+		if len(manifest.Parts) == 0 || manifest.Parts[len(manifest.Parts)-1].Rows >= manifest.RowsPerPart {
+			manifest.Parts = append(manifest.Parts, Part{
+				File:     fmt.Sprintf("part-%05d.%v", len(manifest.Parts)+1, manifest.Format),
+				AfterKey: manifest.Cursor.Key,
+			})
+		}
+		part = &manifest.Parts[len(manifest.Parts)-1]
+		var err error
+		file, err = os.OpenFile(filepath.Join(m.path(manifest.ID), part.File), os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		if err := file.Truncate(part.Bytes); err != nil {
+			return err
+		}
+		if _, err := file.Seek(part.Bytes, 0); err != nil {
+			return err
+		}
+		write = NewRowWriter(file, manifest.Format, part.Rows == 0)
+		return nil
+	}
+	if err := openPart(); err != nil {
+		return err
+	}
+
+	chunkRows := 0
+	writeRow := Project(manifest.Columns, func(columns []engine.Column, row []string, nulls []bool) error {
+		chunkRows++
+		return write(columns, row, nulls)
+	})
+	afterChunk := func() error {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		part.Bytes = info.Size()
+		part.Rows += chunkRows
+		part.LastKey = manifest.Cursor.Key
+		manifest.Rows = manifest.Cursor.Offset
+		chunkRows = 0
+		if err := m.save(manifest); err != nil {
+			return err
+		}
+		if part.Rows < manifest.RowsPerPart {
+			return nil
+		}
+		file.Close()
+		file = nil
+		return openPart()
+	}
+	if err := StorageUnit(plugin, config, manifest.Schema, manifest.StorageUnit, manifest.Where, &manifest.Cursor, writeRow, afterChunk); err != nil {
+		return err
+	}
+
+	// The last part is opened as soon as the one before fills up, even when no rows are left.
+	if last := len(manifest.Parts) - 1; last > 0 && manifest.Parts[last].Rows == 0 {
+		file.Close()
+		file = nil
+		os.Remove(filepath.Join(m.path(manifest.ID), manifest.Parts[last].File))
+		manifest.Parts = manifest.Parts[:last]
+	}
+	return nil
+}
+
+func (m *Manager) save(manifest *Manifest) error {
+	manifest.UpdatedAt = time.Now()
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.path(manifest.ID), manifestFile)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func newID() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
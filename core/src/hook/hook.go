@@ -0,0 +1,366 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+type Phase string
+
+const (
+	Phase_Before Phase = "before"
+	Phase_After  Phase = "after"
+)
+
+type Action string
+
+const (
+	// Action_Block rejects the statement with Message.
+	Action_Block Action = "block"
+	// Action_Tag prepends Comment to the statement as a SQL comment.
+	Action_Tag Action = "tag"
+	// Action_Webhook POSTs an Event to URL without waiting for it.
+	Action_Webhook Action = "webhook"
+	// Action_Command runs Command with the Event as JSON on its standard input. Before a
+	// statement it is waited for: a non-zero exit blocks the statement with what the command
+	// wrote to standard error, and anything written to standard output replaces the statement.
+	Action_Command Action = "command"
+)
+
+const hookTimeout = 10 * time.Second
+
+// Hook is an action run before or after the statements it matches. All conditions that
+// are set must hold for a statement to match.
+type Hook struct {
+	Name  string `json:"name"`
+	Phase Phase  `json:"phase"`
+	// Connections are patterns of the form "<type>://<hostname>/<database>", as in the auth policy.
+	Connections []string `json:"connections"`
+	// Statements are the first keywords of the matching statements, e.g. CREATE or DROP.
+	Statements []string `json:"statements"`
+	// Tables match statements referencing any of them, optionally schema qualified.
+	Tables []string `json:"tables"`
+	// Days (Mon, Tue...) and Hours ("09:00-17:00") restrict the hook to a time window in Timezone.
+	Days     []string `json:"days"`
+	Hours    string   `json:"hours"`
+	Timezone string   `json:"timezone"`
+
+	Action  Action   `json:"action"`
+	Message string   `json:"message"`
+	Comment string   `json:"comment"`
+	URL     string   `json:"url"`
+	Command []string `json:"command"`
+}
+
+// Event describes the statement a hook runs for; Rows, Error and DurationMs are only set after it.
+type Event struct {
+	Hook         string
+	Phase        Phase
+	DatabaseType string
+	Connection   string
+	Tenant       string
+	Caller       string
+	Query        string
+	Rows         int
+	Error        string
+	DurationMs   int64
+}
+
+// Hooks runs the configured hooks around raw statements, in the order they are defined.
+type Hooks struct {
+	hooks  []Hook
+	client *http.Client
+	now    func() time.Time
+}
+
+// Load reads the hooks, a JSON array, from path.
+func Load(path string) (*Hooks, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read hooks: %w", err)
+	}
+	hooks := []Hook{}
+	if err := json.Unmarshal(content, &hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks: %w", err)
+	}
+	for _, hook := range hooks {
+		if err := hook.validate(); err != nil {
+			return nil, fmt.Errorf("invalid hook %q: %w", hook.Name, err)
+		}
+	}
+	return &Hooks{
+		hooks:  hooks,
+		client: &http.Client{Timeout: hookTimeout},
+		now:    time.Now,
+	}, nil
+}
+
+func (h Hook) validate() error {
+	if h.Phase != Phase_Before && h.Phase != Phase_After {
+		return fmt.Errorf("phase must be %v or %v", Phase_Before, Phase_After)
+	}
+	if _, _, err := parseHours(h.Hours); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(h.Timezone); err != nil {
+		return err
+	}
+	switch h.Action {
+	case Action_Block, Action_Tag:
+		if h.Phase != Phase_Before {
+			return fmt.Errorf("%v hooks can only run before statements", h.Action)
+		}
+		if h.Action == Action_Tag && len(h.Comment) == 0 {
+			return fmt.Errorf("comment is required")
+		}
+	case Action_Webhook:
+		if len(h.URL) == 0 {
+			return fmt.Errorf("url is required")
+		}
+	case Action_Command:
+		if len(h.Command) == 0 {
+			return fmt.Errorf("command is required")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", h.Action)
+	}
+	return nil
+}
+
+func (h *Hooks) Before(databaseType engine.DatabaseType, config *engine.PluginConfig, query string) (string, error) {
+	for _, hook := range h.matching(Phase_Before, databaseType, config, query) {
+		switch hook.Action {
+		case Action_Block:
+			if len(hook.Message) == 0 {
+				return "", fmt.Errorf("%w %v", engine.ErrBlockedByHook, hook.Name)
+			}
+			return "", fmt.Errorf("%w %v: %v", engine.ErrBlockedByHook, hook.Name, hook.Message)
+		case Action_Tag:
+			// The comment cannot be allowed to end early and smuggle SQL in.
+			query = fmt.Sprintf("/* %v */ %v", strings.ReplaceAll(hook.Comment, "*/", "* /"), query)
+		case Action_Webhook:
+			h.notify(hook, newEvent(hook, databaseType, config, query))
+		case Action_Command:
+			rewritten, err := h.runBefore(hook, newEvent(hook, databaseType, config, query))
+			if err != nil {
+				return "", err
+			}
+			query = rewritten
+		}
+	}
+	return query, nil
+}
+
+func (h *Hooks) After(databaseType engine.DatabaseType, config *engine.PluginConfig, query string, result *engine.GetRowsResult, err error, duration time.Duration) {
+	for _, hook := range h.matching(Phase_After, databaseType, config, query) {
+		event := newEvent(hook, databaseType, config, query)
+		event.DurationMs = duration.Milliseconds()
+		if result != nil {
+			event.Rows = len(result.Rows)
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		switch hook.Action {
+		case Action_Webhook:
+			h.notify(hook, event)
+		case Action_Command:
+			go func(hook Hook) {
+				if _, err := runCommand(hook, event); err != nil {
+					log.Logger.Warnf("Hook %v failed: %v", hook.Name, err)
+				}
+			}(hook)
+		}
+	}
+}
+
+func (h *Hooks) matching(phase Phase, databaseType engine.DatabaseType, config *engine.PluginConfig, query string) []Hook {
+	matching := []Hook{}
+	var tables []lineage.Table
+	for _, hook := range h.hooks {
+		if hook.Phase != phase || !hook.matchesConnection(databaseType, config) || !hook.matchesStatement(query) || !hook.matchesTime(h.now()) {
+			continue
+		}
+		if len(hook.Tables) > 0 {
+			if tables == nil {
+				tables = lineage.ReferencedTables(query)
+			}
+			if !hook.matchesTables(tables) {
+				continue
+			}
+		}
+		matching = append(matching, hook)
+	}
+	return matching
+}
+
+func connectionName(databaseType engine.DatabaseType, config *engine.PluginConfig) string {
+	if config == nil || config.Credentials == nil {
+		return fmt.Sprintf("%v://", databaseType)
+	}
+	return fmt.Sprintf("%v://%v/%v", databaseType, config.Credentials.Hostname, config.Credentials.Database)
+}
+
+func (h Hook) matchesConnection(databaseType engine.DatabaseType, config *engine.PluginConfig) bool {
+	return len(h.Connections) == 0 || common.MatchesAnyPattern(h.Connections, connectionName(databaseType, config))
+}
+
+func (h Hook) matchesStatement(query string) bool {
+	if len(h.Statements) == 0 {
+		return true
+	}
+	keywords := common.SQLKeywords(query)
+	for _, keyword := range keywords {
+		if keyword == ";" {
+			continue
+		}
+		for _, statement := range h.Statements {
+			if strings.EqualFold(statement, keyword) {
+				return true
+			}
+		}
+		// Only the first keyword of the statement decides what it is.
+		return false
+	}
+	return false
+}
+
+func (h Hook) matchesTables(tables []lineage.Table) bool {
+	for _, table := range tables {
+		for _, pattern := range h.Tables {
+			schema, name, qualified := strings.Cut(pattern, ".")
+			if !qualified {
+				schema, name = "", pattern
+			}
+			if !strings.EqualFold(name, table.Table) {
+				continue
+			}
+			if len(schema) == 0 || len(table.Schema) == 0 || strings.EqualFold(schema, table.Schema) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h Hook) matchesTime(now time.Time) bool {
+	location, err := time.LoadLocation(h.Timezone)
+	if err != nil {
+		return false
+	}
+	now = now.In(location)
+	if len(h.Days) > 0 && !common.ContainsString(h.Days, now.Weekday().String()[:3]) {
+		return false
+	}
+	if len(h.Hours) == 0 {
+		return true
+	}
+	from, to, _ := parseHours(h.Hours)
+	minute := now.Hour()*60 + now.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	// Windows such as 22:00-06:00 span midnight.
+	return minute >= from || minute < to
+}
+
+// parseHours parses a window such as "09:00-17:00" into minutes since midnight.
+func parseHours(hours string) (int, int, error) {
+	if len(hours) == 0 {
+		return 0, 0, nil
+	}
+	fromText, toText, ok := strings.Cut(hours, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("hours must look like 09:00-17:00")
+	}
+	from, err := time.Parse("15:04", strings.TrimSpace(fromText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hours: %w", err)
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(toText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hours: %w", err)
+	}
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), nil
+}
+
+func newEvent(hook Hook, databaseType engine.DatabaseType, config *engine.PluginConfig, query string) Event {
+	event := Event{
+		Hook:         hook.Name,
+		Phase:        hook.Phase,
+		DatabaseType: string(databaseType),
+		Connection:   connectionName(databaseType, config),
+		Query:        query,
+	}
+	if config != nil {
+		event.Caller = config.Caller
+		if config.Credentials != nil {
+			event.Tenant = config.Credentials.Tenant
+		}
+	}
+	return event
+}
+
+// notify posts the event in the background so that a slow endpoint does not hold up queries.
+func (h *Hooks) notify(hook Hook, event Event) {
+	go func() {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		response, err := h.client.Post(hook.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Logger.Warnf("Hook %v failed: %v", hook.Name, err)
+			return
+		}
+		response.Body.Close()
+		if response.StatusCode >= http.StatusBadRequest {
+			log.Logger.Warnf("Hook %v failed: webhook responded with %v", hook.Name, response.Status)
+		}
+	}()
+}
+
+func (h *Hooks) runBefore(hook Hook, event Event) (string, error) {
+	output, err := runCommand(hook, event)
+	if err != nil {
+		return "", fmt.Errorf("%w %v: %v", engine.ErrBlockedByHook, hook.Name, err)
+	}
+	if rewritten := strings.TrimSpace(output); len(rewritten) > 0 {
+		return rewritten, nil
+	}
+	return event.Query, nil
+}
+
+func runCommand(hook Hook, event Event) (string, error) {
+	input, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	command := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	command.Stdin = bytes.NewReader(input)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	command.Stdout = stdout
+	command.Stderr = stderr
+	if err := command.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return "", errors.New(message)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
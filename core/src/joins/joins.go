@@ -0,0 +1,130 @@
+package joins
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// Suggestion is a complete JOIN clause from the source table to Table, written as Alias.
+type Suggestion struct {
+	Schema string
+	Table  string
+	Alias  string
+	Clause string
+}
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedWords are the keywords likely to collide with table, column or alias names.
+var reservedWords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BY": true, "CASE": true, "CHECK": true,
+	"COLUMN": true, "CROSS": true, "DEFAULT": true, "DESC": true, "DISTINCT": true, "DO": true,
+	"ELSE": true, "END": true, "FOR": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true,
+	"IF": true, "IN": true, "INDEX": true, "INNER": true, "IS": true, "JOIN": true, "KEY": true,
+	"LEFT": true, "LIKE": true, "LIMIT": true, "NOT": true, "NULL": true, "OF": true, "ON": true,
+	"OR": true, "ORDER": true, "OUTER": true, "PRIMARY": true, "REFERENCES": true, "RIGHT": true,
+	"SELECT": true, "SET": true, "TABLE": true, "THEN": true, "TO": true, "UNION": true,
+	"UNIQUE": true, "USER": true, "USING": true, "VALUES": true, "WHEN": true, "WHERE": true,
+	"WITH": true,
+}
+
+// Suggest returns a JOIN clause for every foreign key from or to table, which the query refers
+// to as alias (derived from its name when empty). Tables outside schema are qualified, and
+// identifiers are quoted for dbType only where they need to be.
+func Suggest(dbType engine.DatabaseType, foreignKeys []engine.ForeignKey, schema string, table string, alias string) []Suggestion {
+	taken := map[string]bool{}
+	if alias == "" {
+		alias = uniqueAlias(Alias(table), taken)
+	}
+	taken[strings.ToLower(alias)] = true
+
+	suggestions := []Suggestion{}
+	add := func(targetSchema string, targetTable string, targetColumns []string, sourceColumns []string) {
+		targetAlias := uniqueAlias(Alias(targetTable), taken)
+		conditions := []string{}
+		for i := range targetColumns {
+			conditions = append(conditions, fmt.Sprintf("%v.%v = %v.%v",
+				targetAlias, quote(dbType, targetColumns[i]), alias, quote(dbType, sourceColumns[i])))
+		}
+		name := quote(dbType, targetTable)
+		if targetSchema != schema {
+			name = quote(dbType, targetSchema) + "." + name
+		}
+		suggestions = append(suggestions, Suggestion{
+			Schema: targetSchema,
+			Table:  targetTable,
+			Alias:  targetAlias,
+			Clause: fmt.Sprintf("JOIN %v %v ON %v", name, targetAlias, strings.Join(conditions, " AND ")),
+		})
+	}
+
+	for _, foreignKey := range foreignKeys {
+		if len(foreignKey.Columns) == 0 || len(foreignKey.Columns) != len(foreignKey.ReferencedColumns) {
+			continue
+		}
+		if foreignKey.Schema == schema && foreignKey.Table == table {
+			add(foreignKey.ReferencedSchema, foreignKey.ReferencedTable, foreignKey.ReferencedColumns, foreignKey.Columns)
+		}
+		if foreignKey.ReferencedSchema == schema && foreignKey.ReferencedTable == table {
+			add(foreignKey.Schema, foreignKey.Table, foreignKey.Columns, foreignKey.ReferencedColumns)
+		}
+	}
+	return suggestions
+}
+
+// Alias abbreviates a table name to the first letter of each of its words, so that
+// order_items becomes oi and userAccounts becomes ua.
+func Alias(table string) string {
+	alias := []rune{}
+	previous := '_'
+	for _, r := range table {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+		case !unicode.IsLetter(previous) && !unicode.IsDigit(previous),
+			unicode.IsUpper(r) && unicode.IsLower(previous):
+			if unicode.IsLetter(r) {
+				alias = append(alias, unicode.ToLower(r))
+			}
+		}
+		previous = r
+	}
+	if len(alias) == 0 {
+		return "t"
+	}
+	return string(alias)
+}
+
+// uniqueAlias numbers alias from 2 until it is neither taken nor a keyword, and takes it.
+func uniqueAlias(alias string, taken map[string]bool) string {
+	candidate := alias
+	for i := 2; taken[strings.ToLower(candidate)] || reservedWords[strings.ToUpper(candidate)]; i++ {
+		candidate = fmt.Sprintf("%v%v", alias, i)
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func quote(dbType engine.DatabaseType, identifier string) string {
+	plain := plainIdentifier.MatchString(identifier) && !reservedWords[strings.ToUpper(identifier)]
+	switch dbType {
+	case engine.DatabaseType_MySQL, engine.DatabaseType_BigQuery:
+		if plain {
+			return identifier
+		}
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	case engine.DatabaseType_Postgres:
+		// Unquoted names are folded to lower case.
+		plain = plain && identifier == strings.ToLower(identifier)
+	case engine.DatabaseType_Snowflake, engine.DatabaseType_Oracle:
+		// Unquoted names are folded to upper case.
+		plain = plain && identifier == strings.ToUpper(identifier)
+	}
+	if plain {
+		return identifier
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,513 @@
+package lineage
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+var ErrUnparsable = errors.New("unable to parse the view definition")
+
+// relation is a table referenced in a FROM clause. Base tables are identified by schema
+// and table, while subqueries and common table expressions are derived: their columns
+// already point at the base columns they come from.
+type relation struct {
+	schema  string
+	table   string
+	derived bool
+	names   []string
+	columns map[string][]engine.ColumnSource
+}
+
+// scope holds the relations a SELECT can reference, keyed by their lower-cased alias.
+type scope struct {
+	relations map[string]*relation
+	ctes      map[string]*relation
+	schema    string
+}
+
+type selectColumn struct {
+	name    string
+	sources []engine.ColumnSource
+}
+
+// ViewLineage reports the base table columns each column of a view is computed from.
+// Definitions are expected the way Postgres and MySQL print them, with the star already
+// expanded; tables without a schema are looked up in defaultSchema. Columns computed from
+// nothing but constants, or from references that cannot be resolved, have no sources.
+func ViewLineage(view *engine.ViewDefinition, defaultSchema string) ([]engine.ColumnLineage, error) {
+	tokens := tokenize(view.Definition)
+	for len(tokens) > 0 && tokens[len(tokens)-1].isSymbol(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	columns, err := parseQuery(tokens, &scope{ctes: map[string]*relation{}, schema: defaultSchema})
+	if err != nil {
+		return nil, err
+	}
+	lineage := []engine.ColumnLineage{}
+	for i, column := range columns {
+		name := column.name
+		// The catalog knows the names of columns the definition leaves unnamed.
+		if len(view.Columns) == len(columns) {
+			name = view.Columns[i]
+		}
+		lineage = append(lineage, engine.ColumnLineage{Column: name, Sources: column.sources})
+	}
+	return lineage, nil
+}
+
+// parseQuery parses a query made of one or more SELECTs combined with UNION, INTERSECT or
+// EXCEPT, optionally preceded by common table expressions.
+func parseQuery(tokens []token, parent *scope) ([]selectColumn, error) {
+	tokens = unwrap(tokens)
+	ctes := map[string]*relation{}
+	for name, cte := range parent.ctes {
+		ctes[name] = cte
+	}
+	if len(tokens) > 0 && tokens[0].isKeyword("WITH") {
+		var err error
+		tokens, err = parseCTEs(tokens[1:], &scope{ctes: ctes, schema: parent.schema})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var columns []selectColumn
+	for _, branch := range splitSetOperations(tokens) {
+		branchColumns, err := parseSelect(unwrap(branch), &scope{ctes: ctes, schema: parent.schema})
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			columns = branchColumns
+			continue
+		}
+		// Later branches feed the columns of the first one by position.
+		for i := 0; i < len(columns) && i < len(branchColumns); i++ {
+			columns[i].sources = appendSources(columns[i].sources, branchColumns[i].sources...)
+		}
+	}
+	if columns == nil {
+		return nil, ErrUnparsable
+	}
+	return columns, nil
+}
+
+// parseCTEs adds the common table expressions at the start of tokens to the scope and
+// returns the query that follows them.
+func parseCTEs(tokens []token, cteScope *scope) ([]token, error) {
+	if len(tokens) > 0 && tokens[0].isKeyword("RECURSIVE") {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 {
+		if !tokens[0].isIdentifier() {
+			return nil, ErrUnparsable
+		}
+		name := strings.ToLower(tokens[0].value)
+		tokens = tokens[1:]
+		var columnNames []string
+		if len(tokens) > 0 && tokens[0].isSymbol("(") {
+			end := matchingParenthesis(tokens, 0)
+			columnNames = identifierList(tokens[1:end])
+			tokens = tokens[end+1:]
+		}
+		for len(tokens) > 0 && tokens[0].isKeyword("AS", "NOT", "MATERIALIZED") {
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 || !tokens[0].isSymbol("(") {
+			return nil, ErrUnparsable
+		}
+		end := matchingParenthesis(tokens, 0)
+		columns, err := parseQuery(tokens[1:end], cteScope)
+		if err != nil {
+			return nil, err
+		}
+		cteScope.ctes[name] = derivedRelation(columns, columnNames)
+		tokens = tokens[end+1:]
+		if len(tokens) == 0 || !tokens[0].isSymbol(",") {
+			return tokens, nil
+		}
+		tokens = tokens[1:]
+	}
+	return nil, ErrUnparsable
+}
+
+func parseSelect(tokens []token, selectScope *scope) ([]selectColumn, error) {
+	if len(tokens) == 0 || !tokens[0].isKeyword("SELECT") {
+		return nil, ErrUnparsable
+	}
+	i := 1
+	for i < len(tokens) && tokens[i].isKeyword("DISTINCT", "ALL", "DISTINCTROW", "SQL_NO_CACHE", "SQL_CACHE", "SQL_CALC_FOUND_ROWS", "HIGH_PRIORITY", "STRAIGHT_JOIN") {
+		i++
+		// Postgres' DISTINCT ON (expressions) does not name any output column.
+		if i < len(tokens) && tokens[i].isKeyword("ON") && i+1 < len(tokens) && tokens[i+1].isSymbol("(") {
+			i = matchingParenthesis(tokens, i+1) + 1
+		}
+	}
+
+	selectEnd := findKeyword(tokens, i, "FROM")
+	selectScope.relations = map[string]*relation{}
+	if selectEnd < len(tokens) {
+		fromEnd := len(tokens)
+		for _, keyword := range []string{"WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR"} {
+			if end := findKeyword(tokens, selectEnd+1, keyword); end < fromEnd {
+				fromEnd = end
+			}
+		}
+		if err := parseFrom(tokens[selectEnd+1:fromEnd], selectScope); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := []selectColumn{}
+	for _, item := range splitTopLevel(tokens[i:selectEnd], ",") {
+		if len(item) == 0 {
+			return nil, ErrUnparsable
+		}
+		columns = append(columns, parseSelectItem(item, selectScope))
+	}
+	return columns, nil
+}
+
+func parseSelectItem(item []token, selectScope *scope) selectColumn {
+	name := ""
+	expression := item
+	last := len(item) - 1
+	switch {
+	case last >= 2 && item[last-1].isKeyword("AS") && item[last].isIdentifier():
+		name, expression = item[last].value, item[:last-1]
+	case last >= 1 && item[last].isIdentifier() && !item[last-1].isSymbol(".") && !item[last-1].isSymbol("::") && !isOperator(item[last-1]):
+		name, expression = item[last].value, item[:last]
+	case isColumnReference(item):
+		// An unaliased column reference keeps the column's name.
+		name = item[last].value
+	}
+	return selectColumn{name: name, sources: expressionSources(expression, selectScope)}
+}
+
+// parseFrom adds the relations of a FROM clause to the scope.
+func parseFrom(tokens []token, fromScope *scope) error {
+	for i := 0; i < len(tokens); {
+		for i < len(tokens) && isJoinKeyword(tokens[i]) {
+			i++
+		}
+		if i >= len(tokens) {
+			break
+		}
+
+		var rel *relation
+		alias := ""
+		switch {
+		case tokens[i].isSymbol("("):
+			end := matchingParenthesis(tokens, i)
+			inner := tokens[i+1 : end]
+			if len(inner) > 0 && inner[0].isKeyword("SELECT", "WITH") {
+				columns, err := parseQuery(inner, fromScope)
+				if err != nil {
+					return err
+				}
+				rel = derivedRelation(columns, nil)
+			} else if err := parseFrom(inner, fromScope); err != nil {
+				// A parenthesized join adds its tables to the same scope.
+				return err
+			}
+			i = end + 1
+		case tokens[i].isIdentifier():
+			// Postgres' ONLY excludes inheriting tables, which does not change the columns.
+			if tokens[i].isKeyword("ONLY") && i+1 < len(tokens) && tokens[i+1].isIdentifier() {
+				i++
+			}
+			names := []string{}
+			for i < len(tokens) && tokens[i].isIdentifier() {
+				names = append(names, tokens[i].value)
+				i++
+				if i+1 < len(tokens) && tokens[i].isSymbol(".") {
+					i++
+					continue
+				}
+				break
+			}
+			if len(names) == 0 {
+				return ErrUnparsable
+			}
+			table := names[len(names)-1]
+			alias = table
+			if i < len(tokens) && tokens[i].isSymbol("(") {
+				// Set returning functions produce columns of their own.
+				i = matchingParenthesis(tokens, i) + 1
+				rel = &relation{derived: true, columns: map[string][]engine.ColumnSource{}}
+			} else if cte, ok := fromScope.ctes[strings.ToLower(table)]; ok && len(names) == 1 {
+				rel = cte
+			} else {
+				rel = &relation{schema: fromScope.schema, table: table}
+				if len(names) > 1 {
+					rel.schema = names[len(names)-2]
+				}
+			}
+		default:
+			return ErrUnparsable
+		}
+
+		if i < len(tokens) && tokens[i].isKeyword("AS") {
+			i++
+		}
+		if i < len(tokens) && tokens[i].isIdentifier() {
+			alias = tokens[i].value
+			i++
+			if i < len(tokens) && tokens[i].isSymbol("(") && rel != nil && rel.derived {
+				end := matchingParenthesis(tokens, i)
+				rel = renameColumns(rel, identifierList(tokens[i+1:end]))
+				i = end + 1
+			}
+		}
+		if rel != nil {
+			fromScope.relations[strings.ToLower(alias)] = rel
+		}
+
+		// Skip join conditions up to the next table.
+		for depth := 0; i < len(tokens); i++ {
+			if tokens[i].isSymbol("(") {
+				depth++
+			} else if tokens[i].isSymbol(")") {
+				depth--
+			} else if depth == 0 && (tokens[i].isSymbol(",") || isJoinKeyword(tokens[i])) {
+				break
+			}
+		}
+		if i < len(tokens) && tokens[i].isSymbol(",") {
+			i++
+		}
+	}
+	return nil
+}
+
+// expressionSources returns the base columns referenced anywhere in an expression.
+func expressionSources(tokens []token, exprScope *scope) []engine.ColumnSource {
+	sources := []engine.ColumnSource{}
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i].isSymbol("(") && i+1 < len(tokens) && tokens[i+1].isKeyword("SELECT", "WITH"):
+			end := matchingParenthesis(tokens, i)
+			if columns, err := parseQuery(tokens[i+1:end], exprScope); err == nil {
+				for _, column := range columns {
+					sources = appendSources(sources, column.sources...)
+				}
+			}
+			i = end
+		case tokens[i].isSymbol("::"):
+			// Skip the type of a cast, e.g. ::character varying(10)[].
+			for i+1 < len(tokens) && tokens[i+1].kind == token_Word && !tokens[i+1].isKeyword("AS") {
+				i++
+			}
+			for i+1 < len(tokens) && (tokens[i+1].isSymbol("(") || tokens[i+1].isSymbol("[")) {
+				if tokens[i+1].isSymbol("(") {
+					i = matchingParenthesis(tokens, i+1)
+				} else {
+					i += 2
+				}
+			}
+		case tokens[i].isKeyword("AS"):
+			// Inside CAST(value AS type), the type is not a column.
+			for i+1 < len(tokens) && tokens[i+1].kind == token_Word {
+				i++
+			}
+		case tokens[i].isIdentifier():
+			names := []string{tokens[i].value}
+			for i+2 < len(tokens) && tokens[i+1].isSymbol(".") && tokens[i+2].isIdentifier() {
+				names = append(names, tokens[i+2].value)
+				i += 2
+			}
+			// Function calls, qualified stars and the field of EXTRACT(field FROM value) are not columns.
+			if i+1 < len(tokens) && (tokens[i+1].isSymbol("(") || tokens[i+1].isSymbol(".") || tokens[i+1].isKeyword("FROM")) {
+				continue
+			}
+			sources = appendSources(sources, exprScope.resolve(names)...)
+		}
+	}
+	return sources
+}
+
+// resolve returns the base columns a possibly qualified column reference stands for.
+func (s *scope) resolve(names []string) []engine.ColumnSource {
+	column := names[len(names)-1]
+	if len(names) == 1 {
+		if len(s.relations) == 1 {
+			for _, rel := range s.relations {
+				return rel.source(column)
+			}
+		}
+		// With several tables, only derived ones tell which columns they have.
+		for _, rel := range s.relations {
+			if rel.derived {
+				if sources, ok := rel.columns[strings.ToLower(column)]; ok {
+					return sources
+				}
+			}
+		}
+		return nil
+	}
+
+	table := names[len(names)-2]
+	if rel, ok := s.relations[strings.ToLower(table)]; ok {
+		if len(names) == 2 || strings.EqualFold(rel.schema, names[len(names)-3]) || rel.derived {
+			return rel.source(column)
+		}
+	}
+	if len(names) >= 3 {
+		for _, rel := range s.relations {
+			if !rel.derived && strings.EqualFold(rel.table, table) && strings.EqualFold(rel.schema, names[len(names)-3]) {
+				return rel.source(column)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *relation) source(column string) []engine.ColumnSource {
+	if r.derived {
+		return r.columns[strings.ToLower(column)]
+	}
+	return []engine.ColumnSource{{Schema: r.schema, Table: r.table, Column: column}}
+}
+
+// derivedRelation turns the columns of a subquery into a relation, renamed by position
+// when the query names them explicitly.
+func derivedRelation(columns []selectColumn, names []string) *relation {
+	rel := &relation{derived: true, columns: map[string][]engine.ColumnSource{}}
+	for i, column := range columns {
+		name := column.name
+		if i < len(names) {
+			name = names[i]
+		}
+		rel.names = append(rel.names, name)
+		rel.columns[strings.ToLower(name)] = column.sources
+	}
+	return rel
+}
+
+func renameColumns(rel *relation, names []string) *relation {
+	columns := []selectColumn{}
+	for _, name := range rel.names {
+		columns = append(columns, selectColumn{name: name, sources: rel.columns[strings.ToLower(name)]})
+	}
+	return derivedRelation(columns, names)
+}
+
+func appendSources(sources []engine.ColumnSource, newSources ...engine.ColumnSource) []engine.ColumnSource {
+	for _, source := range newSources {
+		found := false
+		for _, existing := range sources {
+			if existing == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// unwrap removes parentheses around a whole query, as in (SELECT ...) UNION (SELECT ...).
+func unwrap(tokens []token) []token {
+	for len(tokens) >= 2 && tokens[0].isSymbol("(") && matchingParenthesis(tokens, 0) == len(tokens)-1 {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	return tokens
+}
+
+func splitSetOperations(tokens []token) [][]token {
+	branches := [][]token{}
+	start := 0
+	for depth, i := 0, 0; i < len(tokens); i++ {
+		if tokens[i].isSymbol("(") {
+			depth++
+		} else if tokens[i].isSymbol(")") {
+			depth--
+		} else if depth == 0 && tokens[i].isKeyword("UNION", "INTERSECT", "EXCEPT") {
+			branches = append(branches, tokens[start:i])
+			if i+1 < len(tokens) && tokens[i+1].isKeyword("ALL", "DISTINCT") {
+				i++
+			}
+			start = i + 1
+		}
+	}
+	return append(branches, tokens[start:])
+}
+
+func splitTopLevel(tokens []token, separator string) [][]token {
+	parts := [][]token{}
+	start := 0
+	for depth, i := 0, 0; i < len(tokens); i++ {
+		if tokens[i].isSymbol("(") {
+			depth++
+		} else if tokens[i].isSymbol(")") {
+			depth--
+		} else if depth == 0 && tokens[i].isSymbol(separator) {
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// findKeyword returns the index of the first keyword outside parentheses from start on,
+// or the number of tokens when there is none.
+func findKeyword(tokens []token, start int, keyword string) int {
+	for depth, i := 0, start; i < len(tokens); i++ {
+		if tokens[i].isSymbol("(") {
+			depth++
+		} else if tokens[i].isSymbol(")") {
+			depth--
+		} else if depth == 0 && tokens[i].isKeyword(keyword) {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// matchingParenthesis returns the index of the parenthesis closing the one at start, or
+// the last index when it is never closed.
+func matchingParenthesis(tokens []token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		if tokens[i].isSymbol("(") {
+			depth++
+		} else if tokens[i].isSymbol(")") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+func identifierList(tokens []token) []string {
+	names := []string{}
+	for _, part := range splitTopLevel(tokens, ",") {
+		if len(part) == 1 && part[0].isIdentifier() {
+			names = append(names, part[0].value)
+		}
+	}
+	return names
+}
+
+func isColumnReference(tokens []token) bool {
+	for i, t := range tokens {
+		if (i%2 == 0 && !t.isIdentifier()) || (i%2 == 1 && !t.isSymbol(".")) {
+			return false
+		}
+	}
+	return len(tokens)%2 == 1
+}
+
+func isJoinKeyword(t token) bool {
+	return t.isKeyword("JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "FULL", "CROSS", "NATURAL", "STRAIGHT_JOIN", "LATERAL")
+}
+
+func isOperator(t token) bool {
+	return t.kind == token_Symbol && !t.isSymbol(")")
+}
@@ -0,0 +1,159 @@
+package lineage
+
+import "strings"
+
+// Table is a table referenced by a query. Schema is empty when the query does not qualify it.
+type Table struct {
+	Schema string
+	Table  string
+}
+
+// Reference is a table referenced by a query, with the alias the query gives it. Offset is
+// where the name of the table starts in the query, in runes.
+type Reference struct {
+	Table
+	Alias  string
+	Offset int
+}
+
+// ReferencedTables returns the tables a query reads or writes, in order of appearance.
+// Common table expressions are left out since they are not tables.
+func ReferencedTables(query string) []Table {
+	tables := []Table{}
+	for _, reference := range References(query) {
+		tables = appendTable(tables, reference.Table)
+	}
+	return tables
+}
+
+// References returns every reference to a table in a query, in order of appearance, leaving
+// out common table expressions.
+func References(query string) []Reference {
+	tokens := tokenize(query)
+	ctes := map[string]bool{}
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].isIdentifier() && tokens[i+1].isKeyword("AS") && tokens[i+2].isSymbol("(") {
+			ctes[strings.ToLower(tokens[i].value)] = true
+		}
+		// WITH name (columns) AS (...)
+		if tokens[i].isIdentifier() && tokens[i+1].isSymbol("(") {
+			if end := matchingParenthesis(tokens, i+1); end+2 < len(tokens) && tokens[end+1].isKeyword("AS") && tokens[end+2].isSymbol("(") {
+				ctes[strings.ToLower(tokens[i].value)] = true
+			}
+		}
+	}
+
+	references := []Reference{}
+	// Whether each open parenthesis holds a query; FROM also appears in EXTRACT(field FROM value).
+	queryGroups := []bool{true}
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i].isSymbol("("):
+			queryGroups = append(queryGroups, i+1 < len(tokens) && tokens[i+1].isKeyword("SELECT", "WITH"))
+		case tokens[i].isSymbol(")"):
+			if len(queryGroups) > 1 {
+				queryGroups = queryGroups[:len(queryGroups)-1]
+			}
+		case tokens[i].isKeyword("FROM", "JOIN", "UPDATE", "INTO", "TABLE") && queryGroups[len(queryGroups)-1]:
+			// INSERT INTO table (columns) is the only place a name followed by a parenthesis is a table.
+			allowFunctions := !tokens[i].isKeyword("INTO")
+			for {
+				table, next, ok := readTable(tokens, i+1, allowFunctions)
+				if !ok {
+					break
+				}
+				reference := Reference{Table: table, Offset: tokens[next].offset}
+				i = next
+				// FROM a, b lists several tables; read an alias before looking for a comma.
+				if i+1 < len(tokens) && tokens[i+1].isKeyword("AS") {
+					i++
+				}
+				if i+1 < len(tokens) && tokens[i+1].isIdentifier() && !tokens[i+1].isKeyword("SET") {
+					i++
+					reference.Alias = tokens[i].value
+				}
+				if len(table.Schema) > 0 || !ctes[strings.ToLower(table.Table)] {
+					references = append(references, reference)
+				}
+				if i+1 >= len(tokens) || !tokens[i+1].isSymbol(",") || !tokens[i].isIdentifier() {
+					break
+				}
+				i++
+			}
+		}
+	}
+	return references
+}
+
+// readTable reads a possibly qualified table name starting at start and returns the index
+// of its last token. With allowFunctions, a function call there is not a table.
+func readTable(tokens []token, start int, allowFunctions bool) (Table, int, bool) {
+	if start < len(tokens) && tokens[start].isKeyword("ONLY", "IF") {
+		// Skips ONLY, and IF [NOT] EXISTS, which is made of reserved words.
+		for start < len(tokens) && tokens[start].isKeyword("ONLY", "IF", "NOT", "EXISTS") {
+			start++
+		}
+	}
+	names := []string{}
+	i := start
+	for i < len(tokens) && tokens[i].isIdentifier() {
+		names = append(names, tokens[i].value)
+		if i+2 < len(tokens) && tokens[i+1].isSymbol(".") {
+			i += 2
+			continue
+		}
+		break
+	}
+	// A name followed by a parenthesis is a function, e.g. FROM generate_series(1, 10).
+	if len(names) == 0 || (allowFunctions && i+1 < len(tokens) && tokens[i+1].isSymbol("(")) {
+		return Table{}, start, false
+	}
+	table := Table{Table: names[len(names)-1]}
+	if len(names) > 1 {
+		table.Schema = names[len(names)-2]
+	}
+	return table, i, true
+}
+
+func appendTable(tables []Table, table Table) []Table {
+	for _, existing := range tables {
+		if strings.EqualFold(existing.Schema, table.Schema) && strings.EqualFold(existing.Table, table.Table) {
+			return tables
+		}
+	}
+	return append(tables, table)
+}
+
+// Function is a function called by a query. Schema is empty when the call does not qualify it.
+type Function struct {
+	Schema string
+	Name   string
+}
+
+// CalledFunctions returns the functions a query calls, in order of appearance. Type
+// modifiers such as numeric(10, 2), the column lists of INSERT INTO and of common table
+// expressions, and VALUES are not calls.
+func CalledFunctions(query string) []Function {
+	tokens := tokenize(query)
+	functions := []Function{}
+	for i := 0; i+1 < len(tokens); i++ {
+		if !tokens[i].isIdentifier() || !tokens[i+1].isSymbol("(") || tokens[i].isKeyword("VALUES") {
+			continue
+		}
+		function := Function{Name: tokens[i].value}
+		first := i
+		if i >= 2 && tokens[i-1].isSymbol(".") && tokens[i-2].isIdentifier() {
+			function.Schema = tokens[i-2].value
+			first = i - 2
+		}
+		if first > 0 && (tokens[first-1].isSymbol("::") || tokens[first-1].isKeyword("AS", "INTO")) {
+			continue
+		}
+		// WITH name (columns) AS (...)
+		if end := matchingParenthesis(tokens, i+1); end+2 < len(tokens) && tokens[end+1].isKeyword("AS") && tokens[end+2].isSymbol("(") {
+			continue
+		}
+		functions = append(functions, function)
+	}
+	return functions
+}
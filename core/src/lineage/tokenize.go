@@ -0,0 +1,118 @@
+package lineage
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	token_Word tokenKind = iota
+	token_QuotedIdentifier
+	token_String
+	token_Number
+	token_Symbol
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// offset is where the token starts in the query, in runes.
+	offset int
+}
+
+// isKeyword reports whether the token is the given keyword; quoted identifiers never are.
+func (t token) isKeyword(keywords ...string) bool {
+	if t.kind != token_Word {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.value, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == token_Symbol && t.value == symbol
+}
+
+func (t token) isIdentifier() bool {
+	return t.kind == token_QuotedIdentifier || (t.kind == token_Word && !reservedWords[strings.ToUpper(t.value)])
+}
+
+// tokenize splits a SQL statement into tokens, dropping whitespace and comments. Both
+// double quotes (Postgres) and backticks (MySQL) quote identifiers.
+func tokenize(query string) []token {
+	tokens := []token{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			start := i
+			value := strings.Builder{}
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					// A doubled quote stands for the quote itself.
+					if i+1 < len(runes) && runes[i+1] == r {
+						value.WriteRune(r)
+						i++
+						continue
+					}
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+			kind := token_QuotedIdentifier
+			if r == '\'' {
+				kind = token_String
+			}
+			tokens = append(tokens, token{kind: kind, value: value.String(), offset: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for ; i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$'); i++ {
+			}
+			tokens = append(tokens, token{kind: token_Word, value: string(runes[start:i]), offset: start})
+			i--
+		case unicode.IsDigit(r):
+			start := i
+			for ; i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{kind: token_Number, value: string(runes[start:i]), offset: start})
+			i--
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			tokens = append(tokens, token{kind: token_Symbol, value: "::", offset: i})
+			i++
+		default:
+			tokens = append(tokens, token{kind: token_Symbol, value: string(r), offset: i})
+		}
+	}
+	return tokens
+}
+
+// reservedWords are the keywords that can never be a column or table reference.
+var reservedWords = map[string]bool{
+	"ALL": true, "AND": true, "ANY": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "CAST": true, "CROSS": true, "DESC": true, "DISTINCT": true, "ELSE": true,
+	"END": true, "EXCEPT": true, "EXISTS": true, "FALSE": true, "FROM": true, "FULL": true,
+	"GROUP": true, "HAVING": true, "ILIKE": true, "IN": true, "INNER": true, "INTERSECT": true,
+	"INTERVAL": true, "IS": true, "JOIN": true, "LATERAL": true, "LEFT": true, "LIKE": true,
+	"LIMIT": true, "NATURAL": true, "NOT": true, "NULL": true, "OFFSET": true, "ON": true,
+	"OR": true, "ORDER": true, "OUTER": true, "OVER": true, "PARTITION": true, "RIGHT": true,
+	"SELECT": true, "STRAIGHT_JOIN": true, "THEN": true, "TRUE": true, "UNION": true,
+	"USING": true, "WHEN": true, "WHERE": true, "WINDOW": true, "WITH": true,
+	// Functions called without parentheses.
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "CURRENT_USER": true,
+	"LOCALTIME": true, "LOCALTIMESTAMP": true, "SESSION_USER": true,
+}
@@ -0,0 +1,281 @@
+package lint
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+type KeywordCase string
+
+const (
+	KeywordCase_Upper    KeywordCase = "Upper"
+	KeywordCase_Lower    KeywordCase = "Lower"
+	KeywordCase_Preserve KeywordCase = "Preserve"
+)
+
+type FormatOptions struct {
+	// KeywordCase is how keywords are written, upper case by default. Identifiers, strings
+	// and comments are always kept as written.
+	KeywordCase KeywordCase
+	// IndentWidth is the number of spaces per level of indentation, 2 by default.
+	IndentWidth int
+}
+
+// keywords are the words whose case FormatOptions.KeywordCase sets.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ALL ALTER ANALYZE AND ANY AS ASC AVG BEGIN BETWEEN BY CASCADE CASE CAST CHECK COALESCE
+		COMMIT CONFLICT CONSTRAINT COUNT CREATE CROSS DEFAULT DELETE DESC DISTINCT DO DROP ELSE
+		END ESCAPE EXCEPT EXISTS EXPLAIN FALSE FETCH FILTER FIRST FOREIGN FROM FULL GRANT GROUP
+		HAVING ILIKE IN INDEX INNER INSERT INTERSECT INTERVAL INTO IS JOIN KEY LAST LATERAL LEFT
+		LIKE LIMIT MATERIALIZED MAX MIN NATURAL NEXT NOT NOTHING NULL NULLIF NULLS OFFSET ON ONLY
+		OR ORDER OUTER OVER PARTITION PRIMARY RECURSIVE REFERENCES REPLACE RETURNING REVOKE RIGHT
+		ROLLBACK ROW ROWS SELECT SET SIMILAR SOME SUM TABLE THEN TRUE TRUNCATE UNION UNIQUE UPDATE
+		USING VALUES VIEW WHEN WHERE WINDOW WITH
+	`) {
+		keywords[keyword] = true
+	}
+}
+
+// clauseStarts begin a clause, which Format puts on a line of its own above its body.
+var clauseStarts = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "WINDOW": true,
+	"ORDER": true, "LIMIT": true, "OFFSET": true, "UNION": true, "INTERSECT": true, "EXCEPT": true,
+	"INSERT": true, "VALUES": true, "UPDATE": true, "SET": true, "DELETE": true, "RETURNING": true,
+	"WITH": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "FULL": true,
+	"CROSS": true, "NATURAL": true,
+}
+
+// clauseContinuations stay on the line of the clause they follow, as in GROUP BY or LEFT
+// OUTER JOIN.
+var clauseContinuations = map[string]bool{
+	"BY": true, "ALL": true, "DISTINCT": true, "OUTER": true, "JOIN": true, "INTO": true, "FROM": true,
+}
+
+// notClauseAfter lists the words after which a clause keyword is part of something else, as
+// in ON DELETE SET NULL, FOR UPDATE or IS DISTINCT FROM.
+var notClauseAfter = map[string][]string{
+	"UPDATE": {"ON", "DO", "KEY", "FOR"},
+	"DELETE": {"ON"},
+	"SET":    {"CHARACTER", "DELETE", "UPDATE"},
+	"FROM":   {"DISTINCT"},
+	"GROUP":  {"WITHIN"},
+}
+
+const operatorSymbols = "=<>!+-*/%|&^~"
+
+// formatFrame is the statement or parenthesis being formatted. Subqueries are formatted as
+// statements of their own, indented under the parenthesis opening them; other parentheses
+// are kept on one line.
+type formatFrame struct {
+	inline       bool
+	clauseIndent int
+	closeIndent  int
+	// inClause is set once a clause started, so what follows is indented as its body.
+	inClause bool
+	started  bool
+	// between is set after BETWEEN, whose AND does not start a condition.
+	between bool
+	cases   int
+}
+
+func (f *formatFrame) bodyIndent() int {
+	if f.inClause {
+		return f.clauseIndent + 1
+	}
+	return f.clauseIndent
+}
+
+// Format lays out a query written for databaseType: one clause per line with its body
+// indented under it, one item of a list and one condition per line, and subqueries indented
+// under their parenthesis. Comments are kept where they are, and statements are separated by
+// a blank line. Queries that cannot be tokenized, such as ones with an unterminated string,
+// are returned with an error.
+func Format(databaseType engine.DatabaseType, query string, options FormatOptions) (string, error) {
+	tokens, issues := scan(databaseType, query, true)
+	if len(issues) > 0 {
+		return "", errors.New(issues[0].Message)
+	}
+	if options.IndentWidth <= 0 {
+		options.IndentWidth = 2
+	}
+	runes := []rune(query)
+	indent := strings.Repeat(" ", options.IndentWidth)
+
+	out := strings.Builder{}
+	lineEmpty := true
+	lineIndent := 0
+	newline := func(level int) {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat(indent, level))
+		lineEmpty = true
+		lineIndent = level
+	}
+
+	frames := []formatFrame{{}}
+	var prev *token
+	prevWord := ""
+	pendingBody := false
+	breakNext := false
+	newStatement := false
+	unary := false
+	for i := range tokens {
+		t := tokens[i]
+		frame := &frames[len(frames)-1]
+		text := string(runes[t.offset:t.end])
+
+		// A comment on the line of the token before it stays there.
+		trailing := t.kind == token_Comment && prev != nil && !strings.ContainsRune(string(runes[prev.end:t.offset]), '\n')
+		if newStatement && !trailing {
+			newline(0)
+			newline(0)
+			frames = []formatFrame{{}}
+			frame = &frames[0]
+			pendingBody, breakNext, newStatement, prevWord = false, false, false, ""
+		}
+		if t.kind == token_Comment {
+			if !trailing && !lineEmpty {
+				newline(frame.bodyIndent())
+			}
+			if !lineEmpty {
+				out.WriteString(" ")
+			}
+			out.WriteString(text)
+			lineEmpty = false
+			breakNext = !trailing || strings.HasPrefix(text, "--") || strings.HasPrefix(text, "#")
+			prev = &tokens[i]
+			continue
+		}
+
+		word := ""
+		if t.kind == token_Word {
+			word = strings.ToUpper(t.value)
+			qualified := (prev != nil && prev.isSymbol(".")) || (i+1 < len(tokens) && tokens[i+1].isSymbol("."))
+			if keywords[word] && !qualified && prevWord != "AS" {
+				switch options.KeywordCase {
+				case KeywordCase_Lower:
+					text = strings.ToLower(text)
+				case KeywordCase_Preserve:
+				default:
+					text = strings.ToUpper(text)
+				}
+			}
+		}
+		// LEFT and RIGHT are also string functions.
+		call := t.kind == token_Word && i+1 < len(tokens) && tokens[i+1].isSymbol("(") && tokens[i+1].offset == t.end &&
+			(!clauseStarts[word] || word == "LEFT" || word == "RIGHT")
+
+		lineBreak := -1
+		switch {
+		case frame.inline || (prev != nil && prev.isSymbol(".")):
+		case pendingBody && clauseContinuations[word]:
+		case clauseStarts[word] && !call && isClauseStart(word, prevWord, prev, frame):
+			lineBreak = frame.clauseIndent
+			frame.inClause = true
+			frame.between = false
+			pendingBody = true
+		case pendingBody:
+			lineBreak = frame.bodyIndent()
+			pendingBody = false
+		case frame.inClause && frame.cases == 0 && (word == "AND" || word == "OR"):
+			if word == "AND" && frame.between {
+				frame.between = false
+			} else {
+				lineBreak = frame.bodyIndent()
+			}
+		}
+		if lineBreak < 0 && breakNext {
+			lineBreak = frame.bodyIndent()
+		}
+		if t.isSymbol(")") && len(frames) > 1 && !frame.inline {
+			lineBreak = frame.closeIndent
+		}
+		breakNext = false
+
+		if lineBreak >= 0 && !lineEmpty {
+			newline(lineBreak)
+		} else if !lineEmpty && !unary && spaceBefore(prev, t) {
+			out.WriteString(" ")
+		}
+		out.WriteString(text)
+		lineEmpty = false
+		frame.started = true
+
+		unary = (t.isSymbol("-") || t.isSymbol("+")) && (prev == nil || (prev.kind == token_Symbol && !prev.isSymbol(")") && !prev.isSymbol("]")) || (prev.kind == token_Word && keywords[prevWord]))
+		switch {
+		case word == "BETWEEN":
+			frame.between = true
+		case word == "CASE":
+			frame.cases++
+		case word == "END" && frame.cases > 0:
+			frame.cases--
+		case t.isSymbol("("):
+			subquery := false
+			for j := i + 1; j < len(tokens); j++ {
+				if tokens[j].kind != token_Comment {
+					subquery = tokens[j].isKeyword("SELECT", "WITH", "VALUES")
+					break
+				}
+			}
+			frames = append(frames, formatFrame{inline: !subquery, clauseIndent: lineIndent + 1, closeIndent: lineIndent})
+		case t.isSymbol(")"):
+			if len(frames) > 1 {
+				frames = frames[:len(frames)-1]
+			}
+		case t.isSymbol(","):
+			breakNext = !frame.inline && frame.inClause && frame.cases == 0
+		case t.isSymbol(";"):
+			newStatement = true
+		}
+		prev = &tokens[i]
+		prevWord = word
+	}
+	return out.String(), nil
+}
+
+// isClauseStart reports whether a clause keyword starts a clause where it appears.
+func isClauseStart(word string, prevWord string, prev *token, frame *formatFrame) bool {
+	for _, after := range notClauseAfter[word] {
+		if prevWord == after {
+			return false
+		}
+	}
+	switch word {
+	case "WITH":
+		// WITH also appears in WITH TIME ZONE and WITH GRANT OPTION.
+		return !frame.started
+	case "EXCEPT":
+		// BigQuery's SELECT * EXCEPT (column) leaves out columns.
+		return prev == nil || !prev.isSymbol("*")
+	}
+	return true
+}
+
+// spaceBefore reports whether a space separates t from the token written before it on the
+// same line. Operators and parameters written as one, such as >= or $1, are kept together.
+func spaceBefore(prev *token, t token) bool {
+	if prev == nil {
+		return false
+	}
+	adjacent := prev.end == t.offset
+	switch {
+	case t.kind == token_Symbol && strings.Contains(",;).]", t.value), t.isSymbol("::"):
+		return false
+	case prev.kind == token_Symbol && strings.Contains("([.", prev.value), prev.isSymbol("::"):
+		return false
+	case t.isSymbol("(") && (prev.kind == token_Word || prev.kind == token_QuotedIdentifier), t.isSymbol("["):
+		return !adjacent
+	case prev.kind == token_Symbol && t.kind == token_Symbol && strings.Contains(operatorSymbols, prev.value) && strings.Contains(operatorSymbols, t.value):
+		// A sign after a comparison, as in >=-1, starts the operand.
+		return !adjacent || (prev.isSymbol("=") && (t.isSymbol("-") || t.isSymbol("+")))
+	case prev.kind == token_Symbol && strings.Contains("$:@?", prev.value):
+		return !adjacent
+	}
+	return true
+}
@@ -0,0 +1,424 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/lineage"
+)
+
+type Severity string
+
+const (
+	// Severity_Error is a mistake the database rejects the query for.
+	Severity_Error Severity = "Error"
+	// Severity_Warning is a query that runs, but likely not as intended.
+	Severity_Warning Severity = "Warning"
+)
+
+type Rule string
+
+const (
+	Rule_Unterminated          Rule = "Unterminated"
+	Rule_UnbalancedParenthesis Rule = "UnbalancedParenthesis"
+	Rule_UnknownStatement      Rule = "UnknownStatement"
+	Rule_TrailingComma         Rule = "TrailingComma"
+	Rule_Dialect               Rule = "Dialect"
+	Rule_UnknownTable          Rule = "UnknownTable"
+	Rule_UnknownColumn         Rule = "UnknownColumn"
+	Rule_SelectStar            Rule = "SelectStar"
+	Rule_MissingWhere          Rule = "MissingWhere"
+)
+
+// Issue is a problem found in a query. Line and Column are where it starts, counted from 1.
+type Issue struct {
+	Severity Severity
+	Rule     Rule
+	Message  string
+	Line     int
+	Column   int
+	offset   int
+}
+
+// Catalog lists the tables and views of the schema a query runs in by lower-cased name,
+// along with their columns. Tables whose columns are unknown have none.
+type Catalog struct {
+	Schema string
+	Tables map[string][]string
+}
+
+var statementKeywords = []string{
+	"ABORT", "ALTER", "ANALYZE", "ATTACH", "BEGIN", "CALL", "CHECKPOINT", "CLOSE", "CLUSTER",
+	"COMMENT", "COMMIT", "COPY", "CREATE", "DEALLOCATE", "DECLARE", "DELETE", "DESC", "DESCRIBE",
+	"DETACH", "DISCARD", "DO", "DROP", "END", "EXEC", "EXECUTE", "EXPLAIN", "FETCH", "GRANT",
+	"HANDLER", "IMPORT", "INSERT", "KILL", "LISTEN", "LOAD", "LOCK", "MERGE", "MOVE", "NOTIFY",
+	"OPTIMIZE", "PRAGMA", "PREPARE", "REFRESH", "REINDEX", "RELEASE", "RENAME", "REPAIR",
+	"REPLACE", "RESET", "REVOKE", "ROLLBACK", "SAVEPOINT", "SELECT", "SET", "SHOW", "START",
+	"TABLE", "TRUNCATE", "UNDROP", "UNLISTEN", "UNLOCK", "UPDATE", "USE", "VACUUM", "VALUES", "WITH",
+}
+
+// clauseKeywords end the list before them, so a comma cannot come right before them.
+var clauseKeywords = []string{"FROM", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "UNION", "INTERSECT", "EXCEPT"}
+
+// dataStatements are the statements whose table references are checked against the catalog;
+// others, such as CREATE TABLE, name tables that do not exist yet.
+var dataStatements = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "TABLE"}
+
+// Lint checks a query written for databaseType without running it. Syntax is only checked
+// as far as it can be without a full parser: strings, comments and parentheses are closed,
+// statements start with a statement and lists do not end with a comma. With a catalog, the
+// tables the query references and the columns it qualifies with a table are looked up in it.
+// Issues are returned in the order they appear in the query.
+func Lint(databaseType engine.DatabaseType, query string, catalog *Catalog) []Issue {
+	tokens, issues := tokenize(databaseType, query)
+	if len(issues) == 0 {
+		issues = append(issues, checkParentheses(tokens)...)
+		var references []lineage.Reference
+		if catalog != nil {
+			references = lineage.References(query)
+		}
+		for _, statement := range splitStatements(tokens) {
+			issues = append(issues, checkStatement(databaseType, statement)...)
+			if catalog != nil {
+				issues = append(issues, checkReferences(databaseType, statement, references, catalog)...)
+			}
+		}
+	}
+
+	runes := []rune(query)
+	for i := range issues {
+		issues[i].Line, issues[i].Column = 1, 1
+		for _, r := range runes[:issues[i].offset] {
+			if r == '\n' {
+				issues[i].Line++
+				issues[i].Column = 1
+			} else {
+				issues[i].Column++
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].offset < issues[j].offset
+	})
+	return issues
+}
+
+// LintQuery lints a query against the schema it runs in, reading the tables of the schema
+// and the columns of those the query references from the database. Columns are not checked
+// for plugins that cannot describe them.
+func LintQuery(plugin *engine.Plugin, config *engine.PluginConfig, schema string, query string) ([]Issue, error) {
+	if plugin.Type == engine.DatabaseType_MongoDB || plugin.Type == engine.DatabaseType_Redis {
+		return nil, errors.ErrUnsupported
+	}
+	storageUnits, err := plugin.GetStorageUnits(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	catalog := &Catalog{Schema: schema, Tables: map[string][]string{}}
+	names := map[string]string{}
+	for _, storageUnit := range storageUnits {
+		catalog.Tables[strings.ToLower(storageUnit.Name)] = nil
+		names[strings.ToLower(storageUnit.Name)] = storageUnit.Name
+	}
+
+	described := map[string]bool{}
+	for _, reference := range lineage.References(query) {
+		table := strings.ToLower(reference.Table.Table)
+		name, ok := names[table]
+		if !ok || described[table] || (len(reference.Schema) > 0 && !strings.EqualFold(reference.Schema, schema)) {
+			continue
+		}
+		described[table] = true
+		constraints, err := plugin.GetColumnConstraints(config, schema, name)
+		if errors.Is(err, errors.ErrUnsupported) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, constraint := range constraints {
+			catalog.Tables[table] = append(catalog.Tables[table], constraint.Name)
+		}
+	}
+	return Lint(plugin.Type, query, catalog), nil
+}
+
+type statement struct {
+	tokens []token
+	// verb is the upper-cased keyword saying what the statement does, looking past WITH.
+	verb string
+	// depths holds how many parentheses each token is nested in.
+	depths []int
+}
+
+func splitStatements(tokens []token) []statement {
+	statements := []statement{}
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !tokens[i].isSymbol(";") {
+			continue
+		}
+		if i > start {
+			statements = append(statements, newStatement(tokens[start:i]))
+		}
+		start = i + 1
+	}
+	return statements
+}
+
+func newStatement(tokens []token) statement {
+	s := statement{tokens: tokens, depths: make([]int, len(tokens))}
+	depth := 0
+	for i, t := range tokens {
+		if t.isSymbol(")") && depth > 0 {
+			depth--
+		}
+		s.depths[i] = depth
+		if t.isSymbol("(") {
+			depth++
+		}
+	}
+	if tokens[0].kind == token_Word {
+		s.verb = strings.ToUpper(tokens[0].value)
+	}
+	if s.verb == "WITH" {
+		for i, t := range tokens {
+			if s.depths[i] == 0 && t.isKeyword("SELECT", "INSERT", "UPDATE", "DELETE", "MERGE") {
+				s.verb = strings.ToUpper(t.value)
+				break
+			}
+		}
+	}
+	return s
+}
+
+func checkParentheses(tokens []token) []Issue {
+	issues := []Issue{}
+	open := []int{}
+	for _, t := range tokens {
+		switch {
+		case t.isSymbol("("):
+			open = append(open, t.offset)
+		case t.isSymbol(")") && len(open) == 0:
+			issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_UnbalancedParenthesis, Message: "Closing parenthesis without an opening one", offset: t.offset})
+		case t.isSymbol(")"):
+			open = open[:len(open)-1]
+		}
+	}
+	for _, offset := range open {
+		issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_UnbalancedParenthesis, Message: "Parenthesis is never closed", offset: offset})
+	}
+	return issues
+}
+
+func checkStatement(databaseType engine.DatabaseType, s statement) []Issue {
+	issues := []Issue{}
+	first := s.tokens[0]
+	if !first.isSymbol("(") && !first.isKeyword(statementKeywords...) {
+		issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_UnknownStatement, Message: fmt.Sprintf("%v does not start a statement", first.value), offset: first.offset})
+	}
+
+	name := databaseName(databaseType)
+	// Whether each open parenthesis follows EXISTS, where SELECT * is idiomatic.
+	exists := []bool{false}
+	for i, t := range s.tokens {
+		var previous, next token
+		if i > 0 {
+			previous = s.tokens[i-1]
+		}
+		if i+1 < len(s.tokens) {
+			next = s.tokens[i+1]
+		}
+		switch {
+		case t.isSymbol("("):
+			exists = append(exists, previous.isKeyword("EXISTS"))
+		case t.isSymbol(")") && len(exists) > 1:
+			exists = exists[:len(exists)-1]
+		case t.isSymbol(",") && (next.isSymbol(")") || next.isKeyword(clauseKeywords...) || i+1 == len(s.tokens)):
+			issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_TrailingComma, Message: "Trailing comma at the end of a list", offset: t.offset})
+		case t.isSymbol("*") && !exists[len(exists)-1] && (previous.isKeyword("SELECT", "DISTINCT", "ALL") ||
+			(previous.isSymbol(".") && (next.isSymbol(",") || next.isKeyword("FROM") || i+1 == len(s.tokens)))):
+			issues = append(issues, Issue{Severity: Severity_Warning, Rule: Rule_SelectStar, Message: "SELECT * returns every column, including those added later; list the columns needed instead", offset: t.offset})
+		}
+
+		switch databaseType {
+		case engine.DatabaseType_Postgres, engine.DatabaseType_Snowflake:
+			if t.kind == token_QuotedIdentifier && t.quote == '`' {
+				issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_Dialect, Message: fmt.Sprintf("%v quotes identifiers with double quotes, not backticks", name), offset: t.offset})
+			}
+			if t.isKeyword("LIMIT") && next.kind == token_Number && i+2 < len(s.tokens) && s.tokens[i+2].isSymbol(",") {
+				issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_Dialect, Message: fmt.Sprintf("%v has no LIMIT offset, count; use LIMIT count OFFSET offset", name), offset: t.offset})
+			}
+		case engine.DatabaseType_MySQL, engine.DatabaseType_Sqlite3, engine.DatabaseType_BigQuery:
+			if t.isSymbol("::") {
+				issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_Dialect, Message: fmt.Sprintf("%v has no :: cast; use CAST(value AS type)", name), offset: t.offset})
+			}
+			if t.isKeyword("ILIKE") {
+				issues = append(issues, Issue{Severity: Severity_Error, Rule: Rule_Dialect, Message: fmt.Sprintf("%v has no ILIKE; compare LOWER(value) with LIKE", name), offset: t.offset})
+			}
+		}
+	}
+
+	if s.verb == "DELETE" || s.verb == "UPDATE" {
+		filtered := false
+		for i, t := range s.tokens {
+			if s.depths[i] == 0 && t.isKeyword("WHERE") {
+				filtered = true
+			}
+		}
+		if !filtered {
+			message := "DELETE without WHERE deletes every row"
+			if s.verb == "UPDATE" {
+				message = "UPDATE without WHERE changes every row"
+			}
+			issues = append(issues, Issue{Severity: Severity_Warning, Rule: Rule_MissingWhere, Message: message, offset: first.offset})
+		}
+	}
+	return issues
+}
+
+type referencedTable struct {
+	name    string
+	columns []string
+}
+
+// checkReferences looks up the tables referenced by a statement in the catalog, then the
+// columns whose table is certain: those qualified with a table or alias, the column list of
+// INSERT and the columns set by an UPDATE of a single table.
+func checkReferences(databaseType engine.DatabaseType, s statement, references []lineage.Reference, catalog *Catalog) []Issue {
+	if !common.ContainsString(dataStatements, s.verb) {
+		return nil
+	}
+	positions := map[int]int{}
+	for i, t := range s.tokens {
+		positions[t.offset] = i
+	}
+
+	issues := []Issue{}
+	tables := map[string]referencedTable{}
+	var target *referencedTable
+	referenced := 0
+	for _, reference := range references {
+		// References inside comments or strings the lineage tokenizer did not skip are ignored.
+		position, ok := positions[reference.Offset]
+		if !ok || !s.tokens[position].isIdentifier() {
+			continue
+		}
+		if len(reference.Schema) > 0 && !strings.EqualFold(reference.Schema, catalog.Schema) {
+			continue
+		}
+		referenced++
+		name := strings.ToLower(reference.Table.Table)
+		columns, found := catalog.Tables[name]
+		if !found {
+			if !isSystemTable(databaseType, name) {
+				issues = append(issues, Issue{Severity: Severity_Warning, Rule: Rule_UnknownTable, Message: fmt.Sprintf("Table %v was not found in %v", reference.Table.Table, catalog.Schema), offset: reference.Offset})
+			}
+			continue
+		}
+		table := referencedTable{name: reference.Table.Table, columns: columns}
+		tables[name] = table
+		if len(reference.Alias) > 0 {
+			tables[strings.ToLower(reference.Alias)] = table
+		}
+		if target == nil {
+			target = &table
+			if s.verb == "INSERT" || s.verb == "REPLACE" {
+				issues = append(issues, checkInsertColumns(s, position, reference.Alias, table)...)
+			}
+		}
+	}
+
+	for i := 0; i+2 < len(s.tokens); i++ {
+		qualifier, column := s.tokens[i], s.tokens[i+2]
+		if !qualifier.isIdentifier() || !s.tokens[i+1].isSymbol(".") || !column.isIdentifier() {
+			continue
+		}
+		// a.b.c and a.b() are not a column of table a.
+		if i+3 < len(s.tokens) && (s.tokens[i+3].isSymbol(".") || s.tokens[i+3].isSymbol("(")) {
+			continue
+		}
+		if table, ok := tables[strings.ToLower(qualifier.value)]; ok {
+			issues = append(issues, checkColumn(table, column)...)
+		}
+	}
+
+	if s.verb == "UPDATE" && referenced == 1 && target != nil {
+		set := -1
+		for i, t := range s.tokens {
+			if s.depths[i] != 0 {
+				continue
+			}
+			switch {
+			case t.isKeyword("SET"):
+				set = i
+			case set < 0:
+			case t.isKeyword("WHERE", "FROM", "RETURNING", "ORDER", "LIMIT"):
+				set = -1
+			case t.isIdentifier() && i+1 < len(s.tokens) && s.tokens[i+1].isSymbol("=") && (i == set+1 || s.tokens[i-1].isSymbol(",")):
+				issues = append(issues, checkColumn(*target, t)...)
+			}
+		}
+	}
+	return issues
+}
+
+// checkInsertColumns checks the column list following the target of an INSERT.
+func checkInsertColumns(s statement, position int, alias string, table referencedTable) []Issue {
+	i := position + 1
+	if i < len(s.tokens) && s.tokens[i].isKeyword("AS") {
+		i++
+	}
+	if len(alias) > 0 {
+		i++
+	}
+	if i+1 >= len(s.tokens) || !s.tokens[i].isSymbol("(") || s.tokens[i+1].isKeyword("SELECT", "WITH") {
+		return nil
+	}
+	issues := []Issue{}
+	for i++; i < len(s.tokens) && !s.tokens[i].isSymbol(")"); i++ {
+		if s.tokens[i].isIdentifier() {
+			issues = append(issues, checkColumn(table, s.tokens[i])...)
+		}
+	}
+	return issues
+}
+
+func checkColumn(table referencedTable, column token) []Issue {
+	if len(table.columns) == 0 {
+		return nil
+	}
+	for _, name := range table.columns {
+		if strings.EqualFold(name, column.value) {
+			return nil
+		}
+	}
+	return []Issue{{Severity: Severity_Warning, Rule: Rule_UnknownColumn, Message: fmt.Sprintf("Column %v was not found in %v", column.value, table.name), offset: column.offset}}
+}
+
+// isSystemTable reports whether an unqualified table is one of the catalog tables databases
+// resolve whatever the schema.
+func isSystemTable(databaseType engine.DatabaseType, table string) bool {
+	switch databaseType {
+	case engine.DatabaseType_Postgres:
+		return strings.HasPrefix(table, "pg_")
+	case engine.DatabaseType_Sqlite3:
+		return strings.HasPrefix(table, "sqlite_")
+	case engine.DatabaseType_MySQL:
+		return table == "dual"
+	case engine.DatabaseType_Oracle:
+		return strings.EqualFold(table, "dual")
+	}
+	return false
+}
+
+func databaseName(databaseType engine.DatabaseType) string {
+	if databaseType == engine.DatabaseType_Sqlite3 {
+		return "SQLite"
+	}
+	return string(databaseType)
+}
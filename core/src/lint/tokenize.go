@@ -0,0 +1,200 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+type tokenKind int
+
+const (
+	token_Word tokenKind = iota
+	token_QuotedIdentifier
+	token_String
+	token_Number
+	token_Symbol
+	token_Comment
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// offset and end are where the token starts and ends in the query, in runes.
+	offset int
+	end    int
+	// quote is the character a quoted identifier or string was quoted with.
+	quote rune
+}
+
+func (t token) isKeyword(keywords ...string) bool {
+	if t.kind != token_Word {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.value, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == token_Symbol && t.value == symbol
+}
+
+func (t token) isIdentifier() bool {
+	return t.kind == token_Word || t.kind == token_QuotedIdentifier
+}
+
+// tokenize splits a query into tokens the way databaseType reads it, dropping whitespace and
+// comments. A string, quoted identifier or comment left open ends the query with an issue.
+func tokenize(databaseType engine.DatabaseType, query string) ([]token, []Issue) {
+	return scan(databaseType, query, false)
+}
+
+// scan is tokenize, also returning comments as tokens when keepComments is set.
+func scan(databaseType engine.DatabaseType, query string, keepComments bool) ([]token, []Issue) {
+	mysqlLike := databaseType == engine.DatabaseType_MySQL || databaseType == engine.DatabaseType_BigQuery
+	dollarQuotes := databaseType == engine.DatabaseType_Postgres || databaseType == engine.DatabaseType_Snowflake
+
+	tokens := []token{}
+	runes := []rune(query)
+	unterminated := func(start int, what string) []Issue {
+		return []Issue{{Severity: Severity_Error, Rule: Rule_Unterminated, Message: fmt.Sprintf("%v is never closed", what), offset: start}}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-', r == '#' && mysqlLike:
+			start := i
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+			}
+			if keepComments {
+				tokens = append(tokens, token{kind: token_Comment, value: string(runes[start:i]), offset: start, end: i})
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := i
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(runes) {
+				return tokens, unterminated(start, "Comment")
+			}
+			i++
+			if keepComments {
+				tokens = append(tokens, token{kind: token_Comment, value: string(runes[start : i+1]), offset: start, end: i + 1})
+			}
+		case r == '$' && dollarQuotes && dollarTag(runes, i) != "":
+			// Postgres dollar quotes, $$text$$ or $tag$text$tag$, hold function bodies.
+			tag := []rune(dollarTag(runes, i))
+			start := i
+			for i += len(tag); i < len(runes) && !hasPrefix(runes[i:], tag); i++ {
+			}
+			if i >= len(runes) {
+				return tokens, unterminated(start, "Dollar-quoted string")
+			}
+			tokens = append(tokens, token{kind: token_String, value: string(runes[start+len(tag) : i]), offset: start, quote: '$'})
+			i += len(tag) - 1
+		case r == '\'' || r == '"' || r == '`':
+			start := i
+			kind := token_QuotedIdentifier
+			if r == '\'' || (r == '"' && mysqlLike) {
+				kind = token_String
+			}
+			// MySQL strings and Postgres E'' strings escape quotes with a backslash.
+			escapes := kind == token_String && mysqlLike
+			if databaseType == engine.DatabaseType_Postgres && r == '\'' && len(tokens) > 0 && tokens[len(tokens)-1].isKeyword("E") && tokens[len(tokens)-1].offset == i-1 {
+				escapes = true
+				start = tokens[len(tokens)-1].offset
+				tokens = tokens[:len(tokens)-1]
+			}
+			// BigQuery also has triple-quoted strings, which may span lines.
+			closing := []rune{r}
+			if databaseType == engine.DatabaseType_BigQuery && kind == token_String && i+2 < len(runes) && runes[i+1] == r && runes[i+2] == r {
+				closing = []rune{r, r, r}
+				i += 2
+			}
+			value := strings.Builder{}
+			closed := false
+			for i++; i < len(runes); i++ {
+				if escapes && runes[i] == '\\' && i+1 < len(runes) {
+					value.WriteRune(runes[i+1])
+					i++
+					continue
+				}
+				if hasPrefix(runes[i:], closing) {
+					// A doubled quote stands for the quote itself.
+					if len(closing) == 1 && i+1 < len(runes) && runes[i+1] == r {
+						value.WriteRune(r)
+						i++
+						continue
+					}
+					i += len(closing) - 1
+					closed = true
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+			if !closed {
+				what := "String"
+				if kind == token_QuotedIdentifier {
+					what = "Quoted identifier"
+				}
+				return tokens, unterminated(start, what)
+			}
+			tokens = append(tokens, token{kind: kind, value: value.String(), offset: start, quote: r})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for ; i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$'); i++ {
+			}
+			tokens = append(tokens, token{kind: token_Word, value: string(runes[start:i]), offset: start})
+			i--
+		case unicode.IsDigit(r):
+			start := i
+			for ; i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{kind: token_Number, value: string(runes[start:i]), offset: start})
+			i--
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			tokens = append(tokens, token{kind: token_Symbol, value: "::", offset: i})
+			i++
+		default:
+			tokens = append(tokens, token{kind: token_Symbol, value: string(r), offset: i})
+		}
+		// Every case leaves i on the last rune of the token it added.
+		if last := len(tokens) - 1; last >= 0 && tokens[last].end == 0 {
+			tokens[last].end = i + 1
+		}
+	}
+	return tokens, nil
+}
+
+// dollarTag returns the $tag$ starting at start, or an empty string when there is none.
+// Positional parameters such as $1 are not tags.
+func dollarTag(runes []rune, start int) string {
+	for i := start + 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '$':
+			return string(runes[start : i+1])
+		case unicode.IsLetter(runes[i]) || runes[i] == '_' || (i > start+1 && unicode.IsDigit(runes[i])):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func hasPrefix(runes []rune, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if runes[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
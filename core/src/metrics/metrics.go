@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics collects what the server exposes on /metrics in the Prometheus format.
+type Metrics struct {
+	registry          *prometheus.Registry
+	queries           *prometheus.CounterVec
+	queryDuration     *prometheus.HistogramVec
+	activeConnections *prometheus.GaugeVec
+	resolverDuration  *prometheus.HistogramVec
+	cacheLookups      *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "whodb_queries_total",
+			Help: "Queries run against databases, by database type, plugin method and outcome.",
+		}, []string{"database_type", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "whodb_query_duration_seconds",
+			Help:    "How long queries take, by database type and plugin method.",
+			Buckets: []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"database_type", "method"}),
+		// Plugins open a connection for each query, so this is also the number of queries running.
+		activeConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "whodb_active_connections",
+			Help: "Database connections currently in use by a query, by database type.",
+		}, []string{"database_type"}),
+		resolverDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "whodb_graphql_resolver_duration_seconds",
+			Help:    "How long GraphQL resolvers take, by field and outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"field", "status"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "whodb_cache_lookups_total",
+			Help: "Cache lookups, by cache and whether they hit.",
+		}, []string{"cache", "result"}),
+	}
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.queries,
+		metrics.queryDuration,
+		metrics.activeConnections,
+		metrics.resolverDuration,
+		metrics.cacheLookups,
+	)
+	return metrics
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) QueryStarted(databaseType engine.DatabaseType) {
+	m.activeConnections.WithLabelValues(string(databaseType)).Inc()
+}
+
+func (m *Metrics) QueryFinished(databaseType engine.DatabaseType, method string, duration time.Duration, err error) {
+	m.activeConnections.WithLabelValues(string(databaseType)).Dec()
+	m.queries.WithLabelValues(string(databaseType), method, status(err)).Inc()
+	m.queryDuration.WithLabelValues(string(databaseType), method).Observe(duration.Seconds())
+}
+
+func (m *Metrics) CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// ResolverFinished records a resolver, named after its field such as Query.RawExecute.
+func (m *Metrics) ResolverFinished(field string, duration time.Duration, err error) {
+	m.resolverDuration.WithLabelValues(field, status(err)).Observe(duration.Seconds())
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,285 @@
+package openlineage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/export"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/google/uuid"
+)
+
+const (
+	producer       = "https://github.com/clidey/whodb"
+	runEventSchema = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	sqlFacetSchema = "https://openlineage.io/spec/facets/1-1-0/SQLJobFacet.json#/$defs/SQLJobFacet"
+	errorSchema    = "https://openlineage.io/spec/facets/1-0-1/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"
+	sendTimeout    = 10 * time.Second
+)
+
+const (
+	EventType_Start    = "START"
+	EventType_Complete = "COMPLETE"
+	EventType_Fail     = "FAIL"
+)
+
+// writeStatements are the statements whose first table is written to rather than read.
+var writeStatements = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "CREATE"}
+
+type RunEvent struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+type Run struct {
+	RunID  string                 `json:"runId"`
+	Facets map[string]interface{} `json:"facets,omitempty"`
+}
+
+type Job struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+type Dataset struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type facet struct {
+	Producer  string `json:"_producer"`
+	SchemaURL string `json:"_schemaURL"`
+}
+
+type sqlFacet struct {
+	facet
+	Query string `json:"query"`
+}
+
+type errorFacet struct {
+	facet
+	Message             string `json:"message"`
+	ProgrammingLanguage string `json:"programmingLanguage"`
+}
+
+// Emitter reports every raw statement and export as an OpenLineage run to a lineage backend
+// such as Marquez. Statements and exports are jobs of Namespace, and the tables they
+// read and write are datasets named after their connection, following the OpenLineage
+// naming conventions.
+type Emitter struct {
+	url       string
+	apiKey    string
+	namespace string
+	client    *http.Client
+}
+
+// New sends events to url, the lineage endpoint of the backend, authenticating with apiKey
+// when it is set.
+func New(url string, apiKey string, namespace string) *Emitter {
+	return &Emitter{url: url, apiKey: apiKey, namespace: namespace, client: &http.Client{Timeout: sendTimeout}}
+}
+
+// Before lets every statement through unchanged; events are only sent once they ran.
+func (e *Emitter) Before(databaseType engine.DatabaseType, config *engine.PluginConfig, query string) (string, error) {
+	return query, nil
+}
+
+// After reports a raw statement. The queries of exports are reported by ExportFinished instead.
+func (e *Emitter) After(databaseType engine.DatabaseType, config *engine.PluginConfig, query string, result *engine.GetRowsResult, err error, duration time.Duration) {
+	if config != nil && config.Caller == engine.Caller_Export {
+		return
+	}
+	caller := "query"
+	if config != nil && config.Caller == engine.Caller_Scheduler {
+		caller = "scheduled_query"
+	}
+	inputs, outputs := e.queryDatasets(databaseType, config, query)
+	e.send(e.newRun(fmt.Sprintf("%v.%v", caller, queryID(query)), query, inputs, outputs, time.Now().Add(-duration), err))
+}
+
+// ExportFinished reports an export, reading from its storage unit or query and writing to
+// its destination.
+func (e *Emitter) ExportFinished(config *engine.PluginConfig, summary export.Summary) {
+	databaseType := engine.DatabaseType(summary.DatabaseType)
+	inputs := []Dataset{}
+	name := ""
+	if len(summary.Query) > 0 {
+		inputs, _ = e.queryDatasets(databaseType, config, summary.Query)
+		name = fmt.Sprintf("export.%v", queryID(summary.Query))
+	} else {
+		inputs = append(inputs, dataset(databaseType, config, summary.Schema, summary.StorageUnit))
+		name = fmt.Sprintf("export.%v", strings.Trim(fmt.Sprintf("%v.%v", summary.Schema, summary.StorageUnit), "."))
+	}
+	outputs := []Dataset{{Namespace: e.namespace, Name: summary.Destination}}
+	e.send(e.newRun(name, summary.Query, inputs, outputs, summary.StartedAt, summary.Error))
+}
+
+// newRun returns the events of a run that started at startedAt and just ended.
+func (e *Emitter) newRun(name string, query string, inputs []Dataset, outputs []Dataset, startedAt time.Time, err error) []RunEvent {
+	start := RunEvent{
+		EventType: EventType_Start,
+		EventTime: startedAt.UTC(),
+		Run:       Run{RunID: newRunID(startedAt)},
+		Job:       Job{Namespace: e.namespace, Name: name},
+		Inputs:    inputs,
+		Outputs:   outputs,
+		Producer:  producer,
+		SchemaURL: runEventSchema,
+	}
+	if len(query) > 0 {
+		start.Job.Facets = map[string]interface{}{
+			"sql": sqlFacet{facet: facet{Producer: producer, SchemaURL: sqlFacetSchema}, Query: query},
+		}
+	}
+	end := start
+	end.EventType = EventType_Complete
+	end.EventTime = time.Now().UTC()
+	if err != nil {
+		end.EventType = EventType_Fail
+		end.Run.Facets = map[string]interface{}{
+			"errorMessage": errorFacet{
+				facet:               facet{Producer: producer, SchemaURL: errorSchema},
+				Message:             err.Error(),
+				ProgrammingLanguage: "SQL",
+			},
+		}
+	}
+	return []RunEvent{start, end}
+}
+
+// queryDatasets splits the tables of query into the ones it reads and the one it writes.
+func (e *Emitter) queryDatasets(databaseType engine.DatabaseType, config *engine.PluginConfig, query string) ([]Dataset, []Dataset) {
+	inputs := []Dataset{}
+	outputs := []Dataset{}
+	tables := lineage.ReferencedTables(query)
+	keywords := common.SQLKeywords(query)
+	if len(tables) > 0 && len(keywords) > 0 && common.ContainsString(writeStatements, keywords[0]) {
+		outputs = append(outputs, dataset(databaseType, config, tables[0].Schema, tables[0].Table))
+		tables = tables[1:]
+	}
+	for _, table := range tables {
+		inputs = append(inputs, dataset(databaseType, config, table.Schema, table.Table))
+	}
+	return inputs, outputs
+}
+
+// send posts the events of a run in order, in the background so that a slow backend does
+// not hold up queries.
+func (e *Emitter) send(events []RunEvent) {
+	go func() {
+		for _, event := range events {
+			if err := e.post(event); err != nil {
+				log.Logger.Warnf("Unable to send lineage event for %v: %v", event.Job.Name, err)
+				return
+			}
+		}
+	}()
+}
+
+func (e *Emitter) post(event RunEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(e.apiKey) > 0 {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %v", e.apiKey))
+	}
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("the lineage backend answered %v", response.Status)
+	}
+	return nil
+}
+
+// dataset names a table as OpenLineage does: the namespace identifies the database server
+// and the name is the table, qualified from the database down.
+func dataset(databaseType engine.DatabaseType, config *engine.PluginConfig, schema string, table string) Dataset {
+	credentials := &engine.Credentials{}
+	if config != nil && config.Credentials != nil {
+		credentials = config.Credentials
+	}
+	address := func(defaultPort int) string {
+		return fmt.Sprintf("%v:%v", credentials.Hostname, credentials.GetAdvanced(engine.AdvancedKey_Port, strconv.Itoa(defaultPort)))
+	}
+	qualify := func(parts ...string) string {
+		qualified := []string{}
+		for _, part := range parts {
+			if len(part) > 0 {
+				qualified = append(qualified, part)
+			}
+		}
+		return strings.Join(qualified, ".")
+	}
+	switch databaseType {
+	case engine.DatabaseType_Postgres:
+		if len(schema) == 0 {
+			schema = "public"
+		}
+		return Dataset{Namespace: "postgres://" + address(5432), Name: qualify(credentials.Database, schema, table)}
+	case engine.DatabaseType_MySQL:
+		// MySQL schemas are databases.
+		if len(schema) == 0 {
+			schema = credentials.Database
+		}
+		return Dataset{Namespace: "mysql://" + address(3306), Name: qualify(schema, table)}
+	case engine.DatabaseType_MongoDB:
+		return Dataset{Namespace: "mongodb://" + address(27017), Name: qualify(credentials.Database, table)}
+	case engine.DatabaseType_Snowflake:
+		return Dataset{Namespace: "snowflake://" + credentials.Hostname, Name: qualify(credentials.Database, schema, table)}
+	case engine.DatabaseType_Oracle:
+		// Oracle schemas are users, qualified by the service.
+		return Dataset{Namespace: "oracle://" + address(1521), Name: qualify(credentials.Database, schema, table)}
+	case engine.DatabaseType_BigQuery:
+		return Dataset{Namespace: "bigquery", Name: qualify(credentials.Hostname, schema, table)}
+	case engine.DatabaseType_Sqlite3:
+		return Dataset{Namespace: "sqlite://" + credentials.Database, Name: table}
+	}
+	return Dataset{Namespace: fmt.Sprintf("%v://%v", strings.ToLower(string(databaseType)), credentials.Hostname), Name: qualify(credentials.Database, schema, table)}
+}
+
+// queryID names the job of a statement after its text, so that every run of the same
+// statement is a run of the same job.
+func queryID(query string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(query), " ")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// newRunID returns a UUIDv7 as recommended by OpenLineage, which sorts by start time.
+func newRunID(startedAt time.Time) string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	// The first 48 bits of a UUIDv7 are its Unix time in milliseconds.
+	milliseconds := startedAt.UnixMilli()
+	for i := 0; i < 6; i++ {
+		id[i] = byte(milliseconds >> (40 - 8*i))
+	}
+	return id.String()
+}
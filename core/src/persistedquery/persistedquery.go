@@ -0,0 +1,99 @@
+package persistedquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errNotWhitelistedCode = "OPERATION_NOT_WHITELISTED"
+
+// Manifest maps the SHA-256 hashes of the operations the frontend sends to their text, as
+// generated at build time.
+type Manifest map[string]string
+
+// Load reads a manifest, checking every operation against its hash so that clients sending
+// automatic persisted queries find them.
+func Load(path string) (Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest := Manifest{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	for hash, query := range manifest {
+		if Hash(query) != hash {
+			return nil, fmt.Errorf("persisted query %v does not match its hash", hash)
+		}
+	}
+	return manifest, nil
+}
+
+// Hash is the key of query in a manifest and in automatic persisted query requests.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache serves the operations of the manifest to automatic persisted query requests, and
+// keeps the ones clients register in Fallback unless only the manifest is allowed.
+type Cache struct {
+	Manifest Manifest
+	Fallback graphql.Cache
+	Only     bool
+}
+
+func (c Cache) Get(ctx context.Context, key string) (interface{}, bool) {
+	if query, ok := c.Manifest[key]; ok {
+		return query, true
+	}
+	if c.Only {
+		return nil, false
+	}
+	return c.Fallback.Get(ctx, key)
+}
+
+func (c Cache) Add(ctx context.Context, key string, value interface{}) {
+	if _, ok := c.Manifest[key]; ok || c.Only {
+		return
+	}
+	c.Fallback.Add(ctx, key, value)
+}
+
+// Whitelist rejects every operation that is not in the manifest, whether it was sent in
+// full or by hash. It must be used after the automatic persisted query extension, which
+// fills in the operations sent by hash.
+type Whitelist struct {
+	Manifest Manifest
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = Whitelist{}
+
+func (w Whitelist) ExtensionName() string {
+	return "PersistedQueryWhitelist"
+}
+
+func (w Whitelist) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (w Whitelist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if _, ok := w.Manifest[Hash(rawParams.Query)]; ok {
+		return nil
+	}
+	err := gqlerror.Errorf("operation is not whitelisted")
+	errcode.Set(err, errNotWhitelistedCode)
+	return err
+}
@@ -0,0 +1,207 @@
+package pii
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+type Kind string
+
+const (
+	Kind_Email      Kind = "Email"
+	Kind_Phone      Kind = "Phone"
+	Kind_NationalID Kind = "NationalID"
+	Kind_CreditCard Kind = "CreditCard"
+)
+
+const (
+	defaultSampleSize = 100
+	// minMatchRatio is the share of sampled, non-empty values that must match for a column to be flagged.
+	minMatchRatio = 0.5
+)
+
+// Finding flags a column whose sampled values look like personal data.
+type Finding struct {
+	StorageUnit string
+	Column      string
+	Kind        Kind
+	// Confidence is the share of sampled, non-empty values that matched.
+	Confidence float64
+	Sampled    int
+}
+
+type detector struct {
+	kind  Kind
+	match func(value string) bool
+}
+
+var (
+	emailPattern      = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}$`)
+	phonePattern      = regexp.MustCompile(`^\+?[0-9][0-9 ().\-]{6,18}[0-9]$`)
+	nationalIDPattern = regexp.MustCompile(`^[0-9]{3}-[0-9]{2}-[0-9]{4}$`)
+	cardPattern       = regexp.MustCompile(`^[0-9][0-9 \-]{11,21}[0-9]$`)
+	datePattern       = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}`)
+)
+
+// Detectors are checked in order and a value counts towards the first one it matches,
+// so that card numbers are not also reported as phone numbers.
+var detectors = []detector{
+	{kind: Kind_Email, match: emailPattern.MatchString},
+	{kind: Kind_NationalID, match: nationalIDPattern.MatchString},
+	{kind: Kind_CreditCard, match: isCardNumber},
+	{kind: Kind_Phone, match: isPhoneNumber},
+}
+
+// Scan samples up to sampleSize rows of every storage unit in the schema and returns the
+// columns that likely hold personal data.
+func Scan(plugin *engine.Plugin, config *engine.PluginConfig, schema string, sampleSize int) ([]Finding, error) {
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	storageUnits, err := plugin.GetStorageUnits(config, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []Finding{}
+	for _, storageUnit := range storageUnits {
+		rows, err := plugin.GetRows(config, schema, storageUnit.Name, "", nil, sampleSize, 0)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, scanRows(storageUnit.Name, flattenDocuments(rows))...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].StorageUnit != findings[j].StorageUnit {
+			return findings[i].StorageUnit < findings[j].StorageUnit
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings, nil
+}
+
+func scanRows(storageUnit string, rows *engine.GetRowsResult) []Finding {
+	findings := []Finding{}
+	for i, column := range rows.Columns {
+		sampled := 0
+		matches := map[Kind]int{}
+		for _, row := range rows.Rows {
+			if i >= len(row) {
+				continue
+			}
+			value := strings.TrimSpace(row[i])
+			if len(value) == 0 {
+				continue
+			}
+			sampled++
+			for _, detector := range detectors {
+				if detector.match(value) {
+					matches[detector.kind]++
+					break
+				}
+			}
+		}
+		if sampled == 0 {
+			continue
+		}
+		for _, detector := range detectors {
+			confidence := float64(matches[detector.kind]) / float64(sampled)
+			if confidence >= minMatchRatio {
+				findings = append(findings, Finding{
+					StorageUnit: storageUnit,
+					Column:      column.Name,
+					Kind:        detector.kind,
+					Confidence:  confidence,
+					Sampled:     sampled,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// flattenDocuments turns document rows (a single JSON column) into one column per top-level field.
+func flattenDocuments(rows *engine.GetRowsResult) *engine.GetRowsResult {
+	if len(rows.Columns) != 1 || rows.Columns[0].Type != "Document" {
+		return rows
+	}
+	documents := []map[string]interface{}{}
+	fieldIndex := map[string]int{}
+	flattened := &engine.GetRowsResult{}
+	for _, row := range rows.Rows {
+		document := map[string]interface{}{}
+		if len(row) == 0 || json.Unmarshal([]byte(row[0]), &document) != nil {
+			continue
+		}
+		documents = append(documents, document)
+		for field := range document {
+			if _, ok := fieldIndex[field]; !ok {
+				fieldIndex[field] = len(flattened.Columns)
+				flattened.Columns = append(flattened.Columns, engine.Column{Name: field, Type: "string"})
+			}
+		}
+	}
+	for _, document := range documents {
+		row := make([]string, len(flattened.Columns))
+		for field, value := range document {
+			if text, ok := value.(string); ok {
+				row[fieldIndex[field]] = text
+			}
+		}
+		flattened.Rows = append(flattened.Rows, row)
+	}
+	return flattened
+}
+
+func digitsOf(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+func isPhoneNumber(value string) bool {
+	if !phonePattern.MatchString(value) {
+		return false
+	}
+	digits := digitsOf(value)
+	// Plain integers such as ids or amounts and dates are far more common than phone numbers.
+	if digits == value || datePattern.MatchString(value) {
+		return false
+	}
+	return len(digits) >= 7 && len(digits) <= 15
+}
+
+func isCardNumber(value string) bool {
+	if !cardPattern.MatchString(value) {
+		return false
+	}
+	digits := digitsOf(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	return passesLuhn(digits)
+}
+
+func passesLuhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,447 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+	"google.golang.org/api/iterator"
+)
+
+type BigQueryPlugin struct{}
+
+func (p *BigQueryPlugin) IsAvailable(config *engine.PluginConfig) bool {
+	client, err := DB(config)
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+	_, err = client.Datasets(context.Background()).Next()
+	return err == nil || errors.Is(err, iterator.Done)
+}
+
+func (p *BigQueryPlugin) GetDatabases() ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// GetSchema lists the datasets of the project.
+func (p *BigQueryPlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	datasets := client.Datasets(context.Background())
+	schemaNames := []string{}
+	for {
+		dataset, err := datasets.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		schemaNames = append(schemaNames, dataset.DatasetID)
+	}
+	return schemaNames, nil
+}
+
+func (p *BigQueryPlugin) GetStorageUnits(config *engine.PluginConfig, dataset string) ([]engine.StorageUnit, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	tables, err := getTables(client, dataset)
+	if err != nil {
+		return nil, err
+	}
+	storageUnits := []engine.StorageUnit{}
+	for _, table := range tables {
+		attributes := []engine.Record{
+			{Key: "Table Type", Value: string(table.Type)},
+			{Key: "Total Size", Value: fmt.Sprintf("%d bytes", table.NumBytes)},
+			{Key: "Count", Value: fmt.Sprintf("%d", table.NumRows)},
+		}
+		for _, field := range table.Schema {
+			attributes = append(attributes, engine.Record{Key: field.Name, Value: fieldType(field)})
+		}
+		storageUnits = append(storageUnits, engine.StorageUnit{
+			Name:       table.Name,
+			Attributes: attributes,
+		})
+	}
+	return storageUnits, nil
+}
+
+// getTables returns the metadata of every table and view in a dataset.
+func getTables(client *bq.Client, dataset string) ([]*bq.TableMetadata, error) {
+	ctx := context.Background()
+	tables := client.Dataset(dataset).Tables(ctx)
+	metadata := []*bq.TableMetadata{}
+	for {
+		table, err := tables.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tableMetadata, err := table.Metadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The listing only carries the table ID, which Name does not always match.
+		tableMetadata.Name = table.TableID
+		metadata = append(metadata, tableMetadata)
+	}
+	return metadata, nil
+}
+
+func (p *BigQueryPlugin) UpdateStorageUnit(config *engine.PluginConfig, dataset string, storageUnit string, values map[string]string) (bool, error) {
+	// BigQuery does not enforce primary keys, so a row cannot be reliably singled out.
+	return false, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, dataset string, storageUnit string, where string, values map[string]string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetRows(config *engine.PluginConfig, dataset string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+	query := fmt.Sprintf("SELECT * FROM %v", tableReference(config, dataset, storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf("%v%v LIMIT @limit OFFSET @offset", query, orderBy)
+	result, _, err := p.executeQuery(config, query, []bq.QueryParameter{
+		{Name: "limit", Value: pageSize},
+		{Name: "offset", Value: pageOffset},
+	})
+	return result, err
+}
+
+// executeQuery runs a standard SQL query and returns its rows along with the job statistics.
+func (p *BigQueryPlugin) executeQuery(config *engine.PluginConfig, query string, parameters []bq.QueryParameter) (*engine.GetRowsResult, *bq.JobStatistics, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	q := client.Query(query)
+	q.Parameters = parameters
+	// BigQuery also stops the job itself when it runs past the timeout.
+	q.JobTimeout = config.QueryTimeout
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := job.Read(ctx)
+	if err != nil {
+		// Jobs keep running, and billing, after their client gives up on them.
+		if ctx.Err() != nil {
+			cancelJob(job)
+		}
+		return nil, nil, err
+	}
+
+	result := &engine.GetRowsResult{
+		Rows: [][]string{},
+		// Without enforced primary keys, edits cannot target a single row.
+		DisableUpdate: true,
+	}
+	guard := config.ResultGuard()
+	for {
+		var values []bq.Value
+		err := rows.Next(&values)
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if guard.Limit(result) {
+			break
+		}
+		if result.Columns == nil {
+			result.Columns = resultColumns(rows.Schema)
+		}
+		row := make([]string, len(values))
+		nulls := make([]bool, len(values))
+		for i, value := range values {
+			row[i], err = formatValue(rows.Schema[i], value)
+			if err != nil {
+				return nil, nil, err
+			}
+			nulls[i] = value == nil
+		}
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, nil, err
+		}
+	}
+	if result.Columns == nil {
+		result.Columns = resultColumns(rows.Schema)
+	}
+
+	var statistics *bq.JobStatistics
+	if status := job.LastStatus(); status != nil {
+		statistics = status.Statistics
+	}
+	return result, statistics, nil
+}
+
+// resultColumns lists the columns of a result, whose schema is only known once the first
+// page has been read.
+func resultColumns(schema bq.Schema) []engine.Column {
+	columns := []engine.Column{}
+	for _, field := range schema {
+		columns = append(columns, engine.Column{Name: field.Name, Type: fieldType(field)})
+	}
+	return columns
+}
+
+// formatValue renders a value the way BigQuery displays it; records and arrays are shown as JSON.
+func formatValue(field *bq.FieldSchema, value bq.Value) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case *big.Rat:
+		if field.Type == bq.BigNumericFieldType {
+			return bq.BigNumericString(v), nil
+		}
+		return bq.NumericString(v), nil
+	case []bq.Value, map[string]bq.Value:
+		content, err := json.Marshal(v)
+		return string(content), err
+	}
+	return fmt.Sprint(value), nil
+}
+
+func fieldType(field *bq.FieldSchema) string {
+	if field.Repeated {
+		return fmt.Sprintf("ARRAY<%v>", field.Type)
+	}
+	return string(field.Type)
+}
+
+// tableReference returns the fully qualified name of a table, which BigQuery needs when
+// the query does not set a default dataset.
+func tableReference(config *engine.PluginConfig, dataset string, storageUnit string) string {
+	return quoteIdentifier(fmt.Sprintf("%v.%v.%v", config.Credentials.Hostname, dataset, storageUnit))
+}
+
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "\\`") + "`"
+}
+
+func (p *BigQueryPlugin) GetGraph(config *engine.PluginConfig, dataset string) ([]engine.GraphUnit, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	tables, err := getTables(client, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Foreign keys are not enforced in BigQuery, but still describe how tables relate.
+	tableMap := map[string][]engine.GraphUnitRelationship{}
+	for _, table := range tables {
+		if table.TableConstraints == nil {
+			continue
+		}
+		for _, foreignKey := range table.TableConstraints.ForeignKeys {
+			if foreignKey.ReferencedTable == nil || foreignKey.ReferencedTable.DatasetID != dataset {
+				continue
+			}
+			referencedTable := foreignKey.ReferencedTable.TableID
+			tableMap[referencedTable] = append(tableMap[referencedTable], engine.GraphUnitRelationship{Name: table.Name, RelationshipType: engine.GraphUnitRelationshipType_OneToMany})
+		}
+	}
+
+	storageUnits, err := p.GetStorageUnits(config, dataset)
+	if err != nil {
+		return nil, err
+	}
+	graphUnits := []engine.GraphUnit{}
+	for _, storageUnit := range storageUnits {
+		graphUnits = append(graphUnits, engine.GraphUnit{Unit: storageUnit, Relations: tableMap[storageUnit.Name]})
+	}
+	return graphUnits, nil
+}
+
+// RawExecute dry-runs the query first so the amount of data it scans, which is what
+// on-demand pricing bills, can be reported along with the results. BigQuery has no read-only
+// transactions, so queries that must run read-only are refused unless the dry run finds
+// them to be a SELECT.
+func (p *BigQueryPlugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
+	bytesProcessed, statementType, err := p.dryRun(config, query)
+	if err != nil {
+		return nil, err
+	}
+	if config.ReadOnly && statementType != "SELECT" {
+		return nil, engine.ErrReadOnlyUnsupported
+	}
+	result, _, err := p.executeQuery(config, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, fmt.Sprintf("This query processes an estimated %v.", formatBytes(bytesProcessed)))
+	return result, nil
+}
+
+// dryRun returns the bytes the query would process and its type, such as SELECT or SCRIPT.
+func (p *BigQueryPlugin) dryRun(config *engine.PluginConfig, query string) (int64, string, error) {
+	client, err := DB(config)
+	if err != nil {
+		return 0, "", err
+	}
+	defer client.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	q := client.Query(query)
+	q.DryRun = true
+	job, err := q.Run(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	status := job.LastStatus()
+	if status == nil || status.Statistics == nil {
+		return 0, "", nil
+	}
+	statementType := ""
+	if details, ok := status.Statistics.Details.(*bq.QueryStatistics); ok {
+		statementType = details.StatementType
+	}
+	return status.Statistics.TotalBytesProcessed, statementType, nil
+}
+
+func formatBytes(bytes int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %v", bytes, units[unit])
+	}
+	return fmt.Sprintf("%.1f %v", value, units[unit])
+}
+
+func (p *BigQueryPlugin) SearchStorageUnits(config *engine.PluginConfig, dataset string, search string, limit int) ([]engine.SearchHit, error) {
+	// Every search would scan, and bill, every text column of the dataset.
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetRoutines(config *engine.PluginConfig, dataset string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) ExecuteRoutine(config *engine.PluginConfig, dataset string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetColumnConstraints(config *engine.PluginConfig, dataset string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetRowsAfter(config *engine.PluginConfig, dataset string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	return nil, "", errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *BigQueryPlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func NewBigQueryPlugin() *engine.Plugin {
+	return &engine.Plugin{
+		Type:            engine.DatabaseType_BigQuery,
+		PluginFunctions: &BigQueryPlugin{},
+	}
+}
@@ -0,0 +1,19 @@
+package bigquery
+
+import (
+	"context"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+// cancelJob asks BigQuery to stop a job whose query was cancelled. Cancellation is best
+// effort: the job may still finish, and is billed for the work already done.
+func cancelJob(job *bq.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := job.Cancel(ctx); err != nil {
+		log.Logger.Warnf("Unable to cancel BigQuery job %v: %v", job.ID(), err)
+	}
+}
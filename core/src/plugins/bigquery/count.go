@@ -0,0 +1,65 @@
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// EstimateRowCount reads the row count BigQuery keeps in the table metadata, which is
+// exact for tables and, unlike a query, free.
+func (p *BigQueryPlugin) EstimateRowCount(config *engine.PluginConfig, dataset string, storageUnit string) (int64, error) {
+	client, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	metadata, err := client.Dataset(dataset).Table(storageUnit).Metadata(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return int64(metadata.NumRows), nil
+}
+
+func (p *BigQueryPlugin) CountRows(config *engine.PluginConfig, dataset string, storageUnit string, where string) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %v", tableReference(config, dataset, storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	result, _, err := p.executeQuery(config, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(result.Rows[0][0], 10, 64)
+}
+
+func (p *BigQueryPlugin) GetStorageUnitStats(config *engine.PluginConfig, dataset string) (map[string][]engine.Record, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	tables, err := getTables(client, dataset)
+	if err != nil {
+		return nil, err
+	}
+	stats := map[string][]engine.Record{}
+	for _, table := range tables {
+		records := []engine.Record{
+			{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", table.NumRows)},
+		}
+		if !table.LastModifiedTime.IsZero() {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastModified, Value: table.LastModifiedTime.Format(time.RFC3339)})
+		}
+		stats[table.Name] = records
+	}
+	return stats, nil
+}
@@ -0,0 +1,30 @@
+package bigquery
+
+import (
+	"context"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/clidey/whodb/core/src/engine"
+	"google.golang.org/api/option"
+)
+
+const (
+	AdvancedKey_CredentialsPath = "Credentials Path"
+	AdvancedKey_Location        = "Location"
+)
+
+// DB connects to the project given as hostname. A service account key is used when a
+// credentials path is configured, Application Default Credentials otherwise.
+func DB(config *engine.PluginConfig) (*bq.Client, error) {
+	credentials := config.Credentials
+	options := []option.ClientOption{}
+	if credentialsPath := credentials.GetAdvanced(AdvancedKey_CredentialsPath, ""); len(credentialsPath) > 0 {
+		options = append(options, option.WithCredentialsFile(credentialsPath))
+	}
+	client, err := bq.NewClient(context.Background(), credentials.Hostname, options...)
+	if err != nil {
+		return nil, err
+	}
+	client.Location = credentials.GetAdvanced(AdvancedKey_Location, "")
+	return client, nil
+}
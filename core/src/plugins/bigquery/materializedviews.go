@@ -0,0 +1,42 @@
+package bigquery
+
+import (
+	"fmt"
+
+	bq "cloud.google.com/go/bigquery"
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetMaterializedViews returns the materialized views of the dataset. BigQuery serves them
+// while they refresh, so they can always be refreshed concurrently.
+func (p *BigQueryPlugin) GetMaterializedViews(config *engine.PluginConfig, dataset string) ([]engine.MaterializedView, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	tables, err := getTables(client, dataset)
+	if err != nil {
+		return nil, err
+	}
+	views := []engine.MaterializedView{}
+	for _, table := range tables {
+		if table.Type != bq.MaterializedView {
+			continue
+		}
+		view := engine.MaterializedView{Name: table.Name, Populated: true, Concurrent: true}
+		if table.MaterializedView != nil {
+			view.LastRefresh = table.MaterializedView.LastRefreshTime
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (p *BigQueryPlugin) RefreshMaterializedView(config *engine.PluginConfig, dataset string, view string) error {
+	_, _, err := p.executeQuery(config, "CALL BQ.REFRESH_MATERIALIZED_VIEW(@view)", []bq.QueryParameter{
+		{Name: "view", Value: fmt.Sprintf("%v.%v.%v", config.Credentials.Hostname, dataset, view)},
+	})
+	return err
+}
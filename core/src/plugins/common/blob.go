@@ -0,0 +1,148 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"gorm.io/gorm"
+)
+
+// BlobQueries read and write a binary column of a single row a chunk at a time. Each query
+// ends with the condition selecting the row, whose arguments are Args.
+type BlobQueries struct {
+	// Length selects the size of the value in bytes as length, NULL when the value is.
+	Length string
+	// Read selects the chunk of the value at the 1-based offset and of the length given as
+	// its first two arguments.
+	Read string
+	// Clear empties the value.
+	Clear string
+	// Append adds the chunk given as its first argument to the end of the value.
+	Append string
+	Args   []interface{}
+}
+
+// RowCondition returns the condition selecting the row identified by key, which must hold
+// every column of the primary key and nothing else. convert turns each value into the type
+// of its column.
+func RowCondition(primaryKey []string, key map[string]string, quoteIdentifier func(string) string, convert func(column string, value string) (interface{}, error)) (string, []interface{}, error) {
+	if len(primaryKey) == 0 {
+		return "", nil, errors.New("the table has no primary key to identify the row with")
+	}
+	keyColumns := map[string]bool{}
+	for _, column := range primaryKey {
+		keyColumns[column] = true
+	}
+	columns := make([]string, 0, len(key))
+	for column := range key {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		if !keyColumns[column] {
+			return "", nil, fmt.Errorf("%v is not part of the primary key", column)
+		}
+	}
+
+	condition := ""
+	args := []interface{}{}
+	for _, column := range primaryKey {
+		value, ok := key[column]
+		if !ok {
+			return "", nil, fmt.Errorf("the primary key column %v is missing", column)
+		}
+		converted, err := convert(column, value)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to convert value for column '%s': %v", column, err)
+		}
+		if len(condition) > 0 {
+			condition += " AND "
+		}
+		condition += quoteIdentifier(column) + " = ?"
+		args = append(args, converted)
+	}
+	return condition, args, nil
+}
+
+// ReadBlob writes the value to w as it is read from the database.
+func ReadBlob(db *gorm.DB, queries BlobQueries, w io.Writer) (int64, error) {
+	length, err := blobLength(db, queries)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	for written < length {
+		var chunk []byte
+		args := append([]interface{}{written + 1, engine.BlobChunkSize}, queries.Args...)
+		if err := db.Raw(queries.Read, args...).Row().Scan(&chunk); err != nil {
+			return written, err
+		}
+		// The value was shortened since its length was read.
+		if len(chunk) == 0 {
+			break
+		}
+		n, err := w.Write(chunk)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// WriteBlob replaces the value with what is read from r, appending it a chunk at a time in
+// a transaction, so that the value is left as it was if the upload fails.
+func WriteBlob(db *gorm.DB, queries BlobQueries, r io.Reader) (int64, error) {
+	var written int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Drivers may report no affected rows when the value was already empty, so the row
+		// is looked up first.
+		if _, err := blobLength(tx, queries); err != nil && !errors.Is(err, engine.ErrNullValue) {
+			return err
+		}
+		if err := tx.Exec(queries.Clear, queries.Args...).Error; err != nil {
+			return err
+		}
+		chunk := make([]byte, engine.BlobChunkSize)
+		for {
+			n, err := io.ReadFull(r, chunk)
+			if n > 0 {
+				if err := tx.Exec(queries.Append, append([]interface{}{chunk[:n]}, queries.Args...)...).Error; err != nil {
+					return err
+				}
+				written += int64(n)
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+func blobLength(db *gorm.DB, queries BlobQueries) (int64, error) {
+	var lengths []struct {
+		Length *int64 `gorm:"column:length"`
+	}
+	if err := db.Raw(queries.Length, queries.Args...).Scan(&lengths).Error; err != nil {
+		return 0, err
+	}
+	switch {
+	case len(lengths) == 0:
+		return 0, engine.ErrRowNotFound
+	case len(lengths) > 1:
+		return 0, errors.New("the key matches more than one row")
+	case lengths[0].Length == nil:
+		return 0, engine.ErrNullValue
+	}
+	return *lengths[0].Length, nil
+}
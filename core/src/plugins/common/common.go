@@ -9,3 +9,11 @@ func IsValidSQLTableName(tableName string) bool {
 	matched, _ := regexp.MatchString(pattern, tableName)
 	return matched
 }
+
+// IsValidPrivilege reports whether privilege is made of words only, such as SELECT or
+// CREATE TEMPORARY TABLES, so that it can be written into a GRANT statement.
+func IsValidPrivilege(privilege string) bool {
+	const pattern = `^[a-zA-Z_]+( [a-zA-Z_]+)*$`
+	matched, _ := regexp.MatchString(pattern, privilege)
+	return matched
+}
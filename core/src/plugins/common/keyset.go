@@ -0,0 +1,41 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// KeysetQuery builds a query returning the rows after afterKey in key order. keyCondition
+// compares the key column with a single placeholder, e.g. `"id" > ?`.
+func KeysetQuery(table string, where string, key string, keyCondition string, afterKey string, pageSize int) (string, []interface{}) {
+	conditions := []string{}
+	params := []interface{}{}
+	if len(strings.TrimSpace(where)) > 0 {
+		conditions = append(conditions, fmt.Sprintf("(%v)", where))
+	}
+	if len(afterKey) > 0 {
+		conditions = append(conditions, keyCondition)
+		params = append(params, afterKey)
+	}
+	query := fmt.Sprintf("SELECT * FROM %v", table)
+	if len(conditions) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, strings.Join(conditions, " AND "))
+	}
+	params = append(params, pageSize)
+	return fmt.Sprintf("%v ORDER BY %v LIMIT ?", query, key), params
+}
+
+// LastKey returns the value of the key column in the last row, or an empty string when there are no rows.
+func LastKey(result *engine.GetRowsResult, keyColumn string) string {
+	if result == nil || len(result.Rows) == 0 {
+		return ""
+	}
+	for i, column := range result.Columns {
+		if column.Name == keyColumn {
+			return result.Rows[len(result.Rows)-1][i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"gorm.io/gorm"
+)
+
+// GetRoutines reads routines from information_schema. routinesQuery must select the specific name,
+// name, type, return type and definition of each routine; parametersQuery the specific name, name,
+// type and mode of each parameter in order. Both are bound to the schema.
+func GetRoutines(db *gorm.DB, routinesQuery string, parametersQuery string, schema string) ([]engine.Routine, error) {
+	rows, err := db.Raw(routinesQuery, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	specificNames := []string{}
+	routines := map[string]*engine.Routine{}
+	for rows.Next() {
+		var specificName string
+		routine := &engine.Routine{Arguments: []engine.RoutineArgument{}}
+		if err := rows.Scan(&specificName, &routine.Name, &routine.Type, &routine.ReturnType, &routine.Definition); err != nil {
+			return nil, err
+		}
+		specificNames = append(specificNames, specificName)
+		routines[specificName] = routine
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	parameterRows, err := db.Raw(parametersQuery, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer parameterRows.Close()
+	for parameterRows.Next() {
+		var specificName string
+		argument := engine.RoutineArgument{}
+		if err := parameterRows.Scan(&specificName, &argument.Name, &argument.Type, &argument.Mode); err != nil {
+			return nil, err
+		}
+		if routine, ok := routines[specificName]; ok {
+			routine.Arguments = append(routine.Arguments, argument)
+		}
+	}
+	if err := parameterRows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := []engine.Routine{}
+	for _, specificName := range specificNames {
+		result = append(result, *routines[specificName])
+	}
+	return result, nil
+}
+
+// FindRoutine returns the routine called name that takes as many input arguments as given,
+// which also picks the right one among overloads.
+func FindRoutine(routines []engine.Routine, name string, argumentCount int) (*engine.Routine, error) {
+	for i, routine := range routines {
+		if routine.Name == name && len(InputArguments(routine)) == argumentCount {
+			return &routines[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no routine %v takes %d argument(s)", name, argumentCount)
+}
+
+// InputArguments returns the arguments a caller has to provide a value for.
+func InputArguments(routine engine.Routine) []engine.RoutineArgument {
+	arguments := []engine.RoutineArgument{}
+	for _, argument := range routine.Arguments {
+		if !strings.EqualFold(argument.Mode, "OUT") {
+			arguments = append(arguments, argument)
+		}
+	}
+	return arguments
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit    = 10
+	searchWorkers         = 4
+	searchEscapeCharacter = "!"
+)
+
+// SearchQueryBuilder returns the query selecting rows of table where any of the columns
+// matches the bound pattern. The pattern is bound once per column.
+type SearchQueryBuilder func(table string, columns []string) string
+
+// SearchTables scans the text columns of every table in parallel and returns the matching cells.
+// Patterns are escaped with "!", so queries must use LIKE ... ESCAPE '!'.
+func SearchTables(db *gorm.DB, tables map[string][]engine.Record, isTextType func(string) bool, buildQuery SearchQueryBuilder, search string, limit int) ([]engine.SearchHit, error) {
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	pattern := "%" + escapeLikePattern(search) + "%"
+
+	var mutex sync.Mutex
+	hits := []engine.SearchHit{}
+
+	group := errgroup.Group{}
+	group.SetLimit(searchWorkers)
+	for table, columns := range tables {
+		textColumns := []string{}
+		for _, column := range columns {
+			if isTextType(column.Value) {
+				textColumns = append(textColumns, column.Key)
+			}
+		}
+		if len(textColumns) == 0 {
+			continue
+		}
+
+		table := table
+		group.Go(func() error {
+			params := []interface{}{}
+			for range textColumns {
+				params = append(params, pattern)
+			}
+			params = append(params, limit)
+			tableHits, err := searchTable(db, table, textColumns, buildQuery(table, textColumns), params, search)
+			if err != nil {
+				return err
+			}
+			mutex.Lock()
+			hits = append(hits, tableHits...)
+			mutex.Unlock()
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].StorageUnit != hits[j].StorageUnit {
+			return hits[i].StorageUnit < hits[j].StorageUnit
+		}
+		return hits[i].Column < hits[j].Column
+	})
+	return hits, nil
+}
+
+func searchTable(db *gorm.DB, table string, textColumns []string, query string, params []interface{}, search string) ([]engine.SearchHit, error) {
+	rows, err := db.Raw(query, params...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	isSearched := map[string]bool{}
+	for _, column := range textColumns {
+		isSearched[column] = true
+	}
+
+	lowerSearch := strings.ToLower(search)
+	hits := []engine.SearchHit{}
+	for rows.Next() {
+		columnPointers := make([]interface{}, len(columns))
+		for i := range columns {
+			columnPointers[i] = new(sql.NullString)
+		}
+		if err := rows.Scan(columnPointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]engine.Record, len(columns))
+		for i, colPtr := range columnPointers {
+			row[i] = engine.Record{Key: columns[i], Value: colPtr.(*sql.NullString).String}
+		}
+
+		for _, record := range row {
+			if isSearched[record.Key] && strings.Contains(strings.ToLower(record.Value), lowerSearch) {
+				hits = append(hits, engine.SearchHit{
+					StorageUnit: table,
+					Column:      record.Key,
+					Row:         row,
+				})
+			}
+		}
+	}
+	return hits, rows.Err()
+}
+
+func escapeLikePattern(search string) string {
+	replacer := strings.NewReplacer(
+		searchEscapeCharacter, searchEscapeCharacter+searchEscapeCharacter,
+		"%", searchEscapeCharacter+"%",
+		"_", searchEscapeCharacter+"_",
+	)
+	return replacer.Replace(search)
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// OrderBy builds the ORDER BY clause of the sort conditions, with a leading space, or an
+// empty string when there are none. Databases without NULLS FIRST/LAST, such as MySQL,
+// pass nativeNulls false to have NULLs placed with an IS NULL sort key instead.
+func OrderBy(sort []engine.SortCondition, quoteIdentifier func(string) string, nativeNulls bool) (string, error) {
+	if len(sort) == 0 {
+		return "", nil
+	}
+	terms := []string{}
+	for _, condition := range sort {
+		if len(condition.Column) == 0 {
+			return "", fmt.Errorf("sort column is required")
+		}
+		column := quoteIdentifier(condition.Column)
+		expression := column
+		switch condition.Function {
+		case "":
+		case engine.SortFunction_Lower, engine.SortFunction_Upper, engine.SortFunction_Length, engine.SortFunction_Abs, engine.SortFunction_Trim:
+			expression = fmt.Sprintf("%v(%v)", condition.Function, column)
+		default:
+			return "", fmt.Errorf("unsupported sort function %q", condition.Function)
+		}
+
+		direction := condition.Direction
+		if len(direction) == 0 {
+			direction = engine.SortDirection_Asc
+		}
+		if direction != engine.SortDirection_Asc && direction != engine.SortDirection_Desc {
+			return "", fmt.Errorf("unsupported sort direction %q", direction)
+		}
+		if condition.Nulls != "" && condition.Nulls != engine.NullsOrder_First && condition.Nulls != engine.NullsOrder_Last {
+			return "", fmt.Errorf("unsupported nulls order %q", condition.Nulls)
+		}
+
+		term := fmt.Sprintf("%v %v", expression, direction)
+		if len(condition.Nulls) > 0 {
+			if nativeNulls {
+				term = fmt.Sprintf("%v NULLS %v", term, condition.Nulls)
+			} else {
+				// IS NULL is 1 for NULLs, so sorting on it descending puts them first.
+				nullsDirection := engine.SortDirection_Asc
+				if condition.Nulls == engine.NullsOrder_First {
+					nullsDirection = engine.SortDirection_Desc
+				}
+				term = fmt.Sprintf("(%v IS NULL) %v, %v", expression, nullsDirection, term)
+			}
+		}
+		terms = append(terms, term)
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
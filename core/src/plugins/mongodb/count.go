@@ -0,0 +1,38 @@
+package mongodb
+
+import (
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (p *MongoDBPlugin) EstimateRowCount(config *engine.PluginConfig, database string, storageUnit string) (int64, error) {
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	client, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Disconnect(ctx)
+
+	return client.Database(database).Collection(storageUnit).EstimatedDocumentCount(ctx)
+}
+
+func (p *MongoDBPlugin) CountRows(config *engine.PluginConfig, database string, storageUnit string, filter string) (int64, error) {
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	client, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Disconnect(ctx)
+
+	bsonFilter := bson.M{}
+	if len(filter) > 0 {
+		if err := bson.UnmarshalExtJSON([]byte(filter), true, &bsonFilter); err != nil {
+			return 0, fmt.Errorf("invalid filter format: %v", err)
+		}
+	}
+	return client.Database(database).Collection(storageUnit).CountDocuments(ctx, bsonFilter)
+}
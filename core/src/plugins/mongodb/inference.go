@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultSampleSize = 1000
+
+// typeAliases names BSON types the way $type does.
+var typeAliases = map[bsontype.Type]string{
+	bson.TypeDouble:           "double",
+	bson.TypeString:           "string",
+	bson.TypeEmbeddedDocument: "object",
+	bson.TypeArray:            "array",
+	bson.TypeBinary:           "binData",
+	bson.TypeUndefined:        "undefined",
+	bson.TypeObjectID:         "objectId",
+	bson.TypeBoolean:          "bool",
+	bson.TypeDateTime:         "date",
+	bson.TypeNull:             "null",
+	bson.TypeRegex:            "regex",
+	bson.TypeDBPointer:        "dbPointer",
+	bson.TypeJavaScript:       "javascript",
+	bson.TypeSymbol:           "symbol",
+	bson.TypeCodeWithScope:    "javascriptWithScope",
+	bson.TypeInt32:            "int",
+	bson.TypeTimestamp:        "timestamp",
+	bson.TypeInt64:            "long",
+	bson.TypeDecimal128:       "decimal",
+	bson.TypeMinKey:           "minKey",
+	bson.TypeMaxKey:           "maxKey",
+}
+
+type fieldStats struct {
+	documents int
+	types     map[string]int
+}
+
+// InferSchema lists the fields of up to sampleSize documents picked at random from the
+// collection, with how often each field and each of its types occurs.
+func (p *MongoDBPlugin) InferSchema(config *engine.PluginConfig, database string, collection string, sampleSize int) ([]engine.InferredField, error) {
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(ctx)
+
+	pipeline := mongo.Pipeline{{{Key: "$sample", Value: bson.M{"size": sampleSize}}}}
+	cursor, err := client.Database(database).Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fields := map[string]*fieldStats{}
+	documents := 0
+	for cursor.Next(ctx) {
+		documents++
+		// A field is counted once per document, even when arrays repeat it.
+		found := map[string]map[string]bool{}
+		if err := collectFields(cursor.Current, "", found); err != nil {
+			return nil, err
+		}
+		for path, types := range found {
+			stats, ok := fields[path]
+			if !ok {
+				stats = &fieldStats{types: map[string]int{}}
+				fields[path] = stats
+			}
+			stats.documents++
+			for fieldType := range types {
+				stats.types[fieldType]++
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	inferred := []engine.InferredField{}
+	for path, stats := range fields {
+		types := make([]string, 0, len(stats.types))
+		for fieldType := range stats.types {
+			types = append(types, fieldType)
+		}
+		sort.Slice(types, func(i, j int) bool {
+			if stats.types[types[i]] != stats.types[types[j]] {
+				return stats.types[types[i]] > stats.types[types[j]]
+			}
+			return types[i] < types[j]
+		})
+		inferred = append(inferred, engine.InferredField{
+			Path:       path,
+			Types:      types,
+			Occurrence: math.Round(float64(stats.documents)*1000/float64(documents)) / 10,
+		})
+	}
+	sort.Slice(inferred, func(i, j int) bool {
+		return inferred[i].Path < inferred[j].Path
+	})
+	return inferred, nil
+}
+
+// collectFields adds the fields of document to found, with the types of their values.
+func collectFields(document bson.Raw, prefix string, found map[string]map[string]bool) error {
+	elements, err := document.Elements()
+	if err != nil {
+		return err
+	}
+	for _, element := range elements {
+		path := element.Key()
+		if len(prefix) > 0 {
+			path = prefix + "." + path
+		}
+		if err := collectValue(element.Value(), path, found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectValue(value bson.RawValue, path string, found map[string]map[string]bool) error {
+	if found[path] == nil {
+		found[path] = map[string]bool{}
+	}
+	found[path][typeAliases[value.Type]] = true
+	switch value.Type {
+	case bson.TypeEmbeddedDocument:
+		return collectFields(value.Document(), path, found)
+	case bson.TypeArray:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+		for _, item := range values {
+			if item.Type == bson.TypeEmbeddedDocument {
+				if err := collectFields(item.Document(), path, found); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
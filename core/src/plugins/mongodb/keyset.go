@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetRowsAfter pages through a collection in _id order. Keys are the extended JSON of the
+// last _id, so ObjectIDs and other BSON types survive the round trip through the client.
+func (p *MongoDBPlugin) GetRowsAfter(config *engine.PluginConfig, database string, collection string, filter string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	client, err := DB(config)
+	if err != nil {
+		return nil, "", err
+	}
+	defer client.Disconnect(context.TODO())
+
+	conditions := bson.A{}
+	if len(filter) > 0 {
+		var bsonFilter bson.M
+		if err := bson.UnmarshalExtJSON([]byte(filter), true, &bsonFilter); err != nil {
+			return nil, "", fmt.Errorf("invalid filter format: %v", err)
+		}
+		conditions = append(conditions, bsonFilter)
+	}
+	if len(afterKey) > 0 {
+		var key bson.M
+		if err := bson.UnmarshalExtJSON([]byte(afterKey), true, &key); err != nil {
+			return nil, "", fmt.Errorf("invalid key: %v", err)
+		}
+		conditions = append(conditions, bson.M{"_id": bson.M{"$gt": key["_id"]}})
+	}
+	query := bson.M{}
+	if len(conditions) > 0 {
+		query = bson.M{"$and": conditions}
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "_id", Value: 1}})
+	findOptions.SetLimit(int64(pageSize))
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	cursor, err := client.Database(database).Collection(collection).Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	result := &engine.GetRowsResult{
+		Columns: []engine.Column{{Name: "document", Type: "Document"}},
+		Rows:    [][]string{},
+	}
+	guard := config.ResultGuard()
+	var lastID interface{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, "", err
+		}
+		jsonBytes, err := json.Marshal(doc)
+		if err != nil {
+			return nil, "", err
+		}
+		row := []string{string(jsonBytes)}
+		if err := guard.Add(row); err != nil {
+			return nil, "", err
+		}
+		result.Rows = append(result.Rows, row)
+		lastID = doc["_id"]
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, "", err
+	}
+
+	lastKey := ""
+	if len(result.Rows) > 0 {
+		keyBytes, err := bson.MarshalExtJSON(bson.M{"_id": lastID}, true, false)
+		if err != nil {
+			return nil, "", err
+		}
+		lastKey = string(keyBytes)
+	}
+	return result, lastKey, nil
+}
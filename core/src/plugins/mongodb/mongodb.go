@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"go.mongodb.org/mongo-driver/bson"
@@ -71,7 +73,7 @@ func (p *MongoDBPlugin) GetStorageUnits(config *engine.PluginConfig, database st
 	}
 	return storageUnits, nil
 }
-func (p *MongoDBPlugin) GetRows(config *engine.PluginConfig, database, collection, filter string, pageSize, pageOffset int) (*engine.GetRowsResult, error) {
+func (p *MongoDBPlugin) GetRows(config *engine.PluginConfig, database, collection, filter string, sort []engine.SortCondition, pageSize, pageOffset int) (*engine.GetRowsResult, error) {
 	client, err := DB(config)
 	if err != nil {
 		return nil, err
@@ -88,20 +90,28 @@ func (p *MongoDBPlugin) GetRows(config *engine.PluginConfig, database, collectio
 		}
 	}
 
+	// One document past the row limit tells whether the page was cut short by it.
+	if config.MaxRows > 0 && pageSize > config.MaxRows {
+		pageSize = config.MaxRows + 1
+	}
 	findOptions := options.Find()
 	findOptions.SetLimit(int64(pageSize))
 	findOptions.SetSkip(int64(pageOffset))
-
-	cursor, err := coll.Find(context.TODO(), bsonFilter, findOptions)
-	if err != nil {
-		return nil, err
+	if len(sort) > 0 {
+		sortDocument, err := sortDocument(sort)
+		if err != nil {
+			return nil, err
+		}
+		findOptions.SetSort(sortDocument)
 	}
-	defer cursor.Close(context.TODO())
 
-	var rowsResult []bson.M
-	if err = cursor.All(context.TODO(), &rowsResult); err != nil {
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	cursor, err := coll.Find(ctx, bsonFilter, findOptions)
+	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
 	result := &engine.GetRowsResult{
 		Columns: []engine.Column{
@@ -113,23 +123,118 @@ func (p *MongoDBPlugin) GetRows(config *engine.PluginConfig, database, collectio
 		Rows: [][]string{},
 	}
 
-	for _, doc := range rowsResult {
+	// Documents are converted one at a time so the guard stops before they are all in memory.
+	guard := config.ResultGuard()
+	for cursor.Next(ctx) {
+		if guard.Limit(result) {
+			break
+		}
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
 		jsonBytes, err := json.Marshal(doc)
 		if err != nil {
 			return nil, err
 		}
-		result.Rows = append(result.Rows, []string{
-			string(jsonBytes),
-		})
+		row := []string{string(jsonBytes)}
+		if err := guard.Add(row); err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, row)
 	}
 
-	return result, nil
+	return result, cursor.Err()
 }
 
 func (p *MongoDBPlugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
 	return nil, errors.ErrUnsupported
 }
 
+func (p *MongoDBPlugin) SearchStorageUnits(config *engine.PluginConfig, database string, search string, limit int) ([]engine.SearchHit, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetRoutines(config *engine.PluginConfig, database string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) ExecuteRoutine(config *engine.PluginConfig, database string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetColumnConstraints(config *engine.PluginConfig, database string, collection string) ([]engine.ColumnConstraint, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *MongoDBPlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	return nil, errors.ErrUnsupported
+}
+
 func NewMongoDBPlugin() *engine.Plugin {
 	return &engine.Plugin{
 		Type:            engine.DatabaseType_MongoDB,
@@ -0,0 +1,35 @@
+package mongodb
+
+import (
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// sortDocument converts the sort conditions to a MongoDB sort. MongoDB always sorts null and
+// missing fields first in ascending order, and cannot sort on a function of a field.
+func sortDocument(sort []engine.SortCondition) (bson.D, error) {
+	document := bson.D{}
+	for _, condition := range sort {
+		if len(condition.Column) == 0 {
+			return nil, fmt.Errorf("sort column is required")
+		}
+		if len(condition.Function) > 0 {
+			return nil, fmt.Errorf("sorting on %v(%v) is not supported by MongoDB", condition.Function, condition.Column)
+		}
+		direction, nullsDefault := 1, engine.NullsOrder_First
+		switch condition.Direction {
+		case "", engine.SortDirection_Asc:
+		case engine.SortDirection_Desc:
+			direction, nullsDefault = -1, engine.NullsOrder_Last
+		default:
+			return nil, fmt.Errorf("unsupported sort direction %q", condition.Direction)
+		}
+		if len(condition.Nulls) > 0 && condition.Nulls != nullsDefault {
+			return nil, fmt.Errorf("MongoDB cannot sort %v with NULLS %v", condition.Column, condition.Nulls)
+		}
+		document = append(document, bson.E{Key: condition.Column, Value: direction})
+	}
+	return document, nil
+}
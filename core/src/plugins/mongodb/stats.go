@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (p *MongoDBPlugin) GetStorageUnitStats(config *engine.PluginConfig, database string) (map[string][]engine.Record, error) {
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(ctx)
+
+	db := client.Database(database)
+	collections, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string][]engine.Record{}
+	for _, collectionName := range collections {
+		collection := db.Collection(collectionName)
+		count, err := collection.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		records := []engine.Record{
+			{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", count)},
+		}
+
+		// Default ObjectIds embed their creation time, so the newest _id tells when the
+		// collection was last inserted into. Updates are not reflected.
+		latest := bson.M{}
+		err = collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}}).SetProjection(bson.M{"_id": 1})).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if id, ok := latest["_id"].(primitive.ObjectID); ok {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastInserted, Value: id.Timestamp().Format(time.RFC3339)})
+		}
+		stats[collectionName] = records
+	}
+	return stats, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"go.mongodb.org/mongo-driver/bson"
@@ -60,3 +61,46 @@ func (p *MongoDBPlugin) UpdateStorageUnit(config *engine.PluginConfig, database
 
 	return true, nil
 }
+
+func (p *MongoDBPlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, database string, storageUnit string, filter string, values map[string]string) (int64, error) {
+	if len(filter) == 0 {
+		return 0, errors.New("a filter is required for batch updates")
+	}
+	if len(values) == 0 {
+		return 0, errors.New("no values to update")
+	}
+
+	ctx := context.Background()
+	client, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database(database).Collection(storageUnit)
+
+	var bsonFilter bson.M
+	if err := bson.UnmarshalExtJSON([]byte(filter), true, &bsonFilter); err != nil {
+		return 0, fmt.Errorf("invalid filter format: %v", err)
+	}
+
+	set := bson.M{}
+	for field, value := range values {
+		if field == "_id" {
+			return 0, errors.New("the '_id' field cannot be updated")
+		}
+		var jsonValue interface{}
+		if err := json.Unmarshal([]byte(value), &jsonValue); err != nil {
+			jsonValue = value
+		}
+		set[field] = jsonValue
+	}
+
+	result, err := collection.UpdateMany(ctx, bsonFilter, bson.M{"$set": set})
+	if err != nil {
+		return 0, err
+	}
+
+	// Documents already holding the values are not modified, but were still updated as asked.
+	return result.MatchedCount, nil
+}
@@ -0,0 +1,157 @@
+package mysql
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+// GetActivity returns the sessions of the server, longest running transaction first, and
+// the InnoDB locks they hold or wait for. Locks are read from performance_schema, which
+// MySQL 8 enables by default; without it, sessions are returned without locks.
+func (p *MySQLPlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var sessions []struct {
+		ID               string     `gorm:"column:id"`
+		User             string     `gorm:"column:user_name"`
+		Database         string     `gorm:"column:database_name"`
+		Client           string     `gorm:"column:client"`
+		State            string     `gorm:"column:state"`
+		Query            string     `gorm:"column:query"`
+		TransactionStart *time.Time `gorm:"column:transaction_start"`
+		Seconds          int64      `gorm:"column:seconds"`
+	}
+	query := `
+		SELECT
+			CAST(p.ID AS CHAR) AS id,
+			COALESCE(p.USER, '') AS user_name,
+			COALESCE(p.DB, '') AS database_name,
+			COALESCE(p.HOST, '') AS client,
+			COALESCE(p.COMMAND, '') AS state,
+			COALESCE(p.INFO, '') AS query,
+			t.trx_started AS transaction_start,
+			COALESCE(p.TIME, 0) AS seconds
+		FROM information_schema.PROCESSLIST p
+		LEFT JOIN information_schema.INNODB_TRX t ON t.trx_mysql_thread_id = p.ID
+		WHERE p.ID <> CONNECTION_ID() AND p.COMMAND <> 'Daemon'
+		ORDER BY t.trx_started IS NULL, t.trx_started, p.ID
+	`
+	if err := db.Raw(query).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	var waits []struct {
+		Waiting  string `gorm:"column:waiting"`
+		Blocking string `gorm:"column:blocking"`
+	}
+	var locks []struct {
+		SessionID string `gorm:"column:session_id"`
+		Type      string `gorm:"column:lock_type"`
+		Object    string `gorm:"column:object"`
+		Mode      string `gorm:"column:mode"`
+		Granted   bool   `gorm:"column:granted"`
+	}
+	err = db.Raw(`
+		SELECT DISTINCT
+			CAST(waiting.PROCESSLIST_ID AS CHAR) AS waiting,
+			CAST(blocking.PROCESSLIST_ID AS CHAR) AS blocking
+		FROM performance_schema.data_lock_waits w
+		JOIN performance_schema.threads waiting ON waiting.THREAD_ID = w.REQUESTING_THREAD_ID
+		JOIN performance_schema.threads blocking ON blocking.THREAD_ID = w.BLOCKING_THREAD_ID
+	`).Scan(&waits).Error
+	if err == nil {
+		err = db.Raw(`
+			SELECT
+				CAST(t.PROCESSLIST_ID AS CHAR) AS session_id,
+				l.LOCK_TYPE AS lock_type,
+				CONCAT(l.OBJECT_SCHEMA, '.', l.OBJECT_NAME) AS object,
+				l.LOCK_MODE AS mode,
+				l.LOCK_STATUS = 'GRANTED' AS granted
+			FROM performance_schema.data_locks l
+			JOIN performance_schema.threads t ON t.THREAD_ID = l.THREAD_ID
+			WHERE t.PROCESSLIST_ID <> CONNECTION_ID()
+			ORDER BY granted, t.PROCESSLIST_ID
+		`).Scan(&locks).Error
+	}
+	if err != nil {
+		log.Logger.Warnf("Unable to read locks from performance_schema: %v", err)
+		waits = nil
+		locks = nil
+	}
+
+	blockedBy := map[string][]string{}
+	for _, wait := range waits {
+		blockedBy[wait.Waiting] = append(blockedBy[wait.Waiting], wait.Blocking)
+	}
+	now := time.Now()
+	activity := &engine.Activity{Sessions: []engine.Session{}, Locks: []engine.Lock{}}
+	for _, session := range sessions {
+		result := engine.Session{
+			ID:        session.ID,
+			User:      session.User,
+			Database:  session.Database,
+			Client:    session.Client,
+			State:     session.State,
+			Query:     session.Query,
+			BlockedBy: []string{},
+		}
+		if session.TransactionStart != nil {
+			result.TransactionStart = *session.TransactionStart
+		}
+		// The process list only has how long the session has been in its current state.
+		if len(session.Query) > 0 {
+			result.QueryStart = now.Add(-time.Duration(session.Seconds) * time.Second)
+		}
+		result.BlockedBy = append(result.BlockedBy, blockedBy[session.ID]...)
+		activity.Sessions = append(activity.Sessions, result)
+	}
+	for _, lock := range locks {
+		activity.Locks = append(activity.Locks, engine.Lock{
+			SessionID: lock.SessionID,
+			Type:      lock.Type,
+			Object:    lock.Object,
+			Mode:      lock.Mode,
+			Granted:   lock.Granted,
+		})
+	}
+	return activity, nil
+}
+
+// KillSession ends a session, rolling back its open transaction.
+func (p *MySQLPlugin) KillSession(config *engine.PluginConfig, id string) error {
+	processID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return engine.ErrSessionNotFound
+	}
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM information_schema.PROCESSLIST WHERE ID = ?", processID).Scan(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return engine.ErrSessionNotFound
+	}
+	// KILL does not take placeholders; the ID was parsed as a number above.
+	return db.Exec(fmt.Sprintf("KILL %d", processID)).Error
+}
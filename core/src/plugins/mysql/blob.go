@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+	"gorm.io/gorm"
+)
+
+var blobTypes = map[string]bool{
+	"tinyblob":   true,
+	"blob":       true,
+	"mediumblob": true,
+	"longblob":   true,
+	"varbinary":  true,
+}
+
+func (p *MySQLPlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, schema, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.ReadBlob(db, queries, w)
+}
+
+// WriteBlob is limited by max_allowed_packet only per chunk, not for the whole value.
+func (p *MySQLPlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, schema, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.WriteBlob(db, queries, r)
+}
+
+func blobQueries(db *gorm.DB, schema string, storageUnit string, column string, key map[string]string) (common.BlobQueries, error) {
+	pkColumns, err := getPrimaryKeyColumns(db, schema, storageUnit)
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+	columnTypes, err := getColumnTypes(db, schema, storageUnit)
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+	columnType, ok := columnTypes[column]
+	if !ok {
+		return common.BlobQueries{}, fmt.Errorf("column '%s' does not exist in table %s", column, storageUnit)
+	}
+	if !blobTypes[columnType] {
+		return common.BlobQueries{}, fmt.Errorf("column '%s' is not a binary column", column)
+	}
+	condition, args, err := common.RowCondition(pkColumns, key, quoteIdentifier, func(column string, value string) (interface{}, error) {
+		return convertStringValue(value, columnTypes[column])
+	})
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+
+	table := fmt.Sprintf("%v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	value := quoteIdentifier(column)
+	return common.BlobQueries{
+		Length: fmt.Sprintf("SELECT OCTET_LENGTH(%v) AS length FROM %v WHERE %v", value, table, condition),
+		Read:   fmt.Sprintf("SELECT SUBSTRING(%v, ?, ?) FROM %v WHERE %v", value, table, condition),
+		Clear:  fmt.Sprintf("UPDATE %v SET %v = '' WHERE %v", table, value, condition),
+		Append: fmt.Sprintf("UPDATE %v SET %v = CONCAT(%v, ?) WHERE %v", table, value, value, condition),
+		Args:   args,
+	}, nil
+}
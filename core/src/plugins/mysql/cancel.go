@@ -0,0 +1,24 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/log"
+	"gorm.io/gorm"
+)
+
+// killQuery stops the query running on a connection. When a query is cancelled the driver
+// only drops its connection, and MySQL keeps running the query until it tries to send the
+// results, so the query is killed from another connection.
+func killQuery(db *gorm.DB, connectionID int64) {
+	if connectionID == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.WithContext(ctx).Exec(fmt.Sprintf("KILL QUERY %d", connectionID)).Error; err != nil {
+		log.Logger.Warnf("Unable to kill cancelled query on connection %v: %v", connectionID, err)
+	}
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+func (p *MySQLPlugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var columns []struct {
+		ColumnName string `gorm:"column:COLUMN_NAME"`
+		DataType   string `gorm:"column:DATA_TYPE"`
+		ColumnType string `gorm:"column:COLUMN_TYPE"`
+		Nullable   bool   `gorm:"column:NULLABLE"`
+		MaxLength  int64  `gorm:"column:MAX_LENGTH"`
+	}
+	query := `
+		SELECT
+			COLUMN_NAME,
+			DATA_TYPE,
+			COLUMN_TYPE,
+			IS_NULLABLE = 'YES' AS NULLABLE,
+			COALESCE(CHARACTER_MAXIMUM_LENGTH, 0) AS MAX_LENGTH
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION
+	`
+	if err := db.Raw(query, schema, storageUnit).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+
+	constraints := []engine.ColumnConstraint{}
+	for _, column := range columns {
+		constraint := engine.ColumnConstraint{
+			Name:      column.ColumnName,
+			Type:      column.DataType,
+			Nullable:  column.Nullable,
+			MaxLength: int(column.MaxLength),
+		}
+		if column.DataType == "enum" {
+			constraint.Values = parseEnumValues(column.ColumnType)
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, nil
+}
+
+// parseEnumValues extracts the values of a column type such as enum('a','it”s').
+func parseEnumValues(columnType string) []string {
+	values := []string{}
+	inside := strings.TrimSuffix(strings.TrimPrefix(columnType, "enum("), ")")
+	var value strings.Builder
+	quoted := false
+	for i := 0; i < len(inside); i++ {
+		switch {
+		case inside[i] == '\'' && quoted && i+1 < len(inside) && inside[i+1] == '\'':
+			value.WriteByte('\'')
+			i++
+		case inside[i] == '\'':
+			if quoted {
+				values = append(values, value.String())
+				value.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			value.WriteByte(inside[i])
+		}
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+func (p *MySQLPlugin) EstimateRowCount(config *engine.PluginConfig, schema string, storageUnit string) (int64, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	// TABLE_ROWS comes from the storage engine statistics and is NULL for views.
+	var estimate *int64
+	err = db.Raw("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", schema, storageUnit).Row().Scan(&estimate)
+	if err != nil {
+		return 0, err
+	}
+	if estimate == nil {
+		return 0, errors.ErrUnsupported
+	}
+	return *estimate, nil
+}
+
+func (p *MySQLPlugin) CountRows(config *engine.PluginConfig, schema string, storageUnit string, where string) (int64, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return 0, errors.New("invalid table name")
+	}
+	db, err := ReadDB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	db = db.WithContext(ctx)
+	if config.ReadOnly {
+		db = db.Begin(&sql.TxOptions{ReadOnly: true})
+		if db.Error != nil {
+			return 0, db.Error
+		}
+		defer db.Rollback()
+	}
+	var count int64
+	if err := db.Raw(query).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
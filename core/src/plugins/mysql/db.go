@@ -1,18 +1,60 @@
 package mysql
 
 import (
-	"fmt"
+	"database/sql"
+	"net"
+	"strconv"
+	"time"
 
+	"github.com/clidey/whodb/core/src/common"
 	"github.com/clidey/whodb/core/src/engine"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 func DB(config *engine.PluginConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%v:%v@tcp(%v:3306)/%v?charset=utf8mb4&parseTime=True&loc=Local", config.Credentials.Username, config.Credentials.Password, config.Credentials.Hostname, config.Credentials.Database)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	host, port, err := engine.ResolveAddress(config.Credentials, 3306)
+	if err != nil {
+		return nil, err
+	}
+	tlsValue, err := tlsParameter(config.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	dsnConfig := mysqldriver.NewConfig()
+	dsnConfig.User = config.Credentials.Username
+	dsnConfig.Passwd = config.Credentials.Password
+	dsnConfig.Net = "tcp"
+	dsnConfig.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	dsnConfig.DBName = config.Credentials.Database
+	dsnConfig.Params = map[string]string{"charset": "utf8mb4"}
+	dsnConfig.ParseTime = true
+	dsnConfig.Loc = time.Local
+	dsnConfig.TLSConfig = tlsValue
+	// The config is handed to the driver as is, since a formatted DSN does not escape the
+	// database name, which could then add parameters of its own.
+	connector, err := mysqldriver.NewConnector(dsnConfig)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{DSNConfig: dsnConfig, Conn: sql.OpenDB(connector)}), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
+
+// ReadDB connects to the read replica when the connection has one, and to the primary otherwise.
+func ReadDB(config *engine.PluginConfig) (*gorm.DB, error) {
+	return DB(engine.ReadConfig(config))
+}
+
+// QueryDB returns the connection a query should run on: the read replica for queries that
+// only read, the primary for everything else.
+func QueryDB(config *engine.PluginConfig, query string) (*gorm.DB, error) {
+	if common.IsReadOnlyQuery(query) {
+		return ReadDB(config)
+	}
+	return DB(config)
+}
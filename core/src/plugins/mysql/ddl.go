@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetDDL returns the statement SHOW CREATE prints for a table or view.
+func (p *MySQLPlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return "", err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return "", err
+	}
+	defer sqlDb.Close()
+
+	var tableTypes []string
+	if err := db.Raw("SELECT TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", schema, storageUnit).Scan(&tableTypes).Error; err != nil {
+		return "", err
+	}
+	if len(tableTypes) == 0 {
+		return "", engine.ErrStorageUnitNotFound
+	}
+	name := fmt.Sprintf("%v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	query := "SHOW CREATE TABLE " + name
+	if tableTypes[0] == "VIEW" {
+		query = "SHOW CREATE VIEW " + name
+	}
+
+	// The statement is the second column of both, whose names differ.
+	rows, err := db.Raw(query).Rows()
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", engine.ErrStorageUnitNotFound
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	values := make([]interface{}, len(columns))
+	var statement string
+	for i := range values {
+		values[i] = new(interface{})
+	}
+	values[1] = &statement
+	if err := rows.Scan(values...); err != nil {
+		return "", err
+	}
+	return statement + ";\n", nil
+}
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetForeignKeys returns the foreign keys from or to the tables of the schema.
+func (p *MySQLPlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var keyColumns []struct {
+		Name             string `gorm:"column:CONSTRAINT_NAME"`
+		Schema           string `gorm:"column:TABLE_SCHEMA"`
+		Table            string `gorm:"column:TABLE_NAME"`
+		Column           string `gorm:"column:COLUMN_NAME"`
+		ReferencedSchema string `gorm:"column:REFERENCED_TABLE_SCHEMA"`
+		ReferencedTable  string `gorm:"column:REFERENCED_TABLE_NAME"`
+		ReferencedColumn string `gorm:"column:REFERENCED_COLUMN_NAME"`
+	}
+	query := `
+		SELECT CONSTRAINT_NAME, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME,
+			REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE REFERENCED_TABLE_NAME IS NOT NULL AND (TABLE_SCHEMA = ? OR REFERENCED_TABLE_SCHEMA = ?)
+		ORDER BY TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+	`
+	if err := db.Raw(query, schema, schema).Scan(&keyColumns).Error; err != nil {
+		return nil, err
+	}
+
+	// Each column of a key is its own row, in order.
+	foreignKeys := []engine.ForeignKey{}
+	for _, keyColumn := range keyColumns {
+		last := len(foreignKeys) - 1
+		if last < 0 || foreignKeys[last].Schema != keyColumn.Schema || foreignKeys[last].Table != keyColumn.Table || foreignKeys[last].Name != keyColumn.Name {
+			foreignKeys = append(foreignKeys, engine.ForeignKey{
+				Name:             keyColumn.Name,
+				Schema:           keyColumn.Schema,
+				Table:            keyColumn.Table,
+				ReferencedSchema: keyColumn.ReferencedSchema,
+				ReferencedTable:  keyColumn.ReferencedTable,
+			})
+			last++
+		}
+		foreignKeys[last].Columns = append(foreignKeys[last].Columns, keyColumn.Column)
+		foreignKeys[last].ReferencedColumns = append(foreignKeys[last].ReferencedColumns, keyColumn.ReferencedColumn)
+	}
+	return foreignKeys, nil
+}
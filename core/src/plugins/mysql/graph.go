@@ -40,7 +40,7 @@ WHERE
 `
 
 func (p *MySQLPlugin) GetGraph(config *engine.PluginConfig, schema string) ([]engine.GraphUnit, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetIndexes returns the indexes of the tables of the schema. Functional key parts, which
+// have no column, are left out.
+func (p *MySQLPlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var indexColumns []struct {
+		Name      string `gorm:"column:INDEX_NAME"`
+		Table     string `gorm:"column:TABLE_NAME"`
+		Column    string `gorm:"column:COLUMN_NAME"`
+		NonUnique int    `gorm:"column:NON_UNIQUE"`
+	}
+	query := `
+		SELECT INDEX_NAME, TABLE_NAME, COLUMN_NAME, NON_UNIQUE
+		FROM INFORMATION_SCHEMA.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND COLUMN_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`
+	if err := db.Raw(query, schema).Scan(&indexColumns).Error; err != nil {
+		return nil, err
+	}
+
+	// Each column of an index is its own row, in order.
+	indexes := []engine.Index{}
+	for _, indexColumn := range indexColumns {
+		last := len(indexes) - 1
+		if last < 0 || indexes[last].Table != indexColumn.Table || indexes[last].Name != indexColumn.Name {
+			indexes = append(indexes, engine.Index{
+				Name:    indexColumn.Name,
+				Table:   indexColumn.Table,
+				Unique:  indexColumn.NonUnique == 0,
+				Primary: indexColumn.Name == "PRIMARY",
+			})
+			last++
+		}
+		indexes[last].Columns = append(indexes[last].Columns, indexColumn.Column)
+	}
+	return indexes, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"github.com/clidey/whodb/core/src/plugins/common"
@@ -31,7 +32,7 @@ func (p *MySQLPlugin) GetDatabases() ([]string, error) {
 }
 
 func (p *MySQLPlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +55,7 @@ func (p *MySQLPlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
 }
 
 func (p *MySQLPlugin) GetStorageUnits(config *engine.PluginConfig, schema string) ([]engine.StorageUnit, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +140,7 @@ func getTableSchema(db *gorm.DB, schema string) (map[string][]engine.Record, err
 	return tableColumnsMap, nil
 }
 
-func (p *MySQLPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+func (p *MySQLPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
 	if !common.IsValidSQLTableName(storageUnit) {
 		return nil, errors.New("invalid table name")
 	}
@@ -148,21 +149,57 @@ func (p *MySQLPlugin) GetRows(config *engine.PluginConfig, schema string, storag
 	if len(where) > 0 {
 		query = fmt.Sprintf("%v WHERE %v", query, where)
 	}
-	query = fmt.Sprintf("%v LIMIT ? OFFSET ?", query)
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf("%v%v LIMIT ? OFFSET ?", query, orderBy)
 	return p.executeRawSQL(config, query, pageSize, pageOffset)
 }
 
 func (p *MySQLPlugin) executeRawSQL(config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
-	db, err := DB(config)
+	db, err := QueryDB(config, query)
 	if err != nil {
 		return nil, err
 	}
+	return p.executeSQL(db, config, query, params...)
+}
 
+// executeSQL runs the query on db, which it closes afterwards, killing it on the server
+// when it is cancelled or times out.
+func (p *MySQLPlugin) executeSQL(db *gorm.DB, config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
 	sqlDb, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
 	defer sqlDb.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	var result *engine.GetRowsResult
+	var connectionID int64
+	err = db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT CONNECTION_ID()").Scan(&connectionID).Error; err != nil {
+			return err
+		}
+		conn = conn.WithContext(ctx)
+		if config.ReadOnly {
+			conn = conn.Begin(&sql.TxOptions{ReadOnly: true})
+			if conn.Error != nil {
+				return conn.Error
+			}
+			defer conn.Rollback()
+		}
+		result, err = readRows(conn, config, query, params...)
+		return err
+	})
+	if err != nil && ctx.Err() != nil {
+		killQuery(db, connectionID)
+	}
+	return result, err
+}
+
+func readRows(db *gorm.DB, config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
 	rows, err := db.Raw(query, params...).Rows()
 	if err != nil {
 		return nil, err
@@ -189,9 +226,15 @@ func (p *MySQLPlugin) executeRawSQL(config *engine.PluginConfig, query string, p
 		}
 	}
 
+	guard := config.ResultGuard()
 	for rows.Next() {
+		if guard.Limit(result) {
+			break
+		}
+
 		columnPointers := make([]interface{}, len(columns))
 		row := make([]string, len(columns))
+		nulls := make([]bool, len(columns))
 		for i := range columns {
 			columnPointers[i] = new(sql.NullString)
 		}
@@ -206,10 +249,17 @@ func (p *MySQLPlugin) executeRawSQL(config *engine.PluginConfig, query string, p
 				row[i] = val.String
 			} else {
 				row[i] = ""
+				nulls[i] = true
 			}
 		}
 
-		result.Rows = append(result.Rows, row)
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, err
+		}
+	}
+	// A query cancelled while its rows are read ends them early.
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -219,6 +269,30 @@ func (p *MySQLPlugin) RawExecute(config *engine.PluginConfig, query string) (*en
 	return p.executeRawSQL(config, query)
 }
 
+func (p *MySQLPlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MySQLPlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MySQLPlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MySQLPlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *MySQLPlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *MySQLPlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
 func NewMySQLPlugin() *engine.Plugin {
 	return &engine.Plugin{
 		Type:            engine.DatabaseType_MySQL,
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+const routinesQuery = `
+	SELECT SPECIFIC_NAME, ROUTINE_NAME, ROUTINE_TYPE, COALESCE(DTD_IDENTIFIER, ''), COALESCE(ROUTINE_DEFINITION, '')
+	FROM information_schema.ROUTINES
+	WHERE ROUTINE_SCHEMA = ?
+	ORDER BY ROUTINE_NAME
+`
+
+// The return value of a function is listed as a parameter without a mode.
+const routineParametersQuery = `
+	SELECT SPECIFIC_NAME, COALESCE(PARAMETER_NAME, ''), DTD_IDENTIFIER, PARAMETER_MODE
+	FROM information_schema.PARAMETERS
+	WHERE SPECIFIC_SCHEMA = ? AND PARAMETER_MODE IS NOT NULL
+	ORDER BY SPECIFIC_NAME, ORDINAL_POSITION
+`
+
+func (p *MySQLPlugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	return common.GetRoutines(db, routinesQuery, routineParametersQuery, schema)
+}
+
+func (p *MySQLPlugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routineName string, arguments []string) (*engine.GetRowsResult, error) {
+	routines, err := p.GetRoutines(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	routine, err := common.FindRoutine(routines, routineName, len(arguments))
+	if err != nil {
+		return nil, err
+	}
+	// OUT parameters can only be bound to session variables, which do not survive
+	// across the pooled connections queries run on.
+	if len(common.InputArguments(*routine)) != len(routine.Arguments) {
+		return nil, errors.New("procedures with OUT parameters are not supported")
+	}
+
+	placeholders := []string{}
+	params := []interface{}{}
+	for _, argument := range arguments {
+		placeholders = append(placeholders, "?")
+		params = append(params, argument)
+	}
+
+	call := fmt.Sprintf("%v.%v(%v)", quoteIdentifier(schema), quoteIdentifier(routine.Name), strings.Join(placeholders, ", "))
+	query := fmt.Sprintf("SELECT %v AS %v", call, quoteIdentifier("result"))
+	if routine.Type == engine.RoutineType_Procedure {
+		query = "CALL " + call
+	}
+	// Functions may write too, so routines always run on the primary.
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	return p.executeSQL(db, config, query, params...)
+}
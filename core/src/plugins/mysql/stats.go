@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+func (p *MySQLPlugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	// UPDATE_TIME is kept in memory by InnoDB and resets when the server restarts.
+	rows, err := db.Raw(`
+		SELECT TABLE_NAME, TABLE_ROWS, UPDATE_TIME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?
+	`, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName string
+		var tableRows sql.NullInt64
+		var updateTime sql.NullTime
+		if err := rows.Scan(&tableName, &tableRows, &updateTime); err != nil {
+			return nil, err
+		}
+		records := []engine.Record{}
+		if tableRows.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", tableRows.Int64)})
+		}
+		if updateTime.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastModified, Value: updateTime.Time.Format(time.RFC3339)})
+		}
+		stats[tableName] = records
+	}
+	return stats, rows.Err()
+}
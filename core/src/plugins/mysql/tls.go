@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsParameter returns the tls parameter of the DSN for the connection's TLS options, or
+// an empty string to keep the driver's default. The driver only takes verifying configs by
+// name, so they are registered under a name derived from the options.
+func tlsParameter(credentials *engine.Credentials) (string, error) {
+	options, err := engine.GetTLSOptions(credentials)
+	if err != nil {
+		return "", err
+	}
+	switch options.Mode {
+	case "":
+		return "", nil
+	case engine.TLSMode_Disable:
+		return "false", nil
+	case engine.TLSMode_Prefer:
+		return "preferred", nil
+	}
+	// The certificate names the database host, not the local end of an SSH tunnel.
+	config, err := options.Config(credentials.Hostname)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v|%v|%v|%v|%v", options.Mode, options.CAPath, options.CertPath, options.KeyPath, credentials.Hostname)))
+	name := "whodb-" + hex.EncodeToString(sum[:8])
+	if err := mysql.RegisterTLSConfig(name, config); err != nil {
+		return "", err
+	}
+	return name, nil
+}
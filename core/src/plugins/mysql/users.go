@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+// GetDatabaseUsers returns the accounts of the server with the roles granted to them and
+// their global, schema and table privileges. Roles are read from mysql.role_edges, which
+// only MySQL 8 has; older servers return accounts without roles.
+func (p *MySQLPlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var accounts []struct {
+		Name      string `gorm:"column:name"`
+		Host      string `gorm:"column:host"`
+		CanLogin  bool   `gorm:"column:can_login"`
+		Superuser bool   `gorm:"column:superuser"`
+	}
+	query := "SELECT User AS name, Host AS host, account_locked = 'N' AS can_login, Super_priv = 'Y' AS superuser FROM mysql.user ORDER BY User, Host"
+	if err := db.Raw(query).Scan(&accounts).Error; err != nil {
+		return nil, err
+	}
+	var grants []struct {
+		Grantee   string `gorm:"column:grantee"`
+		Privilege string `gorm:"column:privilege"`
+		Schema    string `gorm:"column:schema_name"`
+		Object    string `gorm:"column:object"`
+		Grantable bool   `gorm:"column:grantable"`
+	}
+	query = `
+		SELECT GRANTEE AS grantee, PRIVILEGE_TYPE AS privilege, '' AS schema_name, '' AS object, IS_GRANTABLE = 'YES' AS grantable
+		FROM information_schema.USER_PRIVILEGES
+		WHERE PRIVILEGE_TYPE <> 'USAGE'
+		UNION ALL
+		SELECT GRANTEE, PRIVILEGE_TYPE, TABLE_SCHEMA, '', IS_GRANTABLE = 'YES'
+		FROM information_schema.SCHEMA_PRIVILEGES
+		UNION ALL
+		SELECT GRANTEE, PRIVILEGE_TYPE, TABLE_SCHEMA, TABLE_NAME, IS_GRANTABLE = 'YES'
+		FROM information_schema.TABLE_PRIVILEGES
+		ORDER BY 1, 3, 4, 2
+	`
+	if err := db.Raw(query).Scan(&grants).Error; err != nil {
+		return nil, err
+	}
+	var roles []struct {
+		Name     string `gorm:"column:name"`
+		Host     string `gorm:"column:host"`
+		RoleName string `gorm:"column:role_name"`
+		RoleHost string `gorm:"column:role_host"`
+	}
+	query = "SELECT TO_USER AS name, TO_HOST AS host, FROM_USER AS role_name, FROM_HOST AS role_host FROM mysql.role_edges ORDER BY FROM_USER"
+	if err := db.Raw(query).Scan(&roles).Error; err != nil {
+		log.Logger.Warnf("Unable to read roles from mysql.role_edges: %v", err)
+		roles = nil
+	}
+
+	users := []engine.DatabaseUser{}
+	index := map[string]int{}
+	for _, account := range accounts {
+		// information_schema names grantees the way GRANT statements do.
+		index[fmt.Sprintf("'%v'@'%v'", account.Name, account.Host)] = len(users)
+		users = append(users, engine.DatabaseUser{
+			Name:      account.Name,
+			Host:      account.Host,
+			CanLogin:  account.CanLogin,
+			Superuser: account.Superuser,
+			MemberOf:  []string{},
+			Grants:    []engine.Grant{},
+		})
+	}
+	for _, role := range roles {
+		if i, ok := index[fmt.Sprintf("'%v'@'%v'", role.Name, role.Host)]; ok {
+			users[i].MemberOf = append(users[i].MemberOf, role.RoleName+"@"+role.RoleHost)
+		}
+	}
+	for _, grant := range grants {
+		if i, ok := index[grant.Grantee]; ok {
+			users[i].Grants = append(users[i].Grants, engine.Grant{
+				Privilege: grant.Privilege,
+				Schema:    grant.Schema,
+				Object:    grant.Object,
+				Grantable: grant.Grantable,
+			})
+		}
+	}
+	return users, nil
+}
+
+// CreateDatabaseUser creates an account identified by password. The name may end with
+// @host to restrict where it connects from; otherwise it connects from anywhere.
+func (p *MySQLPlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	account, err := quoteAccount(name)
+	if err != nil {
+		return err
+	}
+	query := "CREATE USER " + account
+	if len(password) > 0 {
+		query += " IDENTIFIED BY " + quoteString(password)
+	}
+	return execGrant(config, query)
+}
+
+func (p *MySQLPlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	privilege, target, account, err := grantStatement(user, grant)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("GRANT %v ON %v TO %v", privilege, target, account)
+	if grant.Grantable {
+		query += " WITH GRANT OPTION"
+	}
+	return execGrant(config, query)
+}
+
+func (p *MySQLPlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	privilege, target, account, err := grantStatement(user, grant)
+	if err != nil {
+		return err
+	}
+	// Revoking with Grantable only takes back the right to grant privileges.
+	if grant.Grantable {
+		privilege = "GRANT OPTION"
+	}
+	return execGrant(config, fmt.Sprintf("REVOKE %v ON %v FROM %v", privilege, target, account))
+}
+
+// grantStatement returns the privilege, the object and the account of a GRANT or REVOKE
+// statement.
+func grantStatement(user string, grant engine.Grant) (string, string, string, error) {
+	privilege := strings.ToUpper(strings.TrimSpace(grant.Privilege))
+	if !common.IsValidPrivilege(privilege) {
+		return "", "", "", fmt.Errorf("invalid privilege: %v", grant.Privilege)
+	}
+	account, err := quoteAccount(user)
+	if err != nil {
+		return "", "", "", err
+	}
+	switch {
+	case len(grant.Schema) == 0 && len(grant.Object) == 0:
+		return privilege, "*.*", account, nil
+	case len(grant.Schema) == 0:
+		return "", "", "", errors.New("a schema is required to grant a privilege on a table")
+	case len(grant.Object) == 0 || grant.Object == "*":
+		return privilege, quoteIdentifier(grant.Schema) + ".*", account, nil
+	default:
+		return privilege, fmt.Sprintf("%v.%v", quoteIdentifier(grant.Schema), quoteIdentifier(grant.Object)), account, nil
+	}
+}
+
+// quoteAccount quotes a name@host account, the host defaulting to any.
+func quoteAccount(user string) (string, error) {
+	name, host := user, "%"
+	if i := strings.LastIndex(user, "@"); i >= 0 {
+		name, host = user[:i], user[i+1:]
+	}
+	if len(name) == 0 {
+		return "", errors.New("a user is required")
+	}
+	return quoteString(name) + "@" + quoteString(host), nil
+}
+
+// quoteString writes a string literal, for the statements that do not take placeholders.
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", "''") + "'"
+}
+
+func execGrant(config *engine.PluginConfig, query string) error {
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+	return db.Exec(query).Error
+}
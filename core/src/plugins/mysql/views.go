@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetViewDefinition returns the query of a view as MySQL stores it, with every star
+// expanded and every column qualified with its schema and table.
+func (p *MySQLPlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var definitions []string
+	if err := db.Raw("SELECT VIEW_DEFINITION FROM INFORMATION_SCHEMA.VIEWS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", schema, view).Scan(&definitions).Error; err != nil {
+		return nil, err
+	}
+	// The definition is also empty when the user lacks the SHOW VIEW privilege.
+	if len(definitions) == 0 || len(definitions[0]) == 0 {
+		return nil, engine.ErrNotAView
+	}
+
+	definition := &engine.ViewDefinition{Definition: definitions[0]}
+	if err := db.Raw("SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", schema, view).Scan(&definition.Columns).Error; err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
@@ -0,0 +1,103 @@
+package oracle
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// EstimateRowCount reads the row count of the optimizer statistics, which is only as
+// recent as the last time the table was analyzed.
+func (p *OraclePlugin) EstimateRowCount(config *engine.PluginConfig, schema string, storageUnit string) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count sql.NullInt64
+	err = db.QueryRow("SELECT NUM_ROWS FROM ALL_TABLES WHERE OWNER = :1 AND TABLE_NAME = :2", schema, storageUnit).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64, nil
+}
+
+func (p *OraclePlugin) CountRows(config *engine.PluginConfig, schema string, storageUnit string, where string) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	var count int64
+	if !config.ReadOnly {
+		if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+			return 0, err
+		}
+		return count, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (p *OraclePlugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// ALL_TAB_MODIFICATIONS counts the changes made since the statistics were gathered,
+	// and is flushed from memory every few minutes.
+	rows, err := db.Query(`
+		SELECT t.TABLE_NAME, t.NUM_ROWS, t.LAST_ANALYZED, m.INSERTS + m.UPDATES + m.DELETES
+		FROM ALL_TABLES t
+		LEFT JOIN ALL_TAB_MODIFICATIONS m ON m.TABLE_OWNER = t.OWNER AND m.TABLE_NAME = t.TABLE_NAME AND m.PARTITION_NAME IS NULL
+		WHERE t.OWNER = :1
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName string
+		var rowCount, modifiedRows sql.NullInt64
+		var lastAnalyzed sql.NullTime
+		if err := rows.Scan(&tableName, &rowCount, &lastAnalyzed, &modifiedRows); err != nil {
+			return nil, err
+		}
+		records := []engine.Record{}
+		if rowCount.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", rowCount.Int64)})
+		}
+		if modifiedRows.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_ModifiedSinceAnalyze, Value: fmt.Sprintf("%d", modifiedRows.Int64)})
+		}
+		if lastAnalyzed.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastAnalyzed, Value: lastAnalyzed.Time.Format(time.RFC3339)})
+		}
+		stats[tableName] = records
+	}
+	return stats, rows.Err()
+}
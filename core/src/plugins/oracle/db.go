@@ -0,0 +1,26 @@
+package oracle
+
+import (
+	"database/sql"
+
+	"github.com/clidey/whodb/core/src/engine"
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+const AdvancedKey_SID = "SID"
+
+// DB connects to the service named as database, or to the instance given by the SID
+// option on servers only reachable that way.
+func DB(config *engine.PluginConfig) (*sql.DB, error) {
+	host, port, err := engine.ResolveAddress(config.Credentials, 1521)
+	if err != nil {
+		return nil, err
+	}
+	service := config.Credentials.Database
+	var options map[string]string
+	if sid := config.Credentials.GetAdvanced(AdvancedKey_SID, ""); len(sid) > 0 {
+		service = ""
+		options = map[string]string{"SID": sid}
+	}
+	return sql.Open("oracle", go_ora.BuildUrl(host, port, service, config.Credentials.Username, config.Credentials.Password, options))
+}
@@ -0,0 +1,37 @@
+package oracle
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetDDL returns the statement creating a table, view or synonym, as generated by
+// DBMS_METADATA.
+func (p *OraclePlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	db, err := DB(config)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var objectType string
+	err = db.QueryRow(`
+		SELECT OBJECT_TYPE FROM ALL_OBJECTS
+		WHERE OWNER = :1 AND OBJECT_NAME = :2 AND OBJECT_TYPE IN ('TABLE', 'VIEW', 'SYNONYM')
+	`, schema, storageUnit).Scan(&objectType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", engine.ErrStorageUnitNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var ddl string
+	if err := db.QueryRow("SELECT DBMS_METADATA.GET_DDL(:1, :2, :3) FROM DUAL", objectType, storageUnit, schema).Scan(&ddl); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(ddl), nil
+}
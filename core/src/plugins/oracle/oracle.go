@@ -0,0 +1,444 @@
+package oracle
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+type OraclePlugin struct{}
+
+func (p *OraclePlugin) IsAvailable(config *engine.PluginConfig) bool {
+	db, err := DB(config)
+	if err != nil {
+		return false
+	}
+	defer db.Close()
+	return db.Ping() == nil
+}
+
+func (p *OraclePlugin) GetDatabases() ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// GetSchema lists the users, which are the schemas of Oracle.
+func (p *OraclePlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT USERNAME FROM ALL_USERS ORDER BY USERNAME")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemaNames := []string{}
+	for rows.Next() {
+		var schemaName string
+		if err := rows.Scan(&schemaName); err != nil {
+			return nil, err
+		}
+		schemaNames = append(schemaNames, schemaName)
+	}
+	return schemaNames, rows.Err()
+}
+
+// GetStorageUnits lists the tables, views and synonyms of a schema. Synonyms show the
+// columns of the object they stand for.
+func (p *OraclePlugin) GetStorageUnits(config *engine.PluginConfig, schema string) ([]engine.StorageUnit, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Tables in the recycle bin are still listed as objects, under a BIN$ name.
+	rows, err := db.Query(`
+		SELECT o.OBJECT_NAME, o.OBJECT_TYPE, t.NUM_ROWS, s.TABLE_OWNER, s.TABLE_NAME
+		FROM ALL_OBJECTS o
+		LEFT JOIN ALL_TABLES t ON t.OWNER = o.OWNER AND t.TABLE_NAME = o.OBJECT_NAME
+		LEFT JOIN ALL_SYNONYMS s ON s.OWNER = o.OWNER AND s.SYNONYM_NAME = o.OBJECT_NAME
+		WHERE o.OWNER = :1 AND o.OBJECT_TYPE IN ('TABLE', 'VIEW', 'SYNONYM') AND o.OBJECT_NAME NOT LIKE 'BIN$%'
+		ORDER BY o.OBJECT_NAME
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allTablesWithColumns, err := getTableSchema(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	storageUnits := []engine.StorageUnit{}
+	for rows.Next() {
+		var objectName, objectType string
+		var rowCount sql.NullInt64
+		var synonymOwner, synonymTable sql.NullString
+		if err := rows.Scan(&objectName, &objectType, &rowCount, &synonymOwner, &synonymTable); err != nil {
+			return nil, err
+		}
+		attributes := []engine.Record{
+			{Key: "Table Type", Value: objectType},
+		}
+		if rowCount.Valid {
+			attributes = append(attributes, engine.Record{Key: "Count", Value: fmt.Sprintf("%d", rowCount.Int64)})
+		}
+		if synonymTable.Valid {
+			attributes = append(attributes, engine.Record{Key: "Synonym For", Value: fmt.Sprintf("%v.%v", synonymOwner.String, synonymTable.String)})
+		}
+		attributes = append(attributes, allTablesWithColumns[objectName]...)
+		storageUnits = append(storageUnits, engine.StorageUnit{
+			Name:       objectName,
+			Attributes: attributes,
+		})
+	}
+	return storageUnits, rows.Err()
+}
+
+// getTableSchema returns the columns of every table, view and synonym with their declared
+// types.
+func getTableSchema(db *sql.DB, schema string) (map[string][]engine.Record, error) {
+	rows, err := db.Query(`
+		SELECT c.TABLE_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.DATA_LENGTH, c.CHAR_LENGTH, c.CHAR_USED, c.DATA_PRECISION, c.DATA_SCALE, c.COLUMN_ID
+		FROM ALL_TAB_COLUMNS c
+		WHERE c.OWNER = :1
+		UNION ALL
+		SELECT s.SYNONYM_NAME, c.COLUMN_NAME, c.DATA_TYPE, c.DATA_LENGTH, c.CHAR_LENGTH, c.CHAR_USED, c.DATA_PRECISION, c.DATA_SCALE, c.COLUMN_ID
+		FROM ALL_SYNONYMS s
+		JOIN ALL_TAB_COLUMNS c ON c.OWNER = s.TABLE_OWNER AND c.TABLE_NAME = s.TABLE_NAME
+		WHERE s.OWNER = :2
+		ORDER BY 1, 9
+	`, schema, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tableColumnsMap := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName, columnName, dataType string
+		var length, charLength, precision, scale, columnID sql.NullInt64
+		var charUsed sql.NullString
+		if err := rows.Scan(&tableName, &columnName, &dataType, &length, &charLength, &charUsed, &precision, &scale, &columnID); err != nil {
+			return nil, err
+		}
+		tableColumnsMap[tableName] = append(tableColumnsMap[tableName], engine.Record{
+			Key:   columnName,
+			Value: columnType(dataType, length, charLength, charUsed, precision, scale),
+		})
+	}
+	return tableColumnsMap, rows.Err()
+}
+
+// columnType writes a type the way it is declared, e.g. NUMBER(10,2) or VARCHAR2(100 CHAR),
+// as ALL_TAB_COLUMNS keeps the length, precision and scale apart. Types such as DATE, CLOB
+// or TIMESTAMP(6) are already complete.
+func columnType(dataType string, length sql.NullInt64, charLength sql.NullInt64, charUsed sql.NullString, precision sql.NullInt64, scale sql.NullInt64) string {
+	switch dataType {
+	case "NUMBER":
+		switch {
+		case precision.Valid && scale.Valid && scale.Int64 != 0:
+			return fmt.Sprintf("NUMBER(%d,%d)", precision.Int64, scale.Int64)
+		case precision.Valid:
+			return fmt.Sprintf("NUMBER(%d)", precision.Int64)
+		case scale.Valid && scale.Int64 == 0:
+			// NUMBER(*,0), which is how INTEGER is stored.
+			return "INTEGER"
+		}
+	case "FLOAT":
+		if precision.Valid {
+			return fmt.Sprintf("FLOAT(%d)", precision.Int64)
+		}
+	case "VARCHAR2", "CHAR":
+		if charUsed.String == "C" {
+			return fmt.Sprintf("%v(%d CHAR)", dataType, charLength.Int64)
+		}
+		return fmt.Sprintf("%v(%d BYTE)", dataType, length.Int64)
+	case "NVARCHAR2", "NCHAR":
+		return fmt.Sprintf("%v(%d)", dataType, charLength.Int64)
+	case "RAW":
+		return fmt.Sprintf("RAW(%d)", length.Int64)
+	}
+	return dataType
+}
+
+func (p *OraclePlugin) UpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, values map[string]string) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// GetRows pages with OFFSET ... FETCH, which Oracle added in 12c, and with ROWNUM on older
+// servers.
+func (p *OraclePlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+	query := fmt.Sprintf("SELECT * FROM %v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	query += orderBy
+
+	offsetFetch, err := supportsOffsetFetch(config)
+	if err != nil {
+		return nil, err
+	}
+	if offsetFetch {
+		return p.executeRawSQL(config, fmt.Sprintf("%v OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY", query), pageOffset, pageSize)
+	}
+	query = fmt.Sprintf(`SELECT * FROM (SELECT page.*, ROWNUM AS %v FROM (%v) page WHERE ROWNUM <= :1) WHERE %v > :2`, rowNumberColumn, query, rowNumberColumn)
+	result, err := p.executeRawSQL(config, query, pageOffset+pageSize, pageOffset)
+	if err != nil {
+		return nil, err
+	}
+	dropLastColumn(result)
+	return result, nil
+}
+
+// rowNumberColumn numbers the rows of a page read with ROWNUM, and is removed from the result.
+const rowNumberColumn = `"WHODB_ROWNUM"`
+
+func dropLastColumn(result *engine.GetRowsResult) {
+	if len(result.Columns) == 0 {
+		return
+	}
+	result.Columns = result.Columns[:len(result.Columns)-1]
+	for i := range result.Rows {
+		result.Rows[i] = result.Rows[i][:len(result.Rows[i])-1]
+		result.Nulls[i] = result.Nulls[i][:len(result.Nulls[i])-1]
+	}
+}
+
+func (p *OraclePlugin) executeRawSQL(config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	var rows *sql.Rows
+	if config.ReadOnly {
+		// go-ora does not take read-only transaction options, so the transaction is set
+		// read-only by its first statement.
+		var tx *sql.Tx
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		if _, err = tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+			return nil, err
+		}
+		rows, err = tx.QueryContext(ctx, query, params...)
+	} else {
+		rows, err = db.QueryContext(ctx, query, params...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &engine.GetRowsResult{
+		// Rows are not edited from the table view yet.
+		DisableUpdate: true,
+	}
+	for _, columnType := range columnTypes {
+		result.Columns = append(result.Columns, engine.Column{Name: columnType.Name(), Type: columnType.DatabaseTypeName()})
+	}
+
+	guard := config.ResultGuard()
+	for rows.Next() {
+		if guard.Limit(result) {
+			break
+		}
+
+		columnPointers := make([]interface{}, len(columnTypes))
+		for i := range columnTypes {
+			columnPointers[i] = new(sql.NullString)
+		}
+		if err := rows.Scan(columnPointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columnTypes))
+		nulls := make([]bool, len(columnTypes))
+		for i, colPtr := range columnPointers {
+			val := colPtr.(*sql.NullString)
+			if val.Valid {
+				row[i] = val.String
+			} else {
+				nulls[i] = true
+			}
+		}
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, err
+		}
+	}
+	return result, rows.Err()
+}
+
+func (p *OraclePlugin) GetGraph(config *engine.PluginConfig, schema string) ([]engine.GraphUnit, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT DISTINCT r.TABLE_NAME, c.TABLE_NAME
+		FROM ALL_CONSTRAINTS c
+		JOIN ALL_CONSTRAINTS r ON r.OWNER = c.R_OWNER AND r.CONSTRAINT_NAME = c.R_CONSTRAINT_NAME
+		WHERE c.CONSTRAINT_TYPE = 'R' AND c.OWNER = :1 AND r.OWNER = :2
+	`, schema, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tableMap := map[string][]engine.GraphUnitRelationship{}
+	for rows.Next() {
+		var referencedTable, referencingTable string
+		if err := rows.Scan(&referencedTable, &referencingTable); err != nil {
+			return nil, err
+		}
+		tableMap[referencedTable] = append(tableMap[referencedTable], engine.GraphUnitRelationship{Name: referencingTable, RelationshipType: engine.GraphUnitRelationshipType_OneToMany})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	storageUnits, err := p.GetStorageUnits(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	tables := []engine.GraphUnit{}
+	for _, storageUnit := range storageUnits {
+		tables = append(tables, engine.GraphUnit{Unit: storageUnit, Relations: tableMap[storageUnit.Name]})
+	}
+	return tables, nil
+}
+
+func (p *OraclePlugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
+	return p.executeRawSQL(config, query)
+}
+
+func (p *OraclePlugin) SearchStorageUnits(config *engine.PluginConfig, schema string, search string, limit int) ([]engine.SearchHit, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (p *OraclePlugin) GetRowsAfter(config *engine.PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	return nil, "", errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *OraclePlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func NewOraclePlugin() *engine.Plugin {
+	return &engine.Plugin{
+		Type:            engine.DatabaseType_Oracle,
+		PluginFunctions: &OraclePlugin{},
+	}
+}
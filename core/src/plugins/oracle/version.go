@@ -0,0 +1,41 @@
+package oracle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// majorVersions caches the release of each server, which does not change while connected.
+var majorVersions sync.Map
+
+// supportsOffsetFetch reports whether the server is Oracle 12c or later, which page with
+// OFFSET ... FETCH.
+func supportsOffsetFetch(config *engine.PluginConfig) (bool, error) {
+	credentials := config.Credentials
+	key := fmt.Sprintf("%v:%v/%v/%v", credentials.Hostname, credentials.GetAdvanced(engine.AdvancedKey_Port, ""), credentials.Database, credentials.GetAdvanced(AdvancedKey_SID, ""))
+	if major, ok := majorVersions.Load(key); ok {
+		return major.(int) >= 12, nil
+	}
+
+	db, err := DB(config)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	var version string
+	err = db.QueryRow("SELECT VERSION FROM PRODUCT_COMPONENT_VERSION WHERE PRODUCT LIKE 'Oracle%' AND ROWNUM = 1").Scan(&version)
+	if err != nil {
+		return false, err
+	}
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return false, fmt.Errorf("unexpected Oracle version %q", version)
+	}
+	majorVersions.Store(key, major)
+	return major >= 12, nil
+}
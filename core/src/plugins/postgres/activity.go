@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetActivity returns the client sessions of the server, longest running transaction first,
+// and the locks they hold or wait for.
+func (p *PostgresPlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var sessions []struct {
+		ID               string     `gorm:"column:id"`
+		User             string     `gorm:"column:user_name"`
+		Database         string     `gorm:"column:database_name"`
+		Client           string     `gorm:"column:client"`
+		State            string     `gorm:"column:state"`
+		Query            string     `gorm:"column:query"`
+		TransactionStart *time.Time `gorm:"column:transaction_start"`
+		QueryStart       *time.Time `gorm:"column:query_start"`
+		BlockedBy        string     `gorm:"column:blocked_by"`
+	}
+	query := `
+		SELECT
+			pid::text AS id,
+			COALESCE(usename, '') AS user_name,
+			COALESCE(datname, '') AS database_name,
+			COALESCE(host(client_addr), '') AS client,
+			COALESCE(state, '') AS state,
+			COALESCE(query, '') AS query,
+			xact_start AS transaction_start,
+			query_start,
+			array_to_json(pg_blocking_pids(pid))::text AS blocked_by
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend' AND pid <> pg_backend_pid()
+		ORDER BY xact_start NULLS LAST, pid
+	`
+	if err := db.Raw(query).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	var locks []struct {
+		SessionID string `gorm:"column:session_id"`
+		Type      string `gorm:"column:lock_type"`
+		Object    string `gorm:"column:object"`
+		Mode      string `gorm:"column:mode"`
+		Granted   bool   `gorm:"column:granted"`
+	}
+	query = `
+		SELECT
+			l.pid::text AS session_id,
+			l.locktype AS lock_type,
+			COALESCE(n.nspname || '.' || c.relname, '') AS object,
+			l.mode,
+			l.granted
+		FROM pg_locks l
+		LEFT JOIN pg_class c ON c.oid = l.relation
+		LEFT JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE l.pid <> pg_backend_pid() AND l.locktype <> 'virtualxid'
+		ORDER BY l.granted, l.pid
+	`
+	if err := db.Raw(query).Scan(&locks).Error; err != nil {
+		return nil, err
+	}
+
+	activity := &engine.Activity{Sessions: []engine.Session{}, Locks: []engine.Lock{}}
+	for _, session := range sessions {
+		blockedBy := []int{}
+		if err := json.Unmarshal([]byte(session.BlockedBy), &blockedBy); err != nil {
+			return nil, err
+		}
+		result := engine.Session{
+			ID:        session.ID,
+			User:      session.User,
+			Database:  session.Database,
+			Client:    session.Client,
+			State:     session.State,
+			Query:     session.Query,
+			BlockedBy: []string{},
+		}
+		if session.TransactionStart != nil {
+			result.TransactionStart = *session.TransactionStart
+		}
+		if session.QueryStart != nil {
+			result.QueryStart = *session.QueryStart
+		}
+		for _, pid := range blockedBy {
+			result.BlockedBy = append(result.BlockedBy, strconv.Itoa(pid))
+		}
+		activity.Sessions = append(activity.Sessions, result)
+	}
+	for _, lock := range locks {
+		activity.Locks = append(activity.Locks, engine.Lock{
+			SessionID: lock.SessionID,
+			Type:      lock.Type,
+			Object:    lock.Object,
+			Mode:      lock.Mode,
+			Granted:   lock.Granted,
+		})
+	}
+	return activity, nil
+}
+
+// KillSession terminates a session, rolling back its open transaction.
+func (p *PostgresPlugin) KillSession(config *engine.PluginConfig, id string) error {
+	pid, err := strconv.Atoi(id)
+	if err != nil {
+		return engine.ErrSessionNotFound
+	}
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+
+	terminated := false
+	if err := db.Raw("SELECT pg_terminate_backend(?)", pid).Scan(&terminated).Error; err != nil {
+		return err
+	}
+	if !terminated {
+		return engine.ErrSessionNotFound
+	}
+	return nil
+}
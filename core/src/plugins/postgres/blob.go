@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+	"gorm.io/gorm"
+)
+
+func (p *PostgresPlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, schema, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.ReadBlob(db, queries, w)
+}
+
+func (p *PostgresPlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, schema, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.WriteBlob(db, queries, r)
+}
+
+// blobQueries only allows bytea columns; large objects live outside the table and are left
+// to the SQL editor.
+func blobQueries(db *gorm.DB, schema string, storageUnit string, column string, key map[string]string) (common.BlobQueries, error) {
+	pkColumns, err := getPrimaryKeyColumns(db, schema, storageUnit)
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+	columnTypes, err := getColumnTypes(db, schema, storageUnit)
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+	columnType, ok := columnTypes[column]
+	if !ok {
+		return common.BlobQueries{}, fmt.Errorf("column '%s' does not exist in table %s", column, storageUnit)
+	}
+	if columnType != "bytea" {
+		return common.BlobQueries{}, fmt.Errorf("column '%s' is not a bytea column", column)
+	}
+	condition, args, err := common.RowCondition(pkColumns, key, quoteIdentifier, func(column string, value string) (interface{}, error) {
+		return convertStringValue(value, columnTypes[column])
+	})
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+
+	table := fmt.Sprintf("%v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	value := quoteIdentifier(column)
+	return common.BlobQueries{
+		Length: fmt.Sprintf("SELECT octet_length(%v) AS length FROM %v WHERE %v", value, table, condition),
+		Read:   fmt.Sprintf("SELECT substring(%v FROM ?::integer FOR ?::integer) FROM %v WHERE %v", value, table, condition),
+		Clear:  fmt.Sprintf("UPDATE %v SET %v = ''::bytea WHERE %v", table, value, condition),
+		Append: fmt.Sprintf("UPDATE %v SET %v = %v || ?::bytea WHERE %v", table, value, value, condition),
+		Args:   args,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+func (p *PostgresPlugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var columns []struct {
+		ColumnName string `gorm:"column:column_name"`
+		DataType   string `gorm:"column:data_type"`
+		Nullable   bool   `gorm:"column:nullable"`
+		MaxLength  int    `gorm:"column:max_length"`
+		EnumValues string `gorm:"column:enum_values"`
+	}
+	query := `
+		SELECT
+			c.column_name,
+			c.data_type,
+			c.is_nullable = 'YES' AS nullable,
+			COALESCE(c.character_maximum_length, 0) AS max_length,
+			COALESCE((
+				SELECT json_agg(e.enumlabel ORDER BY e.enumsortorder)
+				FROM pg_type t
+				JOIN pg_namespace n ON n.oid = t.typnamespace
+				JOIN pg_enum e ON e.enumtypid = t.oid
+				WHERE t.typname = c.udt_name AND n.nspname = c.udt_schema
+			)::text, '[]') AS enum_values
+		FROM information_schema.columns c
+		WHERE c.table_schema = ? AND c.table_name = ?
+		ORDER BY c.ordinal_position
+	`
+	if err := db.Raw(query, schema, storageUnit).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+
+	constraints := []engine.ColumnConstraint{}
+	for _, column := range columns {
+		constraint := engine.ColumnConstraint{
+			Name:      column.ColumnName,
+			Type:      column.DataType,
+			Nullable:  column.Nullable,
+			MaxLength: column.MaxLength,
+		}
+		if err := json.Unmarshal([]byte(column.EnumValues), &constraint.Values); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, nil
+}
@@ -2,17 +2,91 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/clidey/whodb/core/src/common"
 	"github.com/clidey/whodb/core/src/engine"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func DB(config *engine.PluginConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=5432", config.Credentials.Hostname, config.Credentials.Username, config.Credentials.Password, config.Credentials.Database)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	host, port, err := engine.ResolveAddress(config.Credentials, 5432)
+	if err != nil {
+		return nil, err
+	}
+	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v", quoteDSNValue(host), quoteDSNValue(config.Credentials.Username),
+		quoteDSNValue(config.Credentials.Password), quoteDSNValue(config.Credentials.Database), port)
+	tlsOptions, err := engine.GetTLSOptions(config.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	dsn += tlsParameters(tlsOptions)
+	pgxConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if host != config.Credentials.Hostname {
+		// Through an SSH tunnel, the certificate names the database host, not the local end.
+		setTLSServerName(pgxConfig, config.Credentials.Hostname)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: stdlib.OpenDB(*pgxConfig)}), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
+
+// ReadDB connects to the read replica when the connection has one, and to the primary otherwise.
+func ReadDB(config *engine.PluginConfig) (*gorm.DB, error) {
+	return DB(engine.ReadConfig(config))
+}
+
+// QueryDB returns the connection a query should run on: the read replica for queries that
+// only read, the primary for everything else.
+func QueryDB(config *engine.PluginConfig, query string) (*gorm.DB, error) {
+	if common.IsReadOnlyQuery(query) {
+		return ReadDB(config)
+	}
+	return DB(config)
+}
+
+// setTLSServerName sets the host name the server's certificate is verified against, in the
+// TLS config of the connection and of its fallbacks.
+func setTLSServerName(config *pgx.ConnConfig, serverName string) {
+	if config.TLSConfig != nil {
+		config.TLSConfig.ServerName = serverName
+	}
+	for _, fallback := range config.Fallbacks {
+		if fallback.TLSConfig != nil {
+			fallback.TLSConfig.ServerName = serverName
+		}
+	}
+}
+
+// tlsParameters returns the sslmode, sslrootcert, sslcert and sslkey parameters of the DSN.
+// The CA is left out in require mode, where the driver would verify the server with it.
+func tlsParameters(options engine.TLSOptions) string {
+	parameters := []string{}
+	if len(options.Mode) > 0 {
+		parameters = append(parameters, "sslmode="+quoteDSNValue(options.Mode))
+	}
+	if len(options.CAPath) > 0 && (options.Mode == engine.TLSMode_VerifyCA || options.Mode == engine.TLSMode_VerifyFull) {
+		parameters = append(parameters, "sslrootcert="+quoteDSNValue(options.CAPath))
+	}
+	if len(options.CertPath) > 0 {
+		parameters = append(parameters, "sslcert="+quoteDSNValue(options.CertPath), "sslkey="+quoteDSNValue(options.KeyPath))
+	}
+	if len(parameters) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parameters, " ")
+}
+
+// quoteDSNValue quotes a value of a keyword/value connection string, so that it may contain
+// spaces and quotes without adding parameters.
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"gorm.io/gorm"
+)
+
+// GetDDL returns the statements creating a table, view or materialized view. Postgres has
+// no SHOW CREATE, so tables are put together from the catalog: columns, constraints and
+// the indexes not backing a constraint.
+func (p *PostgresPlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return "", err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return "", err
+	}
+	defer sqlDb.Close()
+
+	var relations []struct {
+		OID  uint32 `gorm:"column:oid"`
+		Kind string `gorm:"column:kind"`
+	}
+	query := `
+		SELECT c.oid, c.relkind::text AS kind
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ? AND c.relname = ? AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+	`
+	if err := db.Raw(query, schema, storageUnit).Scan(&relations).Error; err != nil {
+		return "", err
+	}
+	if len(relations) == 0 {
+		return "", engine.ErrStorageUnitNotFound
+	}
+	name := fmt.Sprintf("%v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	relation := relations[0]
+
+	switch relation.Kind {
+	case "v", "m":
+		var definition string
+		if err := db.Raw("SELECT pg_get_viewdef(?::oid, true)", relation.OID).Scan(&definition).Error; err != nil {
+			return "", err
+		}
+		definition = strings.TrimSuffix(strings.TrimSpace(definition), ";")
+		if relation.Kind == "v" {
+			return fmt.Sprintf("CREATE VIEW %v AS\n%v;\n", name, definition), nil
+		}
+		indexes, err := getIndexDefinitions(db, relation.OID)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(append([]string{fmt.Sprintf("CREATE MATERIALIZED VIEW %v AS\n%v", name, definition)}, indexes...), ";\n\n") + ";\n", nil
+	}
+
+	var columns []struct {
+		Name      string  `gorm:"column:name"`
+		Type      string  `gorm:"column:type"`
+		NotNull   bool    `gorm:"column:not_null"`
+		Default   *string `gorm:"column:default_value"`
+		Identity  string  `gorm:"column:identity"`
+		Generated string  `gorm:"column:generated"`
+		Collation *string `gorm:"column:collation"`
+	}
+	query = `
+		SELECT
+			a.attname AS name,
+			format_type(a.atttypid, a.atttypmod) AS type,
+			a.attnotnull AS not_null,
+			pg_get_expr(d.adbin, d.adrelid) AS default_value,
+			a.attidentity::text AS identity,
+			a.attgenerated::text AS generated,
+			CASE WHEN a.attcollation <> t.typcollation THEN quote_ident(co.collname) END AS collation
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		WHERE a.attrelid = ?::oid AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`
+	if err := db.Raw(query, relation.OID).Scan(&columns).Error; err != nil {
+		return "", err
+	}
+	var constraints []struct {
+		Name       string `gorm:"column:name"`
+		Definition string `gorm:"column:definition"`
+	}
+	query = `
+		SELECT conname AS name, pg_get_constraintdef(oid, true) AS definition
+		FROM pg_constraint
+		WHERE conrelid = ?::oid AND contype IN ('p', 'u', 'f', 'c', 'x')
+		ORDER BY CASE contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'c' THEN 2 WHEN 'x' THEN 3 ELSE 4 END, conname
+	`
+	if err := db.Raw(query, relation.OID).Scan(&constraints).Error; err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	for _, column := range columns {
+		line := fmt.Sprintf("%v %v", quoteIdentifier(column.Name), column.Type)
+		if column.Collation != nil {
+			line += " COLLATE " + *column.Collation
+		}
+		switch {
+		case column.Identity == "a":
+			line += " GENERATED ALWAYS AS IDENTITY"
+		case column.Identity == "d":
+			line += " GENERATED BY DEFAULT AS IDENTITY"
+		case column.Generated == "s" && column.Default != nil:
+			line += fmt.Sprintf(" GENERATED ALWAYS AS (%v) STORED", *column.Default)
+		case column.Default != nil:
+			line += " DEFAULT " + *column.Default
+		}
+		if column.NotNull {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	for _, constraint := range constraints {
+		lines = append(lines, fmt.Sprintf("CONSTRAINT %v %v", quoteIdentifier(constraint.Name), constraint.Definition))
+	}
+
+	create := "CREATE TABLE"
+	if relation.Kind == "f" {
+		create = "CREATE FOREIGN TABLE"
+	}
+	statement := fmt.Sprintf("%v %v (\n    %v\n)", create, name, strings.Join(lines, ",\n    "))
+	if relation.Kind == "p" {
+		var partitionKey string
+		if err := db.Raw("SELECT pg_get_partkeydef(?::oid)", relation.OID).Scan(&partitionKey).Error; err != nil {
+			return "", err
+		}
+		statement += " PARTITION BY " + partitionKey
+	}
+	indexes, err := getIndexDefinitions(db, relation.OID)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append([]string{statement}, indexes...), ";\n\n") + ";\n", nil
+}
+
+// getIndexDefinitions returns the CREATE INDEX statements of the indexes of a relation that
+// do not back one of its constraints, which create their own.
+func getIndexDefinitions(db *gorm.DB, oid uint32) ([]string, error) {
+	var indexes []string
+	query := `
+		SELECT pg_get_indexdef(i.indexrelid)
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE i.indrelid = ?::oid
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid AND con.conrelid = i.indrelid)
+		ORDER BY c.relname
+	`
+	if err := db.Raw(query, oid).Scan(&indexes).Error; err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetForeignKeys returns the foreign keys from or to the tables of the schema.
+func (p *PostgresPlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var constraints []struct {
+		Name              string `gorm:"column:name"`
+		Schema            string `gorm:"column:table_schema"`
+		Table             string `gorm:"column:table_name"`
+		Columns           string `gorm:"column:columns"`
+		ReferencedSchema  string `gorm:"column:referenced_schema"`
+		ReferencedTable   string `gorm:"column:referenced_table"`
+		ReferencedColumns string `gorm:"column:referenced_columns"`
+	}
+	query := `
+		SELECT
+			con.conname AS name,
+			ns.nspname AS table_schema,
+			cl.relname AS table_name,
+			(
+				SELECT json_agg(a.attname ORDER BY k.position)
+				FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, position)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			)::text AS columns,
+			refns.nspname AS referenced_schema,
+			refcl.relname AS referenced_table,
+			(
+				SELECT json_agg(a.attname ORDER BY k.position)
+				FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, position)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+			)::text AS referenced_columns
+		FROM pg_constraint con
+		JOIN pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		JOIN pg_class refcl ON refcl.oid = con.confrelid
+		JOIN pg_namespace refns ON refns.oid = refcl.relnamespace
+		WHERE con.contype = 'f' AND (ns.nspname = ? OR refns.nspname = ?)
+		ORDER BY ns.nspname, cl.relname, con.conname
+	`
+	if err := db.Raw(query, schema, schema).Scan(&constraints).Error; err != nil {
+		return nil, err
+	}
+
+	foreignKeys := []engine.ForeignKey{}
+	for _, constraint := range constraints {
+		foreignKey := engine.ForeignKey{
+			Name:             constraint.Name,
+			Schema:           constraint.Schema,
+			Table:            constraint.Table,
+			ReferencedSchema: constraint.ReferencedSchema,
+			ReferencedTable:  constraint.ReferencedTable,
+		}
+		if err := json.Unmarshal([]byte(constraint.Columns), &foreignKey.Columns); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(constraint.ReferencedColumns), &foreignKey.ReferencedColumns); err != nil {
+			return nil, err
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+	return foreignKeys, nil
+}
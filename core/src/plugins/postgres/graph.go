@@ -105,7 +105,7 @@ SELECT * FROM many_to_many_constraints
 `
 
 func (p *PostgresPlugin) GetGraph(config *engine.PluginConfig, schema string) ([]engine.GraphUnit, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetIndexes returns the indexes of the tables of the schema. Included columns are listed
+// after the key columns.
+func (p *PostgresPlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var rows []struct {
+		Name    string `gorm:"column:name"`
+		Table   string `gorm:"column:table_name"`
+		Columns string `gorm:"column:columns"`
+		Unique  bool   `gorm:"column:is_unique"`
+		Primary bool   `gorm:"column:is_primary"`
+	}
+	query := `
+		SELECT
+			i.relname AS name,
+			t.relname AS table_name,
+			(
+				SELECT json_agg(pg_get_indexdef(ix.indexrelid, k.position::int, true) ORDER BY k.position)
+				FROM generate_series(1, ix.indnatts) AS k(position)
+			)::text AS columns,
+			ix.indisunique AS is_unique,
+			ix.indisprimary AS is_primary
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = ?
+		ORDER BY t.relname, i.relname
+	`
+	if err := db.Raw(query, schema).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	indexes := []engine.Index{}
+	for _, row := range rows {
+		index := engine.Index{
+			Name:    row.Name,
+			Table:   row.Table,
+			Unique:  row.Unique,
+			Primary: row.Primary,
+		}
+		if err := json.Unmarshal([]byte(row.Columns), &index.Columns); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
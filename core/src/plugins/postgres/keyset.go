@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+// GetRowsAfter pages through a table with a single column primary key in key order, which
+// unlike offsets stays correct and fast however deep into the table it goes.
+func (p *PostgresPlugin) GetRowsAfter(config *engine.PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return nil, "", errors.New("invalid table name")
+	}
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, "", err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, "", err
+	}
+	pkColumns, err := getPrimaryKeyColumns(db, schema, storageUnit)
+	if err != nil || len(pkColumns) != 1 {
+		sqlDb.Close()
+		return nil, "", errors.ErrUnsupported
+	}
+	columnTypes, err := getColumnTypes(db, schema, storageUnit)
+	sqlDb.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := quoteIdentifier(pkColumns[0])
+	// Placeholders are sent untyped, so the key is cast to compare in the column's own order.
+	query, params := common.KeysetQuery(fmt.Sprintf("%v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit)), where, key,
+		fmt.Sprintf("%v > CAST(? AS %v)", key, columnTypes[pkColumns[0]]), afterKey, pageSize)
+	result, err := p.executeRawSQL(config, query, params...)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, common.LastKey(result, pkColumns[0]), nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetMaterializedViews returns the materialized views of the schema. Postgres does not keep
+// when they were last refreshed.
+func (p *PostgresPlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var views []struct {
+		Name       string `gorm:"column:name"`
+		Populated  bool   `gorm:"column:populated"`
+		Concurrent bool   `gorm:"column:concurrent"`
+	}
+	// Refreshing concurrently needs a unique index on plain columns over every row.
+	query := `
+		SELECT
+			m.matviewname AS name,
+			m.ispopulated AS populated,
+			EXISTS (
+				SELECT 1
+				FROM pg_index i
+				WHERE i.indrelid = c.oid AND i.indisunique AND i.indisvalid
+					AND i.indpred IS NULL AND i.indexprs IS NULL
+			) AS concurrent
+		FROM pg_matviews m
+		JOIN pg_namespace n ON n.nspname = m.schemaname
+		JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = m.matviewname
+		WHERE m.schemaname = ?
+		ORDER BY m.matviewname
+	`
+	if err := db.Raw(query, schema).Scan(&views).Error; err != nil {
+		return nil, err
+	}
+
+	materializedViews := []engine.MaterializedView{}
+	for _, view := range views {
+		materializedViews = append(materializedViews, engine.MaterializedView{
+			Name:       view.Name,
+			Populated:  view.Populated,
+			Concurrent: view.Concurrent,
+		})
+	}
+	return materializedViews, nil
+}
+
+// RefreshMaterializedView recomputes the rows of a materialized view, concurrently when it
+// can so that it can still be read meanwhile. It is bound by the query timeout.
+func (p *PostgresPlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	views, err := p.GetMaterializedViews(config, schema)
+	if err != nil {
+		return err
+	}
+	concurrently := ""
+	found := false
+	for _, materializedView := range views {
+		if materializedView.Name == view {
+			found = true
+			// A view that was never populated has no rows to compare against.
+			if materializedView.Concurrent && materializedView.Populated {
+				concurrently = "CONCURRENTLY "
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("materialized view %v.%v not found", schema, view)
+	}
+
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	query := fmt.Sprintf("REFRESH MATERIALIZED VIEW %v%v.%v", concurrently, quoteIdentifier(schema), quoteIdentifier(view))
+	return db.WithContext(ctx).Exec(query).Error
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"github.com/clidey/whodb/core/src/plugins/common"
@@ -31,7 +32,7 @@ func (p *PostgresPlugin) GetDatabases() ([]string, error) {
 }
 
 func (p *PostgresPlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +55,7 @@ func (p *PostgresPlugin) GetSchema(config *engine.PluginConfig) ([]string, error
 }
 
 func (p *PostgresPlugin) GetStorageUnits(config *engine.PluginConfig, schema string) ([]engine.StorageUnit, error) {
-	db, err := DB(config)
+	db, err := ReadDB(config)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +141,7 @@ func getTableSchema(db *gorm.DB, schema string) (map[string][]engine.Record, err
 	return tableColumnsMap, nil
 }
 
-func (p *PostgresPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+func (p *PostgresPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
 	if !common.IsValidSQLTableName(storageUnit) {
 		return nil, errors.New("invalid table name")
 	}
@@ -149,21 +150,40 @@ func (p *PostgresPlugin) GetRows(config *engine.PluginConfig, schema string, sto
 	if len(where) > 0 {
 		query = fmt.Sprintf("%v WHERE %v", query, where)
 	}
-	query = fmt.Sprintf("%v LIMIT ? OFFSET ?", query)
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf("%v%v LIMIT ? OFFSET ?", query, orderBy)
 	return p.executeRawSQL(config, query, pageSize, pageOffset)
 }
 
 func (p *PostgresPlugin) executeRawSQL(config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
-	db, err := DB(config)
+	db, err := QueryDB(config, query)
 	if err != nil {
 		return nil, err
 	}
+	return p.executeSQL(db, config, query, params...)
+}
 
+// executeSQL runs the query on db, which it closes afterwards.
+func (p *PostgresPlugin) executeSQL(db *gorm.DB, config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
 	sqlDb, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
 	defer sqlDb.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	db = db.WithContext(ctx)
+	if config.ReadOnly {
+		db = db.Begin(&sql.TxOptions{ReadOnly: true})
+		if db.Error != nil {
+			return nil, db.Error
+		}
+		defer db.Rollback()
+	}
 	rows, err := db.Raw(query, params...).Rows()
 	if err != nil {
 		return nil, err
@@ -190,9 +210,15 @@ func (p *PostgresPlugin) executeRawSQL(config *engine.PluginConfig, query string
 		}
 	}
 
+	guard := config.ResultGuard()
 	for rows.Next() {
+		if guard.Limit(result) {
+			break
+		}
+
 		columnPointers := make([]interface{}, len(columns))
 		row := make([]string, len(columns))
+		nulls := make([]bool, len(columns))
 		for i := range columns {
 			columnPointers[i] = new(sql.NullString)
 		}
@@ -207,19 +233,38 @@ func (p *PostgresPlugin) executeRawSQL(config *engine.PluginConfig, query string
 				row[i] = val.String
 			} else {
 				row[i] = ""
+				nulls[i] = true
 			}
 		}
 
-		result.Rows = append(result.Rows, row)
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, err
+		}
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 func (p *PostgresPlugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
 	return p.executeRawSQL(config, query)
 }
 
+func (p *PostgresPlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *PostgresPlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *PostgresPlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *PostgresPlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
 func NewPostgresPlugin() *engine.Plugin {
 	return &engine.Plugin{
 		Type:            engine.DatabaseType_Postgres,
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+const routinesQuery = `
+	SELECT specific_name, routine_name, routine_type, COALESCE(type_udt_name, data_type, ''), COALESCE(routine_definition, '')
+	FROM information_schema.routines
+	WHERE routine_schema = ? AND routine_type IN ('FUNCTION', 'PROCEDURE')
+	ORDER BY routine_name, specific_name
+`
+
+const routineParametersQuery = `
+	SELECT specific_name, COALESCE(parameter_name, ''), udt_name, COALESCE(parameter_mode, 'IN')
+	FROM information_schema.parameters
+	WHERE specific_schema = ?
+	ORDER BY specific_name, ordinal_position
+`
+
+func (p *PostgresPlugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	return common.GetRoutines(db, routinesQuery, routineParametersQuery, schema)
+}
+
+func (p *PostgresPlugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routineName string, arguments []string) (*engine.GetRowsResult, error) {
+	routines, err := p.GetRoutines(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	routine, err := common.FindRoutine(routines, routineName, len(arguments))
+	if err != nil {
+		return nil, err
+	}
+
+	// Arguments are cast to their declared types, which also selects the right overload.
+	// Procedures still expect a placeholder for their OUT parameters.
+	placeholders := []string{}
+	params := []interface{}{}
+	for _, argument := range routine.Arguments {
+		if strings.EqualFold(argument.Mode, "OUT") {
+			if routine.Type == engine.RoutineType_Procedure {
+				placeholders = append(placeholders, fmt.Sprintf("NULL::%v", quoteIdentifier(argument.Type)))
+			}
+			continue
+		}
+		placeholders = append(placeholders, fmt.Sprintf("?::%v", quoteIdentifier(argument.Type)))
+		params = append(params, arguments[len(params)])
+	}
+
+	call := fmt.Sprintf("%v.%v(%v)", quoteIdentifier(schema), quoteIdentifier(routine.Name), strings.Join(placeholders, ", "))
+	query := "SELECT * FROM " + call
+	if routine.Type == engine.RoutineType_Procedure {
+		query = "CALL " + call
+	}
+	// Functions may write too, so routines always run on the primary.
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	return p.executeSQL(db, config, query, params...)
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+var textTypes = []string{"text", "character varying", "character", "citext", "json", "jsonb", "uuid", "USER-DEFINED"}
+
+func (p *PostgresPlugin) SearchStorageUnits(config *engine.PluginConfig, schema string, search string, limit int) ([]engine.SearchHit, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	tables, err := getTableSchema(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.SearchTables(db, tables, isTextType, func(table string, columns []string) string {
+		conditions := []string{}
+		for _, column := range columns {
+			conditions = append(conditions, fmt.Sprintf("%v::text ILIKE ? ESCAPE '!'", quoteIdentifier(column)))
+		}
+		return fmt.Sprintf("SELECT * FROM %v.%v WHERE %v LIMIT ?", quoteIdentifier(schema), quoteIdentifier(table), strings.Join(conditions, " OR "))
+	}, search, limit)
+}
+
+func isTextType(dataType string) bool {
+	for _, textType := range textTypes {
+		if dataType == textType {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
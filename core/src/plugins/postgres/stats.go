@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+func (p *PostgresPlugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	// Postgres does not track modification times, but the statistics collector counts
+	// changed rows and knows when the table was last (auto)vacuumed or analyzed.
+	rows, err := db.Raw(`
+		SELECT
+			relname,
+			n_live_tup,
+			n_mod_since_analyze,
+			GREATEST(last_analyze, last_autoanalyze) AS last_analyzed
+		FROM pg_stat_user_tables
+		WHERE schemaname = ?
+	`, schema).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName string
+		var liveRows, modifiedRows int64
+		var lastAnalyzed sql.NullTime
+		if err := rows.Scan(&tableName, &liveRows, &modifiedRows, &lastAnalyzed); err != nil {
+			return nil, err
+		}
+		records := []engine.Record{
+			{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", liveRows)},
+			{Key: engine.StatsKey_ModifiedSinceAnalyze, Value: fmt.Sprintf("%d", modifiedRows)},
+		}
+		if lastAnalyzed.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastAnalyzed, Value: lastAnalyzed.Time.Format(time.RFC3339)})
+		}
+		stats[tableName] = records
+	}
+	return stats, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/clidey/whodb/core/src/common"
@@ -72,6 +73,53 @@ func (p *PostgresPlugin) UpdateStorageUnit(config *engine.PluginConfig, schema s
 	return true, nil
 }
 
+func (p *PostgresPlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error) {
+	if len(strings.TrimSpace(where)) == 0 {
+		return 0, errors.New("a where condition is required for batch updates")
+	}
+	if len(values) == 0 {
+		return 0, errors.New("no values to update")
+	}
+
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	columnTypes, err := getColumnTypes(db, schema, storageUnit)
+	if err != nil {
+		return 0, err
+	}
+
+	convertedValues := make(map[string]interface{})
+	for column, strValue := range values {
+		columnType, exists := columnTypes[column]
+		if !exists {
+			return 0, fmt.Errorf("column '%s' does not exist in table %s", column, storageUnit)
+		}
+
+		convertedValue, err := convertStringValue(strValue, columnType)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert value for column '%s': %v", column, err)
+		}
+		convertedValues[column] = convertedValue
+	}
+
+	tableName := fmt.Sprintf("%s.%s", schema, storageUnit)
+	result := db.Table(tableName).Where(where).Updates(convertedValues)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 func getPrimaryKeyColumns(db *gorm.DB, schema string, tableName string) ([]string, error) {
 	var primaryKeys []string
 	query := `
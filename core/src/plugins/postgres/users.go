@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+// GetDatabaseUsers returns the roles of the server, leaving out the predefined pg_ roles, with
+// the roles they are members of and the privileges granted to them in the current database.
+// Owners hold every privilege on their objects without it being granted, so those are not listed.
+func (p *PostgresPlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var roles []struct {
+		Name      string `gorm:"column:rolname"`
+		CanLogin  bool   `gorm:"column:rolcanlogin"`
+		Superuser bool   `gorm:"column:rolsuper"`
+	}
+	if err := db.Raw("SELECT rolname, rolcanlogin, rolsuper FROM pg_roles WHERE rolname NOT LIKE 'pg\\_%' ORDER BY rolname").Scan(&roles).Error; err != nil {
+		return nil, err
+	}
+	var memberships []struct {
+		Member string `gorm:"column:member"`
+		Role   string `gorm:"column:role_name"`
+	}
+	query := `
+		SELECT r.rolname AS member, g.rolname AS role_name
+		FROM pg_auth_members m
+		JOIN pg_roles r ON r.oid = m.member
+		JOIN pg_roles g ON g.oid = m.roleid
+		ORDER BY g.rolname
+	`
+	if err := db.Raw(query).Scan(&memberships).Error; err != nil {
+		return nil, err
+	}
+	var grants []struct {
+		Grantee   string `gorm:"column:grantee"`
+		Privilege string `gorm:"column:privilege"`
+		Schema    string `gorm:"column:schema_name"`
+		Object    string `gorm:"column:object"`
+		Grantable bool   `gorm:"column:grantable"`
+	}
+	query = `
+		SELECT g.rolname AS grantee, a.privilege_type AS privilege, '' AS schema_name, '' AS object, a.is_grantable AS grantable
+		FROM pg_database d
+		CROSS JOIN LATERAL aclexplode(d.datacl) a
+		JOIN pg_roles g ON g.oid = a.grantee
+		WHERE d.datname = current_database()
+		UNION ALL
+		SELECT g.rolname, a.privilege_type, n.nspname, '', a.is_grantable
+		FROM pg_namespace n
+		CROSS JOIN LATERAL aclexplode(n.nspacl) a
+		JOIN pg_roles g ON g.oid = a.grantee
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\_toast%'
+		UNION ALL
+		SELECT g.rolname, a.privilege_type, n.nspname, c.relname, a.is_grantable
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(c.relacl) a
+		JOIN pg_roles g ON g.oid = a.grantee
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+			-- The owner's own privileges are implied.
+			AND a.grantee <> c.relowner
+		ORDER BY 1, 3, 4, 2
+	`
+	if err := db.Raw(query).Scan(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	users := []engine.DatabaseUser{}
+	index := map[string]int{}
+	for _, role := range roles {
+		index[role.Name] = len(users)
+		users = append(users, engine.DatabaseUser{
+			Name:      role.Name,
+			CanLogin:  role.CanLogin,
+			Superuser: role.Superuser,
+			MemberOf:  []string{},
+			Grants:    []engine.Grant{},
+		})
+	}
+	for _, membership := range memberships {
+		if i, ok := index[membership.Member]; ok {
+			users[i].MemberOf = append(users[i].MemberOf, membership.Role)
+		}
+	}
+	for _, grant := range grants {
+		if i, ok := index[grant.Grantee]; ok {
+			users[i].Grants = append(users[i].Grants, engine.Grant{
+				Privilege: grant.Privilege,
+				Schema:    grant.Schema,
+				Object:    grant.Object,
+				Grantable: grant.Grantable,
+			})
+		}
+	}
+	return users, nil
+}
+
+// CreateDatabaseUser creates a role that can log in with password.
+func (p *PostgresPlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	if len(name) == 0 {
+		return errors.New("a name is required")
+	}
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+
+	query := fmt.Sprintf("CREATE ROLE %v WITH LOGIN", quoteIdentifier(name))
+	if len(password) > 0 {
+		// CREATE ROLE does not take placeholders, so the database quotes the password itself.
+		var literal string
+		if err := db.Raw("SELECT quote_literal(?)", password).Scan(&literal).Error; err != nil {
+			return err
+		}
+		query += " PASSWORD " + literal
+	}
+	return db.Exec(query).Error
+}
+
+func (p *PostgresPlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	privilege, target, err := grantStatement(config, user, grant)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("GRANT %v ON %v TO %v", privilege, target, quoteIdentifier(user))
+	if grant.Grantable {
+		query += " WITH GRANT OPTION"
+	}
+	return execGrant(config, query)
+}
+
+func (p *PostgresPlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	privilege, target, err := grantStatement(config, user, grant)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("REVOKE %v ON %v FROM %v", privilege, target, quoteIdentifier(user))
+	// Revoking with Grantable only takes back the right to grant the privilege.
+	if grant.Grantable {
+		query = fmt.Sprintf("REVOKE GRANT OPTION FOR %v ON %v FROM %v", privilege, target, quoteIdentifier(user))
+	}
+	return execGrant(config, query)
+}
+
+// grantStatement returns the privilege and the object of a GRANT or REVOKE statement.
+func grantStatement(config *engine.PluginConfig, user string, grant engine.Grant) (string, string, error) {
+	privilege := strings.ToUpper(strings.TrimSpace(grant.Privilege))
+	if !common.IsValidPrivilege(privilege) {
+		return "", "", fmt.Errorf("invalid privilege: %v", grant.Privilege)
+	}
+	if len(user) == 0 {
+		return "", "", errors.New("a user is required")
+	}
+	switch {
+	case len(grant.Schema) == 0 && len(grant.Object) == 0:
+		return privilege, "DATABASE " + quoteIdentifier(config.Credentials.Database), nil
+	case len(grant.Schema) == 0:
+		return "", "", errors.New("a schema is required to grant a privilege on a table")
+	case len(grant.Object) == 0:
+		return privilege, "SCHEMA " + quoteIdentifier(grant.Schema), nil
+	case grant.Object == "*":
+		return privilege, "ALL TABLES IN SCHEMA " + quoteIdentifier(grant.Schema), nil
+	default:
+		return privilege, fmt.Sprintf("TABLE %v.%v", quoteIdentifier(grant.Schema), quoteIdentifier(grant.Object)), nil
+	}
+}
+
+func execGrant(config *engine.PluginConfig, query string) error {
+	db, err := DB(config)
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDb.Close()
+	return db.Exec(query).Error
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetViewDefinition returns the query of a view or materialized view as Postgres prints
+// it, with every star expanded and columns qualified whenever more than one table is joined.
+func (p *PostgresPlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	db, err := ReadDB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	var views []struct {
+		Definition string `gorm:"column:definition"`
+		Columns    string `gorm:"column:columns"`
+	}
+	query := `
+		SELECT
+			pg_get_viewdef(c.oid, true) AS definition,
+			COALESCE((
+				SELECT json_agg(a.attname ORDER BY a.attnum)
+				FROM pg_attribute a
+				WHERE a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+			)::text, '[]') AS columns
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ? AND c.relname = ? AND c.relkind IN ('v', 'm')
+	`
+	if err := db.Raw(query, schema, view).Scan(&views).Error; err != nil {
+		return nil, err
+	}
+	if len(views) == 0 {
+		return nil, engine.ErrNotAView
+	}
+
+	definition := &engine.ViewDefinition{Definition: views[0].Definition}
+	if err := json.Unmarshal([]byte(views[0].Columns), &definition.Columns); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
@@ -2,7 +2,8 @@ package redis
 
 import (
 	"context"
-	"fmt"
+	"net"
+	"strconv"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"github.com/go-redis/redis/v8"
@@ -10,7 +11,11 @@ import (
 
 func DB(config *engine.PluginConfig) (*redis.Client, error) {
 	ctx := context.Background()
-	addr := fmt.Sprintf("%s:%d", config.Credentials.Hostname, 6379)
+	host, port, err := engine.ResolveAddress(config.Credentials, 6379)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: config.Credentials.Password,
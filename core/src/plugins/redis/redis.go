@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/clidey/whodb/core/src/engine"
@@ -47,9 +48,11 @@ func (p *RedisPlugin) GetStorageUnits(config *engine.PluginConfig, schema string
 
 	pipe := client.Pipeline()
 	cmds := make(map[string]*redis.StatusCmd, len(keys))
+	ttls := make(map[string]*redis.DurationCmd, len(keys))
 
 	for _, key := range keys {
 		cmds[key] = pipe.Type(ctx, key)
+		ttls[key] = pipe.TTL(ctx, key)
 	}
 
 	_, err = pipe.Exec(ctx)
@@ -124,6 +127,12 @@ func (p *RedisPlugin) GetStorageUnits(config *engine.PluginConfig, schema string
 			}
 		}
 
+		ttl, err := ttls[key].Result()
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, engine.Record{Key: "TTL", Value: formatTTL(ttl)})
+
 		storageUnits = append(storageUnits, engine.StorageUnit{
 			Name:       key,
 			Attributes: attributes,
@@ -133,8 +142,12 @@ func (p *RedisPlugin) GetStorageUnits(config *engine.PluginConfig, schema string
 	return storageUnits, nil
 }
 
-func (p *RedisPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
-	ctx := context.Background()
+func (p *RedisPlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+	if len(sort) > 0 {
+		return nil, errors.ErrUnsupported
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
 
 	client, err := DB(config)
 	if err != nil {
@@ -214,6 +227,106 @@ func (p *RedisPlugin) RawExecute(config *engine.PluginConfig, query string) (*en
 	return nil, errors.New("unsupported operation for Redis")
 }
 
+func (p *RedisPlugin) SearchStorageUnits(config *engine.PluginConfig, schema string, search string, limit int) ([]engine.SearchHit, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) EstimateRowCount(config *engine.PluginConfig, schema string, storageUnit string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) CountRows(config *engine.PluginConfig, schema string, storageUnit string, where string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetRowsAfter(config *engine.PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	return nil, "", errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *RedisPlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	return nil, errors.ErrUnsupported
+}
+
 func NewRedisPlugin() *engine.Plugin {
 	return &engine.Plugin{
 		Type:            engine.DatabaseType_Redis,
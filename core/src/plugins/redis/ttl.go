@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/go-redis/redis/v8"
+)
+
+// ttlField is the value UpdateStorageUnit reads the TTL of a key from, in seconds.
+const ttlField = "ttl"
+
+// formatTTL returns the TTL of a key in whole seconds the way Redis reports it: -1 when
+// the key does not expire.
+func formatTTL(ttl time.Duration) string {
+	if ttl < 0 {
+		return "-1"
+	}
+	return strconv.FormatInt(int64(ttl/time.Second), 10)
+}
+
+// parseTTL reads a TTL in seconds. Empty, zero or negative values remove the expiry.
+func parseTTL(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("the TTL must be a number of seconds")
+	}
+	if seconds <= 0 {
+		return 0, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// setTTL makes a key expire after ttl, or never when ttl is zero.
+func setTTL(ctx context.Context, client redis.Cmdable, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return client.Persist(ctx, key).Err()
+	}
+	return client.Expire(ctx, key, ttl).Err()
+}
+
+// ExpireKeys sets the TTL of every key matching a glob-style pattern, or removes it when ttl
+// is zero, and returns the keys. With dryRun the keys are only listed.
+func (p *RedisPlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	if len(strings.TrimSpace(pattern)) == 0 {
+		return nil, errors.New("a pattern is required")
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+
+	client, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	// SCAN may return a key more than once, and does not block the server like KEYS.
+	found := map[string]bool{}
+	keys := []string{}
+	var cursor uint64
+	for {
+		var page []string
+		page, cursor, err = client.Scan(ctx, cursor, pattern, 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range page {
+			if !found[key] {
+				found[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	sort.Strings(keys)
+
+	if dryRun || len(keys) == 0 {
+		return keys, nil
+	}
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		if err := setTTL(ctx, pipe, key, ttl); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
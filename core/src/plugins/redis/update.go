@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/clidey/whodb/core/src/engine"
 )
@@ -23,11 +24,43 @@ func (p *RedisPlugin) UpdateStorageUnit(config *engine.PluginConfig, schema stri
 		return false, err
 	}
 
+	// The TTL is updated on its own, and can be the only value given for any type of key.
+	ttlValue, updateTTL := values[ttlField]
+	fields := map[string]string{}
+	for field, value := range values {
+		if field != ttlField {
+			fields[field] = value
+		}
+	}
+	values = fields
+	var ttl time.Duration
+	if updateTTL {
+		if ttl, err = parseTTL(ttlValue); err != nil {
+			return false, err
+		}
+		if keyType == "none" {
+			return false, errors.New("the key does not exist")
+		}
+	}
+	if len(values) == 0 && updateTTL {
+		if err := setTTL(ctx, client, storageUnit, ttl); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
 	switch keyType {
 	case "string":
 		if len(values) != 1 {
 			return false, errors.New("invalid number of fields for a string key")
 		}
+		// SET drops the expiry of the key, so it is set again unless a new one was given.
+		if !updateTTL {
+			if ttl, err = client.TTL(ctx, storageUnit).Result(); err != nil {
+				return false, err
+			}
+			updateTTL = ttl > 0
+		}
 		err := client.Set(ctx, storageUnit, values["value"], 0).Err()
 		if err != nil {
 			return false, err
@@ -51,5 +84,14 @@ func (p *RedisPlugin) UpdateStorageUnit(config *engine.PluginConfig, schema stri
 		return false, fmt.Errorf("unsupported Redis data type: %s", keyType)
 	}
 
+	if updateTTL {
+		if err := setTTL(ctx, client, storageUnit, ttl); err != nil {
+			return false, err
+		}
+	}
 	return true, nil
 }
+
+func (p *RedisPlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,79 @@
+package snowflake
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// EstimateRowCount reads the row count Snowflake keeps in its metadata, which is exact for
+// tables and costs no warehouse time.
+func (p *SnowflakePlugin) EstimateRowCount(config *engine.PluginConfig, schema string, storageUnit string) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count sql.NullInt64
+	err = db.QueryRow("SELECT ROW_COUNT FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", schema, storageUnit).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64, nil
+}
+
+func (p *SnowflakePlugin) CountRows(config *engine.PluginConfig, schema string, storageUnit string, where string) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	var count int64
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (p *SnowflakePlugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT TABLE_NAME, ROW_COUNT, LAST_ALTERED FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ?", schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName string
+		var rowCount sql.NullInt64
+		var lastAltered sql.NullTime
+		if err := rows.Scan(&tableName, &rowCount, &lastAltered); err != nil {
+			return nil, err
+		}
+		records := []engine.Record{}
+		if rowCount.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_EstimatedRows, Value: fmt.Sprintf("%d", rowCount.Int64)})
+		}
+		if lastAltered.Valid {
+			records = append(records, engine.Record{Key: engine.StatsKey_LastModified, Value: lastAltered.Time.Format(time.RFC3339)})
+		}
+		stats[tableName] = records
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,69 @@
+package snowflake
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/snowflakedb/gosnowflake"
+)
+
+const (
+	AdvancedKey_Warehouse      = "Warehouse"
+	AdvancedKey_Role           = "Role"
+	AdvancedKey_PrivateKeyPath = "Private Key Path"
+)
+
+// DB connects to the account given as hostname, e.g. "xy12345.eu-west-1". Key-pair
+// authentication is used when a private key is configured, the password otherwise.
+func DB(config *engine.PluginConfig) (*sql.DB, error) {
+	credentials := config.Credentials
+	snowflakeConfig := gosnowflake.Config{
+		Account:   credentials.Hostname,
+		User:      credentials.Username,
+		Password:  credentials.Password,
+		Database:  credentials.Database,
+		Warehouse: credentials.GetAdvanced(AdvancedKey_Warehouse, ""),
+		Role:      credentials.GetAdvanced(AdvancedKey_Role, ""),
+	}
+	if privateKeyPath := credentials.GetAdvanced(AdvancedKey_PrivateKeyPath, ""); len(privateKeyPath) > 0 {
+		privateKey, err := readPrivateKey(privateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		snowflakeConfig.Authenticator = gosnowflake.AuthTypeJwt
+		snowflakeConfig.PrivateKey = privateKey
+		snowflakeConfig.Password = ""
+	}
+	return sql.OpenDB(gosnowflake.NewConnector(gosnowflake.SnowflakeDriver{}, snowflakeConfig)), nil
+}
+
+// readPrivateKey reads an unencrypted RSA key in PEM format, as PKCS #8 (which Snowflake
+// documents) or PKCS #1.
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("private key is not in PEM format")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key (encrypted keys are not supported): %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
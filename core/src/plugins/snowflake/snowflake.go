@@ -0,0 +1,360 @@
+package snowflake
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+type SnowflakePlugin struct{}
+
+func (p *SnowflakePlugin) IsAvailable(config *engine.PluginConfig) bool {
+	db, err := DB(config)
+	if err != nil {
+		return false
+	}
+	defer db.Close()
+	return db.Ping() == nil
+}
+
+func (p *SnowflakePlugin) GetDatabases() ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetSchema(config *engine.PluginConfig) ([]string, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA ORDER BY SCHEMA_NAME")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemaNames := []string{}
+	for rows.Next() {
+		var schemaName string
+		if err := rows.Scan(&schemaName); err != nil {
+			return nil, err
+		}
+		schemaNames = append(schemaNames, schemaName)
+	}
+	return schemaNames, rows.Err()
+}
+
+func (p *SnowflakePlugin) GetStorageUnits(config *engine.PluginConfig, schema string) ([]engine.StorageUnit, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT TABLE_NAME, TABLE_TYPE, COALESCE(ROW_COUNT, 0), COALESCE(BYTES, 0)
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allTablesWithColumns, err := getTableSchema(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	storageUnits := []engine.StorageUnit{}
+	for rows.Next() {
+		var tableName, tableType string
+		var rowCount, bytes int64
+		if err := rows.Scan(&tableName, &tableType, &rowCount, &bytes); err != nil {
+			return nil, err
+		}
+		attributes := []engine.Record{
+			{Key: "Table Type", Value: tableType},
+			{Key: "Total Size", Value: fmt.Sprintf("%d bytes", bytes)},
+			{Key: "Count", Value: fmt.Sprintf("%d", rowCount)},
+		}
+		attributes = append(attributes, allTablesWithColumns[tableName]...)
+		storageUnits = append(storageUnits, engine.StorageUnit{
+			Name:       tableName,
+			Attributes: attributes,
+		})
+	}
+	return storageUnits, rows.Err()
+}
+
+// getTableSchema returns the columns of every table with their data types, which include
+// the semi-structured VARIANT, ARRAY and OBJECT types.
+func getTableSchema(db *sql.DB, schema string) (map[string][]engine.Record, error) {
+	rows, err := db.Query(`
+		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tableColumnsMap := map[string][]engine.Record{}
+	for rows.Next() {
+		var tableName, columnName, dataType string
+		if err := rows.Scan(&tableName, &columnName, &dataType); err != nil {
+			return nil, err
+		}
+		tableColumnsMap[tableName] = append(tableColumnsMap[tableName], engine.Record{Key: columnName, Value: dataType})
+	}
+	return tableColumnsMap, rows.Err()
+}
+
+func (p *SnowflakePlugin) UpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, values map[string]string) (bool, error) {
+	// Snowflake does not enforce primary keys, so a row cannot be reliably singled out.
+	return false, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) BatchUpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+	query := fmt.Sprintf("SELECT * FROM %v.%v", quoteIdentifier(schema), quoteIdentifier(storageUnit))
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf("%v%v LIMIT ? OFFSET ?", query, orderBy)
+	return p.executeRawSQL(config, query, pageSize, pageOffset)
+}
+
+func (p *SnowflakePlugin) executeRawSQL(config *engine.PluginConfig, query string, params ...interface{}) (*engine.GetRowsResult, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &engine.GetRowsResult{
+		// Without enforced primary keys, edits cannot target a single row.
+		DisableUpdate: true,
+	}
+	for _, columnType := range columnTypes {
+		result.Columns = append(result.Columns, engine.Column{Name: columnType.Name(), Type: columnType.DatabaseTypeName()})
+	}
+
+	guard := config.ResultGuard()
+	for rows.Next() {
+		if guard.Limit(result) {
+			break
+		}
+
+		columnPointers := make([]interface{}, len(columnTypes))
+		for i := range columnTypes {
+			columnPointers[i] = new(sql.NullString)
+		}
+		if err := rows.Scan(columnPointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columnTypes))
+		nulls := make([]bool, len(columnTypes))
+		for i, colPtr := range columnPointers {
+			val := colPtr.(*sql.NullString)
+			if val.Valid {
+				row[i] = val.String
+			} else {
+				nulls[i] = true
+			}
+		}
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, err
+		}
+	}
+	return result, rows.Err()
+}
+
+func (p *SnowflakePlugin) GetGraph(config *engine.PluginConfig, schema string) ([]engine.GraphUnit, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Foreign keys are informational in Snowflake, but still describe how tables relate.
+	rows, err := db.Query(`
+		SELECT DISTINCT pk.TABLE_NAME, fk.TABLE_NAME
+		FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+		JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk
+			ON fk.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND fk.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+		JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS pk
+			ON pk.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND pk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+		WHERE fk.TABLE_SCHEMA = ? AND pk.TABLE_SCHEMA = ?
+	`, schema, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tableMap := map[string][]engine.GraphUnitRelationship{}
+	for rows.Next() {
+		var referencedTable, referencingTable string
+		if err := rows.Scan(&referencedTable, &referencingTable); err != nil {
+			return nil, err
+		}
+		tableMap[referencedTable] = append(tableMap[referencedTable], engine.GraphUnitRelationship{Name: referencingTable, RelationshipType: engine.GraphUnitRelationshipType_OneToMany})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	storageUnits, err := p.GetStorageUnits(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	tables := []engine.GraphUnit{}
+	for _, storageUnit := range storageUnits {
+		tables = append(tables, engine.GraphUnit{Unit: storageUnit, Relations: tableMap[storageUnit.Name]})
+	}
+	return tables, nil
+}
+
+// RawExecute refuses queries that must run read-only, as Snowflake has no read-only
+// transactions.
+func (p *SnowflakePlugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
+	if config.ReadOnly {
+		return nil, engine.ErrReadOnlyUnsupported
+	}
+	return p.executeRawSQL(config, query)
+}
+
+func (p *SnowflakePlugin) SearchStorageUnits(config *engine.PluginConfig, schema string, search string, limit int) ([]engine.SearchHit, error) {
+	// Scanning every text column of a warehouse would be slow and costly.
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (p *SnowflakePlugin) GetRowsAfter(config *engine.PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	return nil, "", errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *SnowflakePlugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func NewSnowflakePlugin() *engine.Plugin {
+	return &engine.Plugin{
+		Type:            engine.DatabaseType_Snowflake,
+		PluginFunctions: &SnowflakePlugin{},
+	}
+}
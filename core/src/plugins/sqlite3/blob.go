@@ -0,0 +1,78 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+	"gorm.io/gorm"
+)
+
+func (p *Sqlite3Plugin) ReadBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, w io.Writer) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.ReadBlob(db, queries, w)
+}
+
+func (p *Sqlite3Plugin) WriteBlob(config *engine.PluginConfig, schema string, storageUnit string, column string, key map[string]string, r io.Reader) (int64, error) {
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	queries, err := blobQueries(db, storageUnit, column, key)
+	if err != nil {
+		return 0, err
+	}
+	return common.WriteBlob(db, queries, r)
+}
+
+// blobQueries casts the value to a BLOB, as SQLite columns hold any type and text would be
+// measured and cut in characters rather than bytes.
+func blobQueries(db *gorm.DB, storageUnit string, column string, key map[string]string) (common.BlobQueries, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return common.BlobQueries{}, errors.New("invalid table name")
+	}
+	pkColumns, columnTypes, err := getTableInfo(db, storageUnit)
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+	if _, ok := columnTypes[column]; !ok {
+		return common.BlobQueries{}, fmt.Errorf("column '%s' does not exist in table %s", column, storageUnit)
+	}
+	condition, args, err := common.RowCondition(pkColumns, key, quoteIdentifier, func(column string, value string) (interface{}, error) {
+		return convertStringValue(value, columnTypes[column])
+	})
+	if err != nil {
+		return common.BlobQueries{}, err
+	}
+
+	table := quoteIdentifier(storageUnit)
+	value := quoteIdentifier(column)
+	return common.BlobQueries{
+		Length: fmt.Sprintf("SELECT length(CAST(%v AS BLOB)) AS length FROM %v WHERE %v", value, table, condition),
+		Read:   fmt.Sprintf("SELECT substr(CAST(%v AS BLOB), ?, ?) FROM %v WHERE %v", value, table, condition),
+		Clear:  fmt.Sprintf("UPDATE %v SET %v = X'' WHERE %v", table, value, condition),
+		Append: fmt.Sprintf("UPDATE %v SET %v = CAST(%v || ? AS BLOB) WHERE %v", table, value, value, condition),
+		Args:   args,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+// GetColumnConstraints reports types and nullability only: SQLite does not enforce declared
+// lengths, and enums can only be emulated with CHECK constraints.
+func (p *Sqlite3Plugin) GetColumnConstraints(config *engine.PluginConfig, schema string, storageUnit string) ([]engine.ColumnConstraint, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return nil, errors.New("invalid table name")
+	}
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	rows, err := db.Raw(fmt.Sprintf("PRAGMA table_info(\"%v\")", storageUnit)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := []engine.ColumnConstraint{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			type_     string
+			notnull   int
+			dfltValue interface{}
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &type_, &notnull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, engine.ColumnConstraint{
+			Name:     name,
+			Type:     type_,
+			Nullable: notnull == 0,
+		})
+	}
+	return constraints, rows.Err()
+}
@@ -0,0 +1,61 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+func (p *Sqlite3Plugin) EstimateRowCount(config *engine.PluginConfig, schema string, storageUnit string) (int64, error) {
+	// SQLite keeps no row statistics unless ANALYZE has populated sqlite_stat1, which
+	// tracks index sizes rather than table sizes, so only exact counts are available.
+	return 0, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) CountRows(config *engine.PluginConfig, schema string, storageUnit string, where string) (int64, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return 0, errors.New("invalid table name")
+	}
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM \"%v\"", storageUnit)
+	if len(where) > 0 {
+		query = fmt.Sprintf("%v WHERE %v", query, where)
+	}
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	db = db.WithContext(ctx)
+	if config.ReadOnly {
+		sqlDb.SetMaxOpenConns(1)
+		if err := db.Exec("PRAGMA query_only = ON").Error; err != nil {
+			return 0, err
+		}
+	}
+	var count int64
+	if err := db.Raw(query).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (p *Sqlite3Plugin) GetStorageUnitStats(config *engine.PluginConfig, schema string) (map[string][]engine.Record, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) GetRoutines(config *engine.PluginConfig, schema string) ([]engine.Routine, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) ExecuteRoutine(config *engine.PluginConfig, schema string, routine string, arguments []string) (*engine.GetRowsResult, error) {
+	return nil, errors.ErrUnsupported
+}
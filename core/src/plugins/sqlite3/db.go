@@ -2,8 +2,10 @@ package sqlite3
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"github.com/clidey/whodb/core/src/env"
@@ -21,6 +23,32 @@ func getDefaultDirectory() string {
 
 var errDoesNotExist = errors.New("unauthorized or the database doesn't exist")
 
+// AdvancedKey_Attach lists other database files to attach as schemas, e.g. "archive.db" or
+// "archive=archive-2023.db, logs.db". Without a name, a file is attached under its base name.
+const AdvancedKey_Attach = "Attach"
+
+type attachment struct {
+	schema string
+	file   string
+}
+
+func attachments(credentials *engine.Credentials) []attachment {
+	attached := []attachment{}
+	for _, entry := range strings.Split(credentials.GetAdvanced(AdvancedKey_Attach, ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		schema, file, found := strings.Cut(entry, "=")
+		if !found {
+			file = schema
+			schema = strings.TrimSuffix(file, filepath.Ext(file))
+		}
+		attached = append(attached, attachment{schema: strings.TrimSpace(schema), file: strings.TrimSpace(file)})
+	}
+	return attached
+}
+
 func DB(config *engine.PluginConfig) (*gorm.DB, error) {
 	database := config.Credentials.Database
 	if !isValidDatabaseFileName(database) {
@@ -34,5 +62,37 @@ func DB(config *engine.PluginConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := attach(db, attachments(config.Credentials)); err != nil {
+		if sqlDb, dbErr := db.DB(); dbErr == nil {
+			sqlDb.Close()
+		}
+		return nil, err
+	}
 	return db, nil
 }
+
+// attach attaches the other database files. Attachments only hold for the connection they
+// are made on, so the pool is limited to that one connection.
+func attach(db *gorm.DB, attached []attachment) error {
+	if len(attached) == 0 {
+		return nil
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDb.SetMaxOpenConns(1)
+	for _, attachment := range attached {
+		if len(attachment.schema) == 0 || !isValidDatabaseFileName(attachment.file) {
+			return fmt.Errorf("invalid database to attach: %v", attachment.file)
+		}
+		fileName := filepath.Join(getDefaultDirectory(), attachment.file)
+		if _, err := os.Stat(fileName); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("database to attach does not exist: %v", attachment.file)
+		}
+		if err := db.Exec(fmt.Sprintf("ATTACH DATABASE ? AS %v", quoteIdentifier(attachment.schema)), fileName).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package sqlite3
+
+import (
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetDDL returns the statements SQLite keeps for a table or view, followed by those of its
+// indexes and triggers.
+func (p *Sqlite3Plugin) GetDDL(config *engine.PluginConfig, schema string, storageUnit string) (string, error) {
+	db, err := DB(config)
+	if err != nil {
+		return "", err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return "", err
+	}
+	defer sqlDb.Close()
+
+	var statements []string
+	query := `
+		SELECT sql
+		FROM sqlite_master
+		WHERE tbl_name = ? AND sql IS NOT NULL
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 0 WHEN 'index' THEN 1 ELSE 2 END, name
+	`
+	if err := db.Raw(query, storageUnit).Scan(&statements).Error; err != nil {
+		return "", err
+	}
+	if len(statements) == 0 {
+		return "", engine.ErrStorageUnitNotFound
+	}
+	return strings.Join(statements, ";\n\n") + ";\n", nil
+}
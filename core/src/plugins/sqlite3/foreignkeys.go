@@ -0,0 +1,64 @@
+package sqlite3
+
+import (
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetForeignKeys returns the foreign keys between the tables of the database. SQLite has no
+// schemas, so both sides of every key carry the requested one.
+func (p *Sqlite3Plugin) GetForeignKeys(config *engine.PluginConfig, schema string) ([]engine.ForeignKey, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	// A reference without columns points at the primary key of the referenced table.
+	var keyColumns []struct {
+		ID               int    `gorm:"column:id"`
+		Table            string `gorm:"column:table_name"`
+		Column           string `gorm:"column:column_name"`
+		ReferencedTable  string `gorm:"column:referenced_table"`
+		ReferencedColumn string `gorm:"column:referenced_column"`
+	}
+	query := `
+		SELECT
+			fk.id AS id,
+			m.name AS table_name,
+			fk."from" AS column_name,
+			fk."table" AS referenced_table,
+			COALESCE(fk."to", (
+				SELECT pk.name FROM pragma_table_info(fk."table") pk WHERE pk.pk = fk.seq + 1
+			)) AS referenced_column
+		FROM sqlite_master m
+		JOIN pragma_foreign_key_list(m.name) fk
+		WHERE m.type = 'table'
+		ORDER BY m.name, fk.id, fk.seq
+	`
+	if err := db.Raw(query).Scan(&keyColumns).Error; err != nil {
+		return nil, err
+	}
+
+	foreignKeys := []engine.ForeignKey{}
+	lastID := -1
+	for _, keyColumn := range keyColumns {
+		last := len(foreignKeys) - 1
+		if last < 0 || foreignKeys[last].Table != keyColumn.Table || lastID != keyColumn.ID {
+			foreignKeys = append(foreignKeys, engine.ForeignKey{
+				Schema:           schema,
+				Table:            keyColumn.Table,
+				ReferencedSchema: schema,
+				ReferencedTable:  keyColumn.ReferencedTable,
+			})
+			last++
+			lastID = keyColumn.ID
+		}
+		foreignKeys[last].Columns = append(foreignKeys[last].Columns, keyColumn.Column)
+		foreignKeys[last].ReferencedColumns = append(foreignKeys[last].ReferencedColumns, keyColumn.ReferencedColumn)
+	}
+	return foreignKeys, nil
+}
@@ -0,0 +1,61 @@
+package sqlite3
+
+import (
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// GetIndexes returns the indexes of the tables of the database, including those SQLite
+// creates for PRIMARY KEY and UNIQUE constraints. An INTEGER PRIMARY KEY is the rowid and
+// has no index.
+func (p *Sqlite3Plugin) GetIndexes(config *engine.PluginConfig, schema string) ([]engine.Index, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	// Expressions have no column name, and are listed as such.
+	var indexColumns []struct {
+		Name   string `gorm:"column:index_name"`
+		Table  string `gorm:"column:table_name"`
+		Column string `gorm:"column:column_name"`
+		Unique int    `gorm:"column:is_unique"`
+		Origin string `gorm:"column:origin"`
+	}
+	query := `
+		SELECT
+			il.name AS index_name,
+			m.name AS table_name,
+			COALESCE(ii.name, '<expression>') AS column_name,
+			il."unique" AS is_unique,
+			il.origin AS origin
+		FROM sqlite_master m
+		JOIN pragma_index_list(m.name) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE m.type = 'table'
+		ORDER BY m.name, il.name, ii.seqno
+	`
+	if err := db.Raw(query).Scan(&indexColumns).Error; err != nil {
+		return nil, err
+	}
+
+	indexes := []engine.Index{}
+	for _, indexColumn := range indexColumns {
+		last := len(indexes) - 1
+		if last < 0 || indexes[last].Table != indexColumn.Table || indexes[last].Name != indexColumn.Name {
+			indexes = append(indexes, engine.Index{
+				Name:    indexColumn.Name,
+				Table:   indexColumn.Table,
+				Unique:  indexColumn.Unique == 1,
+				Primary: indexColumn.Origin == "pk",
+			})
+			last++
+		}
+		indexes[last].Columns = append(indexes[last].Columns, indexColumn.Column)
+	}
+	return indexes, nil
+}
@@ -0,0 +1,42 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+// GetRowsAfter pages through a table with a single column primary key in key order, which
+// unlike offsets stays correct and fast however deep into the table it goes.
+func (p *Sqlite3Plugin) GetRowsAfter(config *engine.PluginConfig, schema string, storageUnit string, where string, afterKey string, pageSize int) (*engine.GetRowsResult, string, error) {
+	if !common.IsValidSQLTableName(storageUnit) {
+		return nil, "", errors.New("invalid table name")
+	}
+	db, err := DB(config)
+	if err != nil {
+		return nil, "", err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, "", err
+	}
+	pkColumns, _, err := getTableInfo(db, storageUnit)
+	sqlDb.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(pkColumns) != 1 {
+		return nil, "", errors.ErrUnsupported
+	}
+
+	key := quoteIdentifier(pkColumns[0])
+	// The key column's affinity converts the text placeholder before comparing.
+	query, params := common.KeysetQuery(quoteIdentifier(storageUnit), where, key, fmt.Sprintf("%v > ?", key), afterKey, pageSize)
+	result, err := p.executeRawSQL(config, query, params...)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, common.LastKey(result, pkColumns[0]), nil
+}
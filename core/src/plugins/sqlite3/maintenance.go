@@ -0,0 +1,69 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+var errDatabaseExists = errors.New("a database with this name already exists")
+
+// CreateDatabase creates an empty database file next to the others, which SQLite initializes
+// on first write.
+func (p *Sqlite3Plugin) CreateDatabase(config *engine.PluginConfig, name string) error {
+	if len(name) == 0 || filepath.Base(name) != name || strings.HasPrefix(name, ".") || !isValidDatabaseFileName(name) {
+		return errors.New("invalid database file name")
+	}
+	file, err := os.OpenFile(filepath.Join(getDefaultDirectory(), name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return errDatabaseExists
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// RunMaintenance vacuums or checks the integrity of the database, along with the databases
+// attached to it.
+func (p *Sqlite3Plugin) RunMaintenance(config *engine.PluginConfig, action engine.MaintenanceAction) ([]string, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	schemas := []string{"main"}
+	for _, attachment := range attachments(config.Credentials) {
+		schemas = append(schemas, attachment.schema)
+	}
+	messages := []string{}
+	for _, schema := range schemas {
+		switch action {
+		case engine.MaintenanceAction_Vacuum:
+			if err := db.Exec(fmt.Sprintf("VACUUM %v", quoteIdentifier(schema))).Error; err != nil {
+				return nil, err
+			}
+			messages = append(messages, fmt.Sprintf("%v: vacuumed", schema))
+		case engine.MaintenanceAction_IntegrityCheck:
+			var problems []string
+			if err := db.Raw(fmt.Sprintf("PRAGMA %v.integrity_check", quoteIdentifier(schema))).Scan(&problems).Error; err != nil {
+				return nil, err
+			}
+			for _, problem := range problems {
+				messages = append(messages, fmt.Sprintf("%v: %v", schema, problem))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported maintenance action: %v", action)
+		}
+	}
+	return messages, nil
+}
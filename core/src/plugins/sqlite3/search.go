@@ -0,0 +1,44 @@
+package sqlite3
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/plugins/common"
+)
+
+func (p *Sqlite3Plugin) SearchStorageUnits(config *engine.PluginConfig, schema string, search string, limit int) ([]engine.SearchHit, error) {
+	db, err := DB(config)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDb.Close()
+
+	tables, err := getTableSchema(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.SearchTables(db, tables, isTextType, func(table string, columns []string) string {
+		conditions := []string{}
+		for _, column := range columns {
+			conditions = append(conditions, fmt.Sprintf("%v LIKE ? ESCAPE '!'", quoteIdentifier(column)))
+		}
+		return fmt.Sprintf("SELECT * FROM %v WHERE %v LIMIT ?", quoteIdentifier(table), strings.Join(conditions, " OR "))
+	}, search, limit)
+}
+
+// isTextType follows SQLite's type affinity rules, where untyped columns can hold text too.
+func isTextType(dataType string) bool {
+	upperType := strings.ToUpper(dataType)
+	return len(upperType) == 0 || strings.Contains(upperType, "CHAR") || strings.Contains(upperType, "CLOB") || strings.Contains(upperType, "TEXT")
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/clidey/whodb/core/src/engine"
 	"github.com/clidey/whodb/core/src/plugins/common"
@@ -137,7 +138,7 @@ func getTableSchema(db *gorm.DB) (map[string][]engine.Record, error) {
 	return tableColumnsMap, nil
 }
 
-func (p *Sqlite3Plugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
+func (p *Sqlite3Plugin) GetRows(config *engine.PluginConfig, schema string, storageUnit string, where string, sort []engine.SortCondition, pageSize int, pageOffset int) (*engine.GetRowsResult, error) {
 	if !common.IsValidSQLTableName(storageUnit) {
 		return nil, errors.New("invalid table name")
 	}
@@ -146,7 +147,11 @@ func (p *Sqlite3Plugin) GetRows(config *engine.PluginConfig, schema string, stor
 	if len(where) > 0 {
 		query = fmt.Sprintf("%v WHERE %v", query, where)
 	}
-	query = fmt.Sprintf("%v LIMIT ? OFFSET ?", query)
+	orderBy, err := common.OrderBy(sort, quoteIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf("%v%v LIMIT ? OFFSET ?", query, orderBy)
 	return p.executeRawSQL(config, query, pageSize, pageOffset)
 }
 
@@ -161,6 +166,18 @@ func (p *Sqlite3Plugin) executeRawSQL(config *engine.PluginConfig, query string,
 		return nil, err
 	}
 	defer sqlDb.Close()
+
+	ctx, cancel := config.QueryContext()
+	defer cancel()
+	db = db.WithContext(ctx)
+	if config.ReadOnly {
+		// SQLite has no read-only transactions, but query_only holds for the connection,
+		// which is kept the only one.
+		sqlDb.SetMaxOpenConns(1)
+		if err := db.Exec("PRAGMA query_only = ON").Error; err != nil {
+			return nil, err
+		}
+	}
 	rows, err := db.Raw(query, params...).Rows()
 	if err != nil {
 		return nil, err
@@ -187,9 +204,15 @@ func (p *Sqlite3Plugin) executeRawSQL(config *engine.PluginConfig, query string,
 		}
 	}
 
+	guard := config.ResultGuard()
 	for rows.Next() {
+		if guard.Limit(result) {
+			break
+		}
+
 		columnPointers := make([]interface{}, len(columns))
 		row := make([]string, len(columns))
+		nulls := make([]bool, len(columns))
 		for i := range columns {
 			columnPointers[i] = new(sql.NullString)
 		}
@@ -204,19 +227,66 @@ func (p *Sqlite3Plugin) executeRawSQL(config *engine.PluginConfig, query string,
 				row[i] = val.String
 			} else {
 				row[i] = ""
+				nulls[i] = true
 			}
 		}
 
-		result.Rows = append(result.Rows, row)
+		if err := guard.Append(result, row, nulls); err != nil {
+			return nil, err
+		}
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 func (p *Sqlite3Plugin) RawExecute(config *engine.PluginConfig, query string) (*engine.GetRowsResult, error) {
 	return p.executeRawSQL(config, query)
 }
 
+func (p *Sqlite3Plugin) GetViewDefinition(config *engine.PluginConfig, schema string, view string) (*engine.ViewDefinition, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) GetActivity(config *engine.PluginConfig) (*engine.Activity, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) KillSession(config *engine.PluginConfig, id string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) GetMaterializedViews(config *engine.PluginConfig, schema string) ([]engine.MaterializedView, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) RefreshMaterializedView(config *engine.PluginConfig, schema string, view string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) ExpireKeys(config *engine.PluginConfig, pattern string, ttl time.Duration, dryRun bool) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) GetDatabaseUsers(config *engine.PluginConfig) ([]engine.DatabaseUser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) CreateDatabaseUser(config *engine.PluginConfig, name string, password string) error {
+	return errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) GrantPrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) RevokePrivilege(config *engine.PluginConfig, user string, grant engine.Grant) error {
+	return errors.ErrUnsupported
+}
+
+func (p *Sqlite3Plugin) InferSchema(config *engine.PluginConfig, schema string, storageUnit string, sampleSize int) ([]engine.InferredField, error) {
+	return nil, errors.ErrUnsupported
+}
+
 func NewSqlite3Plugin() *engine.Plugin {
 	return &engine.Plugin{
 		Type:            engine.DatabaseType_Sqlite3,
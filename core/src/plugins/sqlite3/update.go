@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/clidey/whodb/core/src/common"
@@ -28,6 +29,10 @@ func (p *Sqlite3Plugin) UpdateStorageUnit(config *engine.PluginConfig, schema st
 		return false, err
 	}
 
+	if len(pkColumns) == 0 {
+		return false, fmt.Errorf("no primary key found for table %s", storageUnit)
+	}
+
 	conditions := make(map[string]interface{})
 	convertedValues := make(map[string]interface{})
 	for column, strValue := range values {
@@ -65,6 +70,52 @@ func (p *Sqlite3Plugin) UpdateStorageUnit(config *engine.PluginConfig, schema st
 	return true, nil
 }
 
+func (p *Sqlite3Plugin) BatchUpdateStorageUnit(config *engine.PluginConfig, schema string, storageUnit string, where string, values map[string]string) (int64, error) {
+	if len(strings.TrimSpace(where)) == 0 {
+		return 0, errors.New("a where condition is required for batch updates")
+	}
+	if len(values) == 0 {
+		return 0, errors.New("no values to update")
+	}
+
+	db, err := DB(config)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDb.Close()
+
+	_, columnTypes, err := getTableInfo(db, storageUnit)
+	if err != nil {
+		return 0, err
+	}
+
+	convertedValues := make(map[string]interface{})
+	for column, strValue := range values {
+		columnType, exists := columnTypes[column]
+		if !exists {
+			return 0, fmt.Errorf("column '%s' does not exist in table %s", column, storageUnit)
+		}
+
+		convertedValue, err := convertStringValue(strValue, columnType)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert value for column '%s': %v", column, err)
+		}
+		convertedValues[column] = convertedValue
+	}
+
+	result := db.Table(storageUnit).Where(where).Updates(convertedValues)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 func getTableInfo(db *gorm.DB, tableName string) ([]string, map[string]string, error) {
 	var primaryKeys []string
 	columnTypes := make(map[string]string)
@@ -97,10 +148,6 @@ func getTableInfo(db *gorm.DB, tableName string) ([]string, map[string]string, e
 		return nil, nil, err
 	}
 
-	if len(primaryKeys) == 0 {
-		return nil, nil, fmt.Errorf("no primary key found for table %s", tableName)
-	}
-
 	return primaryKeys, columnTypes, nil
 }
 
@@ -0,0 +1,145 @@
+package recyclebin
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Retention is how long deleted items can be restored for.
+	Retention = 30 * 24 * time.Hour
+	// MaxItems bounds the items kept per connection; the oldest are purged first.
+	MaxItems = 50
+)
+
+var ErrItemNotFound = errors.New("item not found in the recycle bin")
+
+// Item is a deleted asset. Payload holds whatever its type needs to restore it.
+type Item struct {
+	ID         string
+	Type       string
+	Name       string
+	Connection string
+	// Schema is the schema the asset belongs to, empty when it belongs to the whole connection.
+	Schema string `json:",omitempty"`
+	// Owner is the subject of the user the asset belonged to, empty when users are not identified.
+	Owner     string `json:",omitempty"`
+	DeletedAt time.Time
+	Payload   json.RawMessage
+}
+
+func (i Item) ExpiresAt() time.Time {
+	return i.DeletedAt.Add(Retention)
+}
+
+// Bin keeps deleted assets so that they can be restored, scoped to the connection they
+// were deleted from. Expired items are purged whenever the bin is used.
+type Bin struct {
+	store Store
+	mutex sync.Mutex
+}
+
+func New(store Store) *Bin {
+	return &Bin{store: store}
+}
+
+// Add puts an item in the bin, purging the oldest items of its connection past MaxItems.
+func (b *Bin) Add(item Item) (Item, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	items, err := b.load()
+	if err != nil {
+		return item, err
+	}
+	item.ID = uuid.NewString()
+	item.DeletedAt = time.Now()
+	items = append([]Item{item}, items...)
+
+	kept := []Item{}
+	count := 0
+	for _, existing := range items {
+		if existing.Connection == item.Connection {
+			count++
+			if count > MaxItems {
+				continue
+			}
+		}
+		kept = append(kept, existing)
+	}
+	return item, b.store.Save(kept)
+}
+
+// List returns the items of the connection, most recently deleted first.
+func (b *Bin) List(connection string) ([]Item, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	items, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	result := []Item{}
+	for _, item := range items {
+		if item.Connection == connection {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (b *Bin) Get(connection string, id string) (Item, error) {
+	items, err := b.List(connection)
+	if err != nil {
+		return Item{}, err
+	}
+	for _, item := range items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return Item{}, ErrItemNotFound
+}
+
+// Remove deletes an item from the bin, once it has been restored or to purge it early.
+func (b *Bin) Remove(connection string, id string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	items, err := b.load()
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if item.Connection == connection && item.ID == id {
+			return b.store.Save(append(items[:i], items[i+1:]...))
+		}
+	}
+	return ErrItemNotFound
+}
+
+// load returns the items that have not expired, most recently deleted first.
+func (b *Bin) load() ([]Item, error) {
+	items, err := b.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	kept := []Item{}
+	for _, item := range items {
+		if item.ExpiresAt().After(now) {
+			kept = append(kept, item)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].DeletedAt.After(kept[j].DeletedAt)
+	})
+	if len(kept) != len(items) {
+		if err := b.store.Save(kept); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,76 @@
+package recyclebin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists the items of the recycle bin.
+type Store interface {
+	Load() ([]Item, error)
+	Save(items []Item) error
+}
+
+type fileStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) Load() ([]Item, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Item{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *fileStore) Save(items []Item) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+type memoryStore struct {
+	items []Item
+}
+
+// NewMemoryStore keeps deleted items for the lifetime of the process only.
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Load() ([]Item, error) {
+	return append([]Item{}, s.items...), nil
+}
+
+func (s *memoryStore) Save(items []Item) error {
+	s.items = items
+	return nil
+}
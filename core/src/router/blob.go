@@ -0,0 +1,143 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/clidey/whodb/core/src"
+	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/settings"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupBlobHandler(router chi.Router) {
+	router.Get("/api/blob", downloadBlobHandler)
+	router.Put("/api/blob", uploadBlobHandler)
+}
+
+// downloadBlobHandler streams a binary cell as a file. The row is identified by its primary
+// key, given as a JSON object in key, e.g. key={"id":"42"}.
+func downloadBlobHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	plugin, key, ok := blobRequest(w, r, params, auth.Operation_Read)
+	if !ok {
+		return
+	}
+
+	fileName := params.Get("filename")
+	if len(fileName) == 0 {
+		fileName = fmt.Sprintf("%v.%v.bin", params.Get("storageUnit"), params.Get("column"))
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	config := settings.PluginConfigFor(params.Get("type"), auth.GetCredentials(r.Context()))
+	written, err := plugin.ReadBlob(config, params.Get("schema"), params.Get("storageUnit"), params.Get("column"), key, w)
+	if err != nil {
+		log.LogFields(log.Fields{
+			"type":        params.Get("type"),
+			"storageUnit": params.Get("storageUnit"),
+			"column":      params.Get("column"),
+			"bytes":       written,
+		}).Errorf("Blob download failed: %v", err)
+		// Once bytes have been streamed the status is already sent.
+		if written == 0 {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), blobErrorStatus(err))
+		}
+	}
+}
+
+// uploadBlobHandler replaces a binary cell with the request body, either sent as is or as
+// the first file of a multipart form. Either way it is streamed to the database in chunks.
+func uploadBlobHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	plugin, key, ok := blobRequest(w, r, params, auth.Operation_Write)
+	if !ok {
+		return
+	}
+
+	body := io.Reader(r.Body)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				http.Error(w, "the form has no file", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if len(part.FileName()) > 0 {
+				body = part
+				break
+			}
+		}
+	}
+
+	config := settings.PluginConfigFor(params.Get("type"), auth.GetCredentials(r.Context()))
+	written, err := plugin.WriteBlob(config, params.Get("schema"), params.Get("storageUnit"), params.Get("column"), key, body)
+	if err != nil {
+		log.LogFields(log.Fields{
+			"type":        params.Get("type"),
+			"storageUnit": params.Get("storageUnit"),
+			"column":      params.Get("column"),
+		}).Errorf("Blob upload failed: %v", err)
+		http.Error(w, err.Error(), blobErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"bytes": written})
+}
+
+// blobRequest checks access to the cell in the query string and returns the plugin of its
+// database and the primary key of its row, answering with an error when it cannot.
+func blobRequest(w http.ResponseWriter, r *http.Request, params url.Values, operation auth.Operation) (*engine.Plugin, map[string]string, bool) {
+	if len(params.Get("storageUnit")) == 0 || len(params.Get("column")) == 0 {
+		http.Error(w, "storageUnit and column are required", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	key := map[string]string{}
+	if err := json.Unmarshal([]byte(params.Get("key")), &key); err != nil || len(key) == 0 {
+		http.Error(w, "key must be a JSON object of the primary key columns", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if err := auth.CheckType(r.Context(), params.Get("type")); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, nil, false
+	}
+	if err := auth.CheckAccess(r.Context(), params.Get("schema"), operation); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, nil, false
+	}
+	plugin := src.MainEngine.Choose(engine.DatabaseType(params.Get("type")))
+	if plugin == nil {
+		http.Error(w, "unsupported database type", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return plugin, key, true
+}
+
+func blobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, engine.ErrRowNotFound), errors.Is(err, engine.ErrNullValue):
+		return http.StatusNotFound
+	case errors.Is(err, errors.ErrUnsupported):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,368 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src"
+	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/export"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/settings"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupExportHandler(router chi.Router) {
+	router.Get("/api/export", exportHandler)
+	router.Post("/api/exports", startExportHandler)
+	router.Get("/api/exports/{id}", getExportHandler)
+	router.Post("/api/exports/{id}/resume", resumeExportHandler)
+	router.Get("/api/exports/{id}/files/{file}", exportFileHandler)
+}
+
+// exportHandler streams a storage unit (or the result of a raw query) as NDJSON, CSV or
+// an HTML report.
+// Rows are fetched in chunks, or streamed from a query, and flushed as they are written, so
+// neither side has to hold the whole export in memory. Chunks that time out are retried, and
+// an interrupted export can be resumed by passing the number of rows already received as
+// offset. Queries must read, and every export runs in a read-only transaction.
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	databaseType := params.Get("type")
+	format := params.Get("format")
+	if len(format) == 0 {
+		format = export.Format_NDJSON
+	}
+	if format != export.Format_NDJSON && format != export.Format_CSV && format != export.Format_HTML {
+		http.Error(w, "format must be ndjson, csv or html", http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if len(params.Get("offset")) > 0 {
+		var err error
+		offset, err = strconv.Atoi(params.Get("offset"))
+		if err != nil || offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// A report is a single page, so it cannot be resumed.
+	if format == export.Format_HTML && offset > 0 {
+		http.Error(w, "offset is not supported for html reports", http.StatusBadRequest)
+		return
+	}
+
+	query := params.Get("query")
+	storageUnit := params.Get("storageUnit")
+	if len(query) == 0 && len(storageUnit) == 0 {
+		http.Error(w, "either storageUnit or query is required", http.StatusBadRequest)
+		return
+	}
+	// Exports are started with a GET, which can be sent from other sites, so they only read.
+	if len(query) > 0 && !common.IsReadOnlyQuery(query) {
+		http.Error(w, "only queries that read data can be exported", http.StatusBadRequest)
+		return
+	}
+	if err := auth.CheckType(r.Context(), databaseType); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	accessErr := auth.CheckAccess(r.Context(), params.Get("schema"), auth.Operation_Read)
+	if len(query) > 0 {
+		accessErr = auth.CheckQueryAccess(r.Context(), query)
+	} else if accessErr == nil {
+		accessErr = auth.CheckFilterAccess(r.Context(), databaseType, params.Get("schema"), storageUnit, params.Get("where"))
+	}
+	if accessErr != nil {
+		http.Error(w, accessErr.Error(), http.StatusForbidden)
+		return
+	}
+
+	plugin := src.MainEngine.Choose(engine.DatabaseType(databaseType))
+	if plugin == nil {
+		http.Error(w, "unsupported database type", http.StatusBadRequest)
+		return
+	}
+
+	fileName := storageUnit
+	if len(fileName) == 0 {
+		fileName = "query"
+	}
+	switch format {
+	case export.Format_CSV:
+		w.Header().Set("Content-Type", "text/csv")
+	case export.Format_HTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%v.%v", fileName, format)))
+
+	flusher, _ := w.(http.Flusher)
+	rowsWritten := 0
+	var report *export.HTMLReport
+	var writeRow export.RowWriter
+	if format == export.Format_HTML {
+		report = newHTMLReport(w, databaseType, query, params, settings.TargetFor(databaseType, auth.GetCredentials(r.Context())))
+		writeRow = report.WriteRow
+	} else {
+		writeRow = export.NewRowWriter(w, format, offset == 0)
+	}
+	write := export.Project(exportColumns(params), func(columns []engine.Column, row []string, nulls []bool) error {
+		rowsWritten++
+		if err := writeRow(columns, row, nulls); err != nil {
+			return err
+		}
+		// Query results are streamed rather than read in chunks, so they are flushed as often.
+		if len(query) > 0 && flusher != nil && rowsWritten%export.ChunkSize == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	config := settings.PluginConfigFor(databaseType, auth.GetCredentials(r.Context()))
+	config.Context = r.Context()
+	config.Caller = engine.Caller_Export
+	// Exports only read, and filters are raw SQL like queries.
+	config.ReadOnly = true
+
+	var err error
+	if src.MainLineage != nil {
+		defer func(startedAt time.Time) {
+			src.MainLineage.ExportFinished(config, export.Summary{
+				DatabaseType: databaseType,
+				Schema:       params.Get("schema"),
+				StorageUnit:  storageUnit,
+				Query:        query,
+				Destination:  fmt.Sprintf("downloads/%v.%v", fileName, format),
+				Format:       format,
+				Rows:         rowsWritten,
+				StartedAt:    startedAt,
+				Error:        err,
+			})
+		}(time.Now())
+	}
+	if len(query) > 0 {
+		err = export.Query(plugin, config, query, offset, write)
+	} else {
+		cursor := &export.Cursor{Offset: offset}
+		err = export.StorageUnit(plugin, config, params.Get("schema"), storageUnit, params.Get("where"), cursor, write, func() error {
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		log.LogFields(log.Fields{
+			"type":        databaseType,
+			"storageUnit": storageUnit,
+			"offset":      offset,
+			"rows":        rowsWritten,
+		}).Errorf("Export failed: %v", err)
+		// Once a row has been streamed the status is already sent; the client notices the
+		// truncated body and can resume from the rows it received.
+		if rowsWritten == 0 {
+			status := http.StatusInternalServerError
+			if errors.Is(err, export.ErrUnknownColumn) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	if report != nil {
+		if err := report.Close(err); err != nil {
+			log.Logger.Errorf("Unable to finish the export report: %v", err)
+		}
+	}
+}
+
+// exportColumns reads the columns to export, e.g. columns=id,email, in the order given.
+func exportColumns(params url.Values) []string {
+	columns := []string{}
+	for _, column := range strings.Split(params.Get("columns"), ",") {
+		if column = strings.TrimSpace(column); len(column) > 0 {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// newHTMLReport titles the report after the exported table or query. The chart parameter
+// names the label and value columns to chart, e.g. chart=country,revenue.
+func newHTMLReport(w http.ResponseWriter, databaseType string, query string, params url.Values, target settings.Target) *export.HTMLReport {
+	title := "Query results"
+	if len(query) == 0 {
+		title = params.Get("storageUnit")
+		if schema := params.Get("schema"); len(schema) > 0 {
+			title = fmt.Sprintf("%v.%v", schema, title)
+		}
+		if where := params.Get("where"); len(where) > 0 {
+			query = fmt.Sprintf("WHERE %v", where)
+		}
+	}
+	chartLabel, chartValue, _ := strings.Cut(params.Get("chart"), ",")
+	if len(chartValue) == 0 {
+		chartLabel, chartValue = "", chartLabel
+	}
+	return export.NewHTMLReport(w, title, query, databaseType, strings.TrimSpace(chartLabel), strings.TrimSpace(chartValue), renderOptions(target))
+}
+
+// renderOptions reads how values should be shown from the settings of the user and connection.
+func renderOptions(target settings.Target) export.RenderOptions {
+	nullDisplay := settings.GetString(target, settings.Key_NullDisplay)
+	if nullDisplay == "empty" {
+		nullDisplay = ""
+	}
+	return export.RenderOptions{
+		NullDisplay:      nullDisplay,
+		MaxColumnWidth:   settings.GetInt(target, settings.Key_MaxColumnWidth),
+		Ellipsis:         settings.GetString(target, settings.Key_ColumnOverflow) == "ellipsis",
+		AlignNumbersLeft: settings.GetString(target, settings.Key_NumericAlignment) == "left",
+	}
+}
+
+// startExportHandler exports a storage unit in the background, splitting it into files of
+// rowsPerPart rows listed in a manifest, for tables too large to download in one request.
+func startExportHandler(w http.ResponseWriter, r *http.Request) {
+	request := export.Request{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if len(request.Format) == 0 {
+		request.Format = export.Format_NDJSON
+	}
+	if request.Format != export.Format_NDJSON && request.Format != export.Format_CSV {
+		http.Error(w, "format must be ndjson or csv", http.StatusBadRequest)
+		return
+	}
+	if len(request.StorageUnit) == 0 {
+		http.Error(w, "storageUnit is required", http.StatusBadRequest)
+		return
+	}
+	if err := auth.CheckType(r.Context(), request.Type); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err := auth.CheckAccess(r.Context(), request.Schema, auth.Operation_Read); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err := auth.CheckFilterAccess(r.Context(), request.Type, request.Schema, request.StorageUnit, request.Where); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	plugin := src.MainEngine.Choose(engine.DatabaseType(request.Type))
+	if plugin == nil {
+		http.Error(w, "unsupported database type", http.StatusBadRequest)
+		return
+	}
+
+	config, connection := exportJobConfig(r, request.Type)
+	manifest, err := src.MainExports.Start(plugin, config, connection, request)
+	if errors.Is(err, export.ErrInvalidPartsSize) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeManifest(w, manifest, err, http.StatusAccepted)
+}
+
+func getExportHandler(w http.ResponseWriter, r *http.Request) {
+	manifest, ok := findExport(w, r)
+	if !ok {
+		return
+	}
+	writeManifest(w, manifest, nil, http.StatusOK)
+}
+
+// resumeExportHandler continues a failed chunked export from the last row written to disk.
+func resumeExportHandler(w http.ResponseWriter, r *http.Request) {
+	manifest, ok := findExport(w, r)
+	if !ok {
+		return
+	}
+	// The policy may have changed since the export was started.
+	err := auth.CheckAccess(r.Context(), manifest.Schema, auth.Operation_Read)
+	if err == nil {
+		err = auth.CheckFilterAccess(r.Context(), manifest.Type, manifest.Schema, manifest.StorageUnit, manifest.Where)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	plugin := src.MainEngine.Choose(engine.DatabaseType(manifest.Type))
+	config, _ := exportJobConfig(r, manifest.Type)
+	manifest, err = src.MainExports.Resume(plugin, config, manifest.ID)
+	if errors.Is(err, export.ErrJobRunning) || errors.Is(err, export.ErrJobNotResumable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeManifest(w, manifest, err, http.StatusAccepted)
+}
+
+func exportFileHandler(w http.ResponseWriter, r *http.Request) {
+	manifest, ok := findExport(w, r)
+	if !ok {
+		return
+	}
+	path, err := src.MainExports.PartPath(manifest.ID, chi.URLParam(r, "file"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", chi.URLParam(r, "file")))
+	http.ServeFile(w, r, path)
+}
+
+// exportJobConfig copies the request's credentials, as chunked exports outlive the request.
+func exportJobConfig(r *http.Request, databaseType string) (*engine.PluginConfig, string) {
+	credentials := *auth.GetCredentials(r.Context())
+	config := settings.PluginConfigFor(databaseType, &credentials)
+	config.Caller = engine.Caller_Export
+	config.ReadOnly = true
+	return config, settings.TargetFor(databaseType, &credentials).Connection
+}
+
+// findExport returns the export in the URL, answering with a 404 when it does not exist
+// or was started from another connection.
+func findExport(w http.ResponseWriter, r *http.Request) (*export.Manifest, bool) {
+	manifest, err := src.MainExports.Get(chi.URLParam(r, "id"))
+	if errors.Is(err, export.ErrJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if auth.CheckType(r.Context(), manifest.Type) != nil || manifest.Connection != settings.TargetFor(manifest.Type, auth.GetCredentials(r.Context())).Connection {
+		http.Error(w, export.ErrJobNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if err := auth.CheckAccess(r.Context(), manifest.Schema, auth.Operation_Read); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	return manifest, true
+}
+
+func writeManifest(w http.ResponseWriter, manifest *export.Manifest, err error, status int) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(manifest)
+}
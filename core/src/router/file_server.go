@@ -1,14 +1,24 @@
 package router
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
+const buildDirectory = "build"
+
 func fileServer(r chi.Router) {
-	fs := http.FileServer(http.Dir("./build"))
+	fs := cacheHeaders(http.FileServer(http.Dir(buildDirectory)))
 	r.Handle("/static/*", fs)
 	r.Handle("/images/*", fs)
 	r.Handle("/favicon.ico", fs)
@@ -18,6 +28,74 @@ func fileServer(r chi.Router) {
 	r.Handle("/logo512.png", fs)
 	r.Handle("/robots.txt", fs)
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join("build", "index.html"))
+		indexFile := filepath.Join(buildDirectory, "index.html")
+		setCacheHeaders(w, "/index.html", indexFile)
+		http.ServeFile(w, r, indexFile)
+	})
+}
+
+// cacheHeaders lets browsers keep the frontend assets: the files under /static have the hash
+// of their content in their name and never change, while the others are revalidated by ETag
+// on every load, so that a new release shows up at once.
+func cacheHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath := path.Clean("/" + r.URL.Path)
+		setCacheHeaders(w, urlPath, filepath.Join(buildDirectory, filepath.FromSlash(urlPath)))
+		next.ServeHTTP(w, r)
 	})
 }
+
+func setCacheHeaders(w http.ResponseWriter, urlPath string, file string) {
+	if strings.HasPrefix(urlPath, "/static/") {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	// http.ServeFile answers If-None-Match itself once the ETag is set.
+	if etag, ok := assetETags.get(file); ok {
+		w.Header().Set("ETag", etag)
+	}
+}
+
+type assetETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// etagCache hashes each asset once, and again only when it changes on disk.
+type etagCache struct {
+	mutex sync.Mutex
+	etags map[string]assetETag
+}
+
+var assetETags = &etagCache{etags: map[string]assetETag{}}
+
+func (c *etagCache) get(file string) (string, bool) {
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	c.mutex.Lock()
+	cached, ok := c.etags[file]
+	c.mutex.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.etag, true
+	}
+
+	content, err := os.Open(file)
+	if err != nil {
+		return "", false
+	}
+	defer content.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", false
+	}
+	// Weak, as the same ETag is sent whether or not the response is compressed.
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+	c.mutex.Lock()
+	c.etags[file] = assetETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	c.mutex.Unlock()
+	return etag, true
+}
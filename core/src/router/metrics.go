@@ -0,0 +1,31 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/clidey/whodb/core/src"
+	"github.com/go-chi/chi/v5"
+)
+
+// setupMetrics serves /metrics and times GraphQL resolvers when metrics are enabled.
+func setupMetrics(router chi.Router, server *handler.Server) {
+	if src.MainMetrics == nil {
+		return
+	}
+	router.Handle("/metrics", src.MainMetrics.Handler())
+	server.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		field := graphql.GetFieldContext(ctx)
+		// Fields read off structs are not worth timing, and would only add series.
+		if !field.IsResolver {
+			return next(ctx)
+		}
+		startedAt := time.Now()
+		result, err := next(ctx)
+		src.MainMetrics.ResolverFinished(fmt.Sprintf("%v.%v", field.Object, field.Field.Name), time.Since(startedAt), err)
+		return result, err
+	})
+}
@@ -2,14 +2,51 @@ package router
 
 import (
 	"context"
+	"io"
 	"net/http"
 
+	"github.com/andybalholm/brotli"
 	"github.com/clidey/whodb/core/src/common"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
+const compressionLevel = 5
+
+// compressedContentTypes adds the export formats to the types compressed by default.
+var compressedContentTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/javascript",
+	"text/csv",
+	"application/javascript",
+	"application/json",
+	"application/x-ndjson",
+	"application/graphql-response+json",
+	"image/svg+xml",
+}
+
 func contextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.WithValue(r.Context(), common.RouterKey_ResponseWriter, w)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// peerAddressMiddleware keeps the address of the connecting peer, which RealIP overwrites.
+func peerAddressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), common.RouterKey_PeerAddress, r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// compressMiddleware compresses responses with brotli or gzip, whichever the client
+// prefers. Streamed exports are compressed chunk by chunk as they are flushed.
+func compressMiddleware() func(http.Handler) http.Handler {
+	compressor := middleware.NewCompressor(compressionLevel, compressedContentTypes...)
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return compressor.Handler
+}
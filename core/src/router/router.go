@@ -1,16 +1,25 @@
 package router
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/clidey/whodb/core/graph"
+	"github.com/clidey/whodb/core/src"
 	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/env"
 	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/persistedquery"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -24,20 +33,77 @@ type OAuthLoginUrl struct {
 
 func setupServer(router *chi.Mux) {
 	fileServer(router)
+	auth.SetupOIDCRoutes(router)
+	setupExportHandler(router)
+	setupBlobHandler(router)
 
-	server := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
-	server.AddTransport(&transport.Websocket{})
+	server := newGraphQLServer()
+	setupMetrics(router, server)
 	setupPlaygroundHandler(router, server)
 }
 
+// newGraphQLServer is handler.NewDefaultServer with automatic persisted queries looked up
+// in the persisted queries file first, and restricted to it in lock-down mode.
+func newGraphQLServer() *handler.Server {
+	manifest := persistedquery.Manifest{}
+	if len(env.PersistedQueriesFile) > 0 {
+		var err error
+		manifest, err = persistedquery.Load(env.PersistedQueriesFile)
+		if err != nil {
+			log.Logger.Fatalf("Unable to load persisted queries: %v", err)
+		}
+	} else if env.PersistedQueriesOnly {
+		log.Logger.Fatal("WHODB_PERSISTED_QUERIES_ONLY requires WHODB_PERSISTED_QUERIES_FILE")
+	}
+
+	server := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	server.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.AddTransport(transport.MultipartForm{})
+
+	server.SetQueryCache(lru.New(1000))
+	server.AroundFields(checkDatabaseType)
+
+	server.Use(extension.Introspection{})
+	server.Use(extension.AutomaticPersistedQuery{
+		Cache: persistedquery.Cache{
+			Manifest: manifest,
+			Fallback: lru.New(100),
+			Only:     env.PersistedQueriesOnly,
+		},
+	})
+	if env.PersistedQueriesOnly {
+		server.Use(persistedquery.Whitelist{Manifest: manifest})
+	}
+	return server
+}
+
+// checkDatabaseType rejects fields run against another database type than the connection
+// logged in to, as the connection policy was only checked for that one.
+func checkDatabaseType(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	field := graphql.GetFieldContext(ctx)
+	if databaseType, ok := field.Args["type"]; ok && field.IsResolver {
+		if err := auth.CheckType(ctx, fmt.Sprint(databaseType)); err != nil {
+			return nil, err
+		}
+	}
+	return next(ctx)
+}
+
 func setupMiddlewares(router *chi.Mux) {
 	router.Use(
+		peerAddressMiddleware,
 		middleware.ThrottleBacklog(10000, 1000, time.Second*5),
 		middleware.RequestID,
 		middleware.RealIP,
 		middleware.Logger,
 		middleware.RedirectSlashes,
 		middleware.Recoverer,
+		compressMiddleware(),
 		middleware.Timeout(10*time.Minute),
 		cors.Handler(cors.Options{
 			AllowedOrigins:   []string{"https://*", "http://*"},
@@ -53,6 +119,10 @@ func setupMiddlewares(router *chi.Mux) {
 }
 
 func InitializeRouter() {
+	if err := auth.Initialize(); err != nil {
+		panic(err)
+	}
+
 	router := chi.NewRouter()
 
 	port := os.Getenv("PORT")
@@ -63,12 +133,60 @@ func InitializeRouter() {
 	setupMiddlewares(router)
 	setupServer(router)
 
+	if err := src.MainVersion.VerifyAssets(buildDirectory); err != nil {
+		log.Logger.Errorf("The frontend does not match this server: %v", err)
+	}
+
 	log.Logger.Infof("🎉 Welcome to WhoDB! 🎉")
 	log.Logger.Infof("Get started by visiting:")
-	log.Logger.Infof("http://0.0.0.0:%s", port)
+	scheme := "http"
+	if isTLSEnabled() {
+		scheme = "https"
+	}
+	log.Logger.Infof("%s://0.0.0.0:%s", scheme, port)
 	log.Logger.Info("Explore and enjoy working with your databases!")
 
-	if err := http.ListenAndServe(fmt.Sprintf(":%v", port), router); err != nil {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%v", port),
+		Handler: router,
+	}
+	if !isTLSEnabled() {
+		if err := server.ListenAndServe(); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	tlsConfig, err := getTLSConfig()
+	if err != nil {
+		panic(err)
+	}
+	server.TLSConfig = tlsConfig
+	if err := server.ListenAndServeTLS(env.TLSCertFile, env.TLSKeyFile); err != nil {
 		panic(err)
 	}
 }
+
+func isTLSEnabled() bool {
+	return len(env.TLSCertFile) > 0 && len(env.TLSKeyFile) > 0
+}
+
+// getTLSConfig asks for client certificates when a client CA is configured. They stay
+// optional so the other authenticators keep working for clients without one.
+func getTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(env.TLSClientCAFile) == 0 {
+		return tlsConfig, nil
+	}
+	caCertificates, err := os.ReadFile(env.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caCertificates) {
+		return nil, fmt.Errorf("no certificates found in %v", env.TLSClientCAFile)
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
+}
@@ -0,0 +1,374 @@
+package scheduledquery
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/auth"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/scheduler"
+	"github.com/clidey/whodb/core/src/settings"
+)
+
+// HandlerName is the scheduler handler that runs scheduled queries.
+const HandlerName = "ScheduledQuery"
+
+const (
+	maxSnapshots   = 20
+	webhookTimeout = 10 * time.Second
+	// maxRemovedRows bounds the stored rows kept in the recycle bin with a removed query;
+	// past it, only the summary of each snapshot is kept.
+	maxRemovedRows = 10000
+)
+
+type Event string
+
+const (
+	Event_Changed Event = "Changed"
+	Event_Failed  Event = "Failed"
+)
+
+var ErrNotScheduledQuery = errors.New("job is not a scheduled query")
+
+// Query is the payload of a scheduled query job. The credentials are kept with the job
+// so that it can run without a user session, sealed so that they are not stored in clear.
+type Query struct {
+	DatabaseType string
+	// Connection identifies the connection the query was created from, see settings.TargetFor.
+	Connection  string
+	Credentials engine.Credentials `json:"-"`
+	// SealedCredentials are the credentials encrypted with auth.Seal.
+	SealedCredentials string `json:",omitempty"`
+	// Tenant the query runs as; credentials do not serialize it.
+	Tenant string
+	// Identity is the user who scheduled the query, whose roles it is checked against on
+	// every run; nil when users are not identified.
+	Identity     *auth.Identity `json:",omitempty"`
+	Query        string
+	StoreResults bool
+	WebhookURL   string
+	// credentialsErr is why the credentials could not be unsealed, reported when the job runs.
+	credentialsErr error
+}
+
+// OwnedBy reports whether identity scheduled the query, and so may see, run and remove it.
+// When users are not identified, queries belong to whoever uses their connection.
+func (q Query) OwnedBy(identity *auth.Identity) bool {
+	if identity == nil || q.Identity == nil {
+		return identity == nil && q.Identity == nil
+	}
+	return q.Identity.Subject == identity.Subject
+}
+
+// legacyQuery reads the credentials of jobs saved before they were sealed.
+type legacyQuery struct {
+	Credentials *engine.Credentials
+}
+
+// Snapshot records the outcome of a single run. Rows are only kept when the query
+// was created with StoreResults.
+type Snapshot struct {
+	RanAt    time.Time
+	Duration time.Duration
+	RowCount int
+	Checksum string
+	Changed  bool
+	Error    string
+	Columns  []engine.Column `json:",omitempty"`
+	Rows     [][]string      `json:",omitempty"`
+}
+
+type webhookPayload struct {
+	JobID    string
+	Name     string
+	Event    Event
+	Query    string
+	Snapshot Snapshot
+}
+
+type Runner struct {
+	engine    *engine.Engine
+	snapshots SnapshotStore
+	client    *http.Client
+	mutex     sync.Mutex
+}
+
+// Register adds the scheduled query handler to the scheduler. It must be called before
+// the scheduler starts so that persisted jobs can run.
+func Register(jobScheduler *scheduler.Scheduler, queryEngine *engine.Engine, snapshots SnapshotStore) *Runner {
+	runner := &Runner{
+		engine:    queryEngine,
+		snapshots: snapshots,
+		client:    newWebhookClient(),
+	}
+	jobScheduler.RegisterHandler(HandlerName, runner.run)
+	return runner
+}
+
+// NewJob builds a scheduler job that runs the query on schedule.
+func NewJob(name string, schedule string, query Query) (scheduler.Job, error) {
+	payload, err := encodePayload(query)
+	if err != nil {
+		return scheduler.Job{}, err
+	}
+	return scheduler.Job{
+		Name:     name,
+		Handler:  HandlerName,
+		Schedule: schedule,
+		Payload:  payload,
+		Enabled:  true,
+	}, nil
+}
+
+// encodePayload seals the credentials of the query and encodes it as the payload of its job.
+func encodePayload(query Query) ([]byte, error) {
+	credentials, err := json.Marshal(query.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	query.SealedCredentials, err = auth.Seal(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(query)
+}
+
+// SealCredentials saves again the scheduled queries saved before their credentials were
+// sealed, so that they are no longer stored in clear. It must be called once the scheduler
+// has started.
+func SealCredentials(jobScheduler *scheduler.Scheduler) error {
+	for _, job := range jobScheduler.GetJobs() {
+		query, err := GetQuery(job)
+		if err != nil || len(query.SealedCredentials) > 0 {
+			continue
+		}
+		job.Payload, err = encodePayload(query)
+		if err != nil {
+			return err
+		}
+		if _, err := jobScheduler.AddJob(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetQuery(job scheduler.Job) (Query, error) {
+	query := Query{}
+	if job.Handler != HandlerName {
+		return query, ErrNotScheduledQuery
+	}
+	if err := json.Unmarshal(job.Payload, &query); err != nil {
+		return query, fmt.Errorf("invalid scheduled query: %w", err)
+	}
+	if len(query.SealedCredentials) > 0 {
+		credentials, err := auth.Unseal(query.SealedCredentials)
+		if err == nil {
+			err = json.Unmarshal(credentials, &query.Credentials)
+		}
+		if err != nil {
+			query.credentialsErr = errors.New("unable to unseal the credentials of the scheduled query, was WHODB_SESSION_SECRET changed?")
+		}
+	} else {
+		legacy := legacyQuery{}
+		if err := json.Unmarshal(job.Payload, &legacy); err == nil && legacy.Credentials != nil {
+			query.Credentials = *legacy.Credentials
+		}
+	}
+	query.Credentials.Tenant = query.Tenant
+	if query.Identity != nil {
+		query.Credentials.Subject = query.Identity.Subject
+	}
+	return query, nil
+}
+
+func (r *Runner) Snapshots(jobID string) ([]Snapshot, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.snapshots.Load(jobID)
+}
+
+func (r *Runner) DeleteSnapshots(jobID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.snapshots.Delete(jobID)
+}
+
+// Removed is what the recycle bin keeps of a removed scheduled query to restore it.
+type Removed struct {
+	Job       scheduler.Job
+	Snapshots []Snapshot
+}
+
+// Removed returns the job along with its snapshots, without their stored rows when there
+// are more than maxRemovedRows of them.
+func (r *Runner) Removed(job scheduler.Job) (Removed, error) {
+	snapshots, err := r.Snapshots(job.ID)
+	if err != nil {
+		return Removed{}, err
+	}
+	rows := 0
+	for _, snapshot := range snapshots {
+		rows += len(snapshot.Rows)
+	}
+	if rows > maxRemovedRows {
+		for i := range snapshots {
+			snapshots[i] = withoutRows(snapshots[i])
+		}
+	}
+	return Removed{Job: job, Snapshots: snapshots}, nil
+}
+
+// RestoreSnapshots puts back the snapshots of a removed scheduled query.
+func (r *Runner) RestoreSnapshots(jobID string, snapshots []Snapshot) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.snapshots.Save(jobID, snapshots)
+}
+
+func (r *Runner) run(ctx context.Context, job scheduler.Job) error {
+	query, err := GetQuery(job)
+	if err != nil {
+		return err
+	}
+	if query.credentialsErr != nil {
+		return query.credentialsErr
+	}
+
+	plugin := r.engine.Choose(engine.DatabaseType(query.DatabaseType))
+	if plugin == nil {
+		return fmt.Errorf("unsupported database type: %v", query.DatabaseType)
+	}
+
+	startedAt := time.Now()
+	var result *engine.GetRowsResult
+	// The policy may have changed since the query was scheduled, which fails the run.
+	access, queryErr := auth.CheckConnectionAccess(query.Identity, query.DatabaseType, &query.Credentials)
+	if queryErr == nil {
+		queryErr = access.CheckQuery(query.Query)
+	}
+	if queryErr == nil {
+		config := settings.PluginConfigFor(query.DatabaseType, &query.Credentials)
+		config.Caller = engine.Caller_Scheduler
+		config.Context = ctx
+		config.ReadOnly = access.RequiresReadOnly(query.Query)
+		result, queryErr = plugin.RawExecute(config, query.Query)
+	}
+	snapshot := Snapshot{
+		RanAt:    startedAt,
+		Duration: time.Since(startedAt),
+	}
+	if queryErr != nil {
+		snapshot.Error = queryErr.Error()
+	} else {
+		snapshot.RowCount = len(result.Rows)
+		snapshot.Checksum = checksum(result)
+		if query.StoreResults {
+			snapshot.Columns = result.Columns
+			snapshot.Rows = result.Rows
+		}
+	}
+
+	event, err := r.record(job.ID, &snapshot)
+	if err != nil {
+		log.LogFields(log.Fields{
+			"job": job.ID,
+		}).Errorf("Unable to save scheduled query snapshot: %v", err)
+	}
+	if len(event) > 0 && len(query.WebhookURL) > 0 {
+		if err := r.notify(ctx, query.WebhookURL, webhookPayload{
+			JobID:    job.ID,
+			Name:     job.Name,
+			Event:    event,
+			Query:    query.Query,
+			Snapshot: withoutRows(snapshot),
+		}); err != nil {
+			log.LogFields(log.Fields{
+				"job":     job.ID,
+				"webhook": query.WebhookURL,
+			}).Errorf("Scheduled query webhook failed: %v", err)
+		}
+	}
+	return queryErr
+}
+
+// record stores the snapshot and returns the event the run raised, if any. A run counts as a
+// change when its checksum differs from the previous successful run.
+func (r *Runner) record(jobID string, snapshot *Snapshot) (Event, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	snapshots, err := r.snapshots.Load(jobID)
+	if err != nil {
+		return "", err
+	}
+
+	var event Event
+	if len(snapshot.Error) > 0 {
+		event = Event_Failed
+	} else {
+		for _, previous := range snapshots {
+			if len(previous.Error) > 0 {
+				continue
+			}
+			if previous.Checksum != snapshot.Checksum {
+				snapshot.Changed = true
+				event = Event_Changed
+			}
+			break
+		}
+	}
+
+	snapshots = append([]Snapshot{*snapshot}, snapshots...)
+	if len(snapshots) > maxSnapshots {
+		snapshots = snapshots[:maxSnapshots]
+	}
+	return event, r.snapshots.Save(jobID, snapshots)
+}
+
+func (r *Runner) notify(ctx context.Context, url string, payload webhookPayload) error {
+	// Allowed hosts may have changed since the query was scheduled.
+	if err := ValidateWebhookURL(url); err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with %v", response.Status)
+	}
+	return nil
+}
+
+func checksum(result *engine.GetRowsResult) string {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	encoder.Encode(result.Columns)
+	encoder.Encode(result.Rows)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func withoutRows(snapshot Snapshot) Snapshot {
+	snapshot.Columns = nil
+	snapshot.Rows = nil
+	return snapshot
+}
@@ -0,0 +1,104 @@
+package scheduledquery
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SnapshotStore keeps the most recent snapshots of every scheduled query, newest first.
+type SnapshotStore interface {
+	Load(jobID string) ([]Snapshot, error)
+	Save(jobID string, snapshots []Snapshot) error
+	Delete(jobID string) error
+}
+
+type fileSnapshotStore struct {
+	directory string
+	mutex     sync.Mutex
+}
+
+// NewFileSnapshotStore stores the snapshots of each job in its own file inside directory.
+func NewFileSnapshotStore(directory string) SnapshotStore {
+	return &fileSnapshotStore{directory: directory}
+}
+
+func (s *fileSnapshotStore) path(jobID string) string {
+	return filepath.Join(s.directory, filepath.Base(jobID)+".json")
+}
+
+func (s *fileSnapshotStore) Load(jobID string) ([]Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := os.ReadFile(s.path(jobID))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []Snapshot{}
+	if err := json.Unmarshal(content, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (s *fileSnapshotStore) Save(jobID string, snapshots []Snapshot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.directory, 0o755); err != nil {
+		return err
+	}
+	tmpPath := s.path(jobID) + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(jobID))
+}
+
+func (s *fileSnapshotStore) Delete(jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := os.Remove(s.path(jobID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type memorySnapshotStore struct {
+	mutex     sync.Mutex
+	snapshots map[string][]Snapshot
+}
+
+// NewMemorySnapshotStore keeps snapshots for the lifetime of the process only.
+func NewMemorySnapshotStore() SnapshotStore {
+	return &memorySnapshotStore{snapshots: map[string][]Snapshot{}}
+}
+
+func (s *memorySnapshotStore) Load(jobID string) ([]Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Snapshot{}, s.snapshots[jobID]...), nil
+}
+
+func (s *memorySnapshotStore) Save(jobID string, snapshots []Snapshot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.snapshots[jobID] = snapshots
+	return nil
+}
+
+func (s *memorySnapshotStore) Delete(jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.snapshots, jobID)
+	return nil
+}
@@ -0,0 +1,89 @@
+package scheduledquery
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/env"
+)
+
+var ErrWebhookNotAllowed = errors.New("webhook target not allowed")
+
+// webhookAllowedHosts are the host patterns set by the operator, or nil when any public
+// host may be notified.
+func webhookAllowedHosts() []string {
+	hosts := []string{}
+	for _, host := range strings.Split(env.WebhookAllowedHosts, ",") {
+		if host = strings.TrimSpace(host); len(host) > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	return hosts
+}
+
+// ValidateWebhookURL checks that a webhook is an http or https URL to an allowed host, as
+// the requests are made from the server.
+func ValidateWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Hostname()) == 0 {
+		return fmt.Errorf("%w: %q is not an http or https URL", ErrWebhookNotAllowed, webhookURL)
+	}
+	allowed := webhookAllowedHosts()
+	if allowed == nil {
+		// Names are checked once resolved, when the webhook is sent.
+		if ip := net.ParseIP(parsed.Hostname()); ip != nil && !isPublicIP(ip) {
+			return fmt.Errorf("%w: %v is not a public address", ErrWebhookNotAllowed, ip)
+		}
+		return nil
+	}
+	if !common.MatchesAnyPattern(allowed, parsed.Hostname()) {
+		return fmt.Errorf("%w: %v is not one of WHODB_WEBHOOK_ALLOWED_HOSTS", ErrWebhookNotAllowed, parsed.Hostname())
+	}
+	return nil
+}
+
+// newWebhookClient returns the client webhooks are sent with. Without allowed hosts, it
+// refuses to connect to loopback, private and link-local addresses, which is checked on the
+// address actually dialed so that a name cannot resolve to one of them. Redirects are not
+// followed, as they could lead anywhere.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if webhookAllowedHosts() == nil {
+		// A proxy would be dialed instead of the webhook's own address.
+		transport.Proxy = nil
+		dialer.Control = func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %v is not a public address", ErrWebhookNotAllowed, host)
+			}
+			return nil
+		}
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
@@ -0,0 +1,522 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+type MisfirePolicy string
+
+const (
+	// MisfirePolicy_RunOnce runs a job that missed one or more fire times once, then resumes its schedule.
+	MisfirePolicy_RunOnce MisfirePolicy = "RunOnce"
+	// MisfirePolicy_Skip drops missed fire times and waits for the next scheduled one.
+	MisfirePolicy_Skip MisfirePolicy = "Skip"
+)
+
+const (
+	tickInterval            = time.Second
+	defaultMisfireThreshold = time.Minute
+	// refreshInterval is how often jobs changed by other servers are looked for.
+	refreshInterval = 10 * time.Second
+	// claimTTL keeps a run claimed for much longer than servers take to reach it.
+	claimTTL = time.Hour
+	// revisionName is the shared revision of the job definitions.
+	revisionName = "scheduler"
+)
+
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrHandlerNotFound = errors.New("no handler registered for job")
+)
+
+// Handler executes a single run of a job. The context is cancelled when the scheduler stops.
+type Handler func(ctx context.Context, job Job) error
+
+type Job struct {
+	ID            string
+	Name          string
+	Handler       string
+	Schedule      string
+	Payload       json.RawMessage
+	Enabled       bool
+	MisfirePolicy MisfirePolicy
+	LastRun       time.Time
+	NextRun       time.Time
+}
+
+type JobStatus struct {
+	ID           string
+	Name         string
+	Running      bool
+	LastRun      time.Time
+	NextRun      time.Time
+	LastDuration time.Duration
+	LastError    string
+	Runs         int
+	Failures     int
+	Misfires     int
+}
+
+type Scheduler struct {
+	store            Store
+	slots            chan struct{}
+	misfireThreshold time.Duration
+
+	mutex     sync.Mutex
+	handlers  map[string]Handler
+	jobs      map[string]*Job
+	schedules map[string]cron.Schedule
+	status    map[string]*JobStatus
+	// revision and refreshedAt track the job definitions shared with other servers.
+	revision    int64
+	refreshedAt time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// New creates a scheduler that runs at most maxConcurrent jobs at the same time.
+func New(store Store, maxConcurrent int) *Scheduler {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &Scheduler{
+		store:            store,
+		slots:            make(chan struct{}, maxConcurrent),
+		misfireThreshold: defaultMisfireThreshold,
+		handlers:         map[string]Handler{},
+		jobs:             map[string]*Job{},
+		schedules:        map[string]cron.Schedule{},
+		status:           map[string]*JobStatus{},
+	}
+}
+
+func ParseSchedule(schedule string) (cron.Schedule, error) {
+	return parser.Parse(schedule)
+}
+
+func (s *Scheduler) RegisterHandler(name string, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[name] = handler
+}
+
+func (s *Scheduler) Start() error {
+	revision, err := cache.Revision(revisionName)
+	if err != nil {
+		return err
+	}
+	jobs, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	for _, job := range jobs {
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			log.LogFields(log.Fields{
+				"job": job.ID,
+			}).Errorf("Skipping job with invalid schedule %q: %v", job.Schedule, err)
+			continue
+		}
+		if job.NextRun.IsZero() {
+			job.NextRun = schedule.Next(time.Now())
+		}
+		s.jobs[job.ID] = job
+		s.schedules[job.ID] = schedule
+		s.status[job.ID] = &JobStatus{ID: job.ID, Name: job.Name, LastRun: job.LastRun, NextRun: job.NextRun}
+	}
+	s.revision = revision
+	s.refreshedAt = time.Now()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	jobCount := len(s.jobs)
+	s.mutex.Unlock()
+
+	s.wg.Add(1)
+	go s.loop()
+	log.Logger.Infof("Scheduler started with %d job(s)", jobCount)
+	return nil
+}
+
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) AddJob(job Job) (*Job, error) {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", job.Schedule, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.handlers[job.Handler]; !ok {
+		return nil, fmt.Errorf("%w: %v", ErrHandlerNotFound, job.Handler)
+	}
+	if err := s.refresh(true); err != nil {
+		return nil, err
+	}
+	if len(job.ID) == 0 {
+		job.ID = uuid.NewString()
+	}
+	if len(job.MisfirePolicy) == 0 {
+		job.MisfirePolicy = MisfirePolicy_RunOnce
+	}
+	job.NextRun = schedule.Next(time.Now())
+	s.jobs[job.ID] = &job
+	s.schedules[job.ID] = schedule
+	s.status[job.ID] = &JobStatus{ID: job.ID, Name: job.Name, NextRun: job.NextRun}
+	if err := s.persistChange(); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *Scheduler) RemoveJob(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.refresh(true); err != nil {
+		return err
+	}
+	if _, ok := s.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	delete(s.schedules, id)
+	delete(s.status, id)
+	return s.persistChange()
+}
+
+func (s *Scheduler) GetJob(id string) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+func (s *Scheduler) GetJobs() []Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	jobs := []Job{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
+
+func (s *Scheduler) Status() []JobStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	statuses := []JobStatus{}
+	for _, status := range s.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// RunNow triggers a job immediately without changing its schedule.
+func (s *Scheduler) RunNow(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if !s.dispatch(job) {
+		return fmt.Errorf("job %v is already running or no run slots are available", id)
+	}
+	return nil
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if now.Sub(s.refreshedAt) >= refreshInterval {
+		if err := s.refresh(false); err != nil {
+			log.Logger.Warnf("Unable to load the jobs changed by other servers: %v", err)
+		}
+	}
+	changed := false
+	for id, job := range s.jobs {
+		if !job.Enabled || now.Before(job.NextRun) {
+			continue
+		}
+		schedule := s.schedules[id]
+		status := s.status[id]
+		if now.Sub(job.NextRun) > s.misfireThreshold {
+			status.Misfires++
+			log.LogFields(log.Fields{
+				"job":      job.ID,
+				"name":     job.Name,
+				"next_run": job.NextRun,
+				"policy":   job.MisfirePolicy,
+			}).Warn("Job misfired")
+			if job.MisfirePolicy == MisfirePolicy_Skip {
+				job.NextRun = schedule.Next(now)
+				status.NextRun = job.NextRun
+				changed = true
+				continue
+			}
+		}
+		if _, ok := s.handlers[job.Handler]; !ok {
+			// The job waits for its next run time rather than being retried on every tick,
+			// and the error is only logged once.
+			if status.LastError != ErrHandlerNotFound.Error() {
+				status.Failures++
+				status.LastError = ErrHandlerNotFound.Error()
+				log.LogFields(log.Fields{
+					"job":     job.ID,
+					"handler": job.Handler,
+				}).Error(ErrHandlerNotFound)
+			}
+			job.NextRun = schedule.Next(now)
+			status.NextRun = job.NextRun
+			changed = true
+			continue
+		}
+		if !status.Running && len(s.slots) < cap(s.slots) && !s.claim(job) {
+			// Another server runs this one.
+			job.NextRun = schedule.Next(now)
+			status.NextRun = job.NextRun
+			changed = true
+			continue
+		}
+		if !s.dispatch(job) {
+			continue
+		}
+		job.NextRun = schedule.Next(now)
+		status.NextRun = job.NextRun
+		changed = true
+	}
+	if changed {
+		// Picks up the jobs other servers changed since, which would be overwritten otherwise.
+		if err := s.refresh(true); err != nil {
+			log.Logger.Warnf("Unable to load the jobs changed by other servers: %v", err)
+		}
+		if err := s.persist(); err != nil {
+			log.Logger.Errorf("Unable to persist scheduler jobs: %v", err)
+		}
+	}
+}
+
+// dispatch starts a run of the job if it is not already running and a slot is free.
+// Must be called with the mutex held.
+func (s *Scheduler) dispatch(job *Job) bool {
+	status := s.status[job.ID]
+	if status.Running {
+		log.LogFields(log.Fields{
+			"job":  job.ID,
+			"name": job.Name,
+		}).Warn("Skipping run, previous run is still in progress")
+		return false
+	}
+	handler, ok := s.handlers[job.Handler]
+	if !ok {
+		log.LogFields(log.Fields{
+			"job":     job.ID,
+			"handler": job.Handler,
+		}).Error(ErrHandlerNotFound)
+		return false
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	startedAt := time.Now()
+	status.Running = true
+	job.LastRun = startedAt
+	status.LastRun = startedAt
+	snapshot := *job
+
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.slots }()
+		err := runHandler(ctx, handler, snapshot)
+		duration := time.Since(startedAt)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		status.Running = false
+		status.Runs++
+		status.LastDuration = duration
+		status.LastError = ""
+		fields := log.Fields{
+			"job":      snapshot.ID,
+			"name":     snapshot.Name,
+			"duration": duration,
+		}
+		if err != nil {
+			status.Failures++
+			status.LastError = err.Error()
+			log.LogFields(fields).Errorf("Job failed: %v", err)
+			return
+		}
+		log.LogFields(fields).Info("Job completed")
+	}()
+	return true
+}
+
+// claim reports whether this server is the one to run the job at its next run time. Every
+// server sharing the cache backend sees the job due at the same time, and the first to claim
+// it runs it. Jobs are run regardless while the backend is unreachable. Must be called with
+// the mutex held.
+func (s *Scheduler) claim(job *Job) bool {
+	backend := cache.GetBackend()
+	if backend == nil {
+		return true
+	}
+	claimed, err := backend.Claim(fmt.Sprintf("scheduler:%v:%v", job.ID, job.NextRun.Unix()), claimTTL)
+	if err != nil {
+		log.LogFields(log.Fields{
+			"job":  job.ID,
+			"name": job.Name,
+		}).Warnf("Unable to claim the run of the job: %v", err)
+		return true
+	}
+	return claimed
+}
+
+// refresh reloads the jobs from the store when another server changed them, or always when
+// forced. Definitions come from the store, while run times are the latest known to either.
+// It does nothing without a shared cache backend, as this server is then the only one
+// changing jobs. Must be called with the mutex held.
+func (s *Scheduler) refresh(force bool) error {
+	if cache.GetBackend() == nil {
+		return nil
+	}
+	s.refreshedAt = time.Now()
+	revision, err := cache.Revision(revisionName)
+	if err != nil {
+		return err
+	}
+	if !force && revision == s.revision {
+		return nil
+	}
+	stored, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	jobs := map[string]*Job{}
+	schedules := map[string]cron.Schedule{}
+	for _, job := range stored {
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			continue
+		}
+		if local, ok := s.jobs[job.ID]; ok {
+			if local.LastRun.After(job.LastRun) {
+				job.LastRun = local.LastRun
+			}
+			if local.NextRun.After(job.NextRun) {
+				job.NextRun = local.NextRun
+			}
+		}
+		if job.NextRun.IsZero() {
+			job.NextRun = schedule.Next(time.Now())
+		}
+		jobs[job.ID] = job
+		schedules[job.ID] = schedule
+		status, ok := s.status[job.ID]
+		if !ok {
+			status = &JobStatus{ID: job.ID}
+			s.status[job.ID] = status
+		}
+		status.Name = job.Name
+		status.LastRun = job.LastRun
+		status.NextRun = job.NextRun
+	}
+	for id := range s.status {
+		if _, ok := jobs[id]; !ok {
+			delete(s.status, id)
+		}
+	}
+	s.jobs = jobs
+	s.schedules = schedules
+	s.revision = revision
+	return nil
+}
+
+// persistChange saves a change to the job definitions and tells the other servers about it.
+// Must be called with the mutex held.
+func (s *Scheduler) persistChange() error {
+	if err := s.persist(); err != nil {
+		return err
+	}
+	if err := cache.Touch(revisionName); err != nil {
+		return err
+	}
+	// This server already has the change.
+	s.revision++
+	return nil
+}
+
+func runHandler(ctx context.Context, handler Handler, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// persist must be called with the mutex held.
+func (s *Scheduler) persist() error {
+	jobs := []*Job{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+	return s.store.Save(jobs)
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists job definitions together with the last run time, which is what
+// allows misfires to be detected after a restart.
+type Store interface {
+	Load() ([]*Job, error)
+	Save(jobs []*Job) error
+}
+
+type fileStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) Load() ([]*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Job{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	jobs := []*Job{}
+	if err := json.Unmarshal(content, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *fileStore) Save(jobs []*Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+type memoryStore struct {
+	jobs []*Job
+}
+
+// NewMemoryStore keeps jobs for the lifetime of the process only.
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Load() ([]*Job, error) {
+	return s.jobs, nil
+}
+
+func (s *memoryStore) Save(jobs []*Job) error {
+	s.jobs = jobs
+	return nil
+}
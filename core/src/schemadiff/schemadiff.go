@@ -0,0 +1,256 @@
+package schemadiff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// snapshotVersion is bumped when the format of snapshots changes, so that older ones are
+// recognized.
+const snapshotVersion = 1
+
+const (
+	Change_Added   = "Added"
+	Change_Removed = "Removed"
+	Change_Changed = "Changed"
+)
+
+const (
+	Object_Table      = "Table"
+	Object_Column     = "Column"
+	Object_Index      = "Index"
+	Object_ForeignKey = "ForeignKey"
+)
+
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
+
+type ForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referencedSchema"`
+	ReferencedTable   string   `json:"referencedTable"`
+	ReferencedColumns []string `json:"referencedColumns"`
+}
+
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Indexes     []Index      `json:"indexes"`
+	ForeignKeys []ForeignKey `json:"foreignKeys"`
+}
+
+// Snapshot is the structure of a schema at a point in time, saved as JSON to be compared
+// against the schema later on.
+type Snapshot struct {
+	Version      int       `json:"version"`
+	DatabaseType string    `json:"databaseType"`
+	Schema       string    `json:"schema"`
+	TakenAt      time.Time `json:"takenAt"`
+	Tables       []Table   `json:"tables"`
+}
+
+// Change is an object added, removed or changed between two snapshots. Before and After
+// describe the object, and are empty where it does not exist.
+type Change struct {
+	Change string
+	Object string
+	Table  string
+	Name   string
+	Before string
+	After  string
+}
+
+// Take reads the tables of a schema with their columns, indexes and foreign keys. Indexes
+// are left out on databases that do not report them.
+func Take(plugin *engine.Plugin, config *engine.PluginConfig, schema string) (*Snapshot, error) {
+	storageUnits, err := plugin.GetStorageUnits(config, schema)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := plugin.GetIndexes(config, schema)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return nil, err
+	}
+	foreignKeys, err := plugin.GetForeignKeys(config, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version:      snapshotVersion,
+		DatabaseType: string(plugin.Type),
+		Schema:       schema,
+		TakenAt:      time.Now().UTC(),
+		Tables:       []Table{},
+	}
+	for _, storageUnit := range storageUnits {
+		constraints, err := plugin.GetColumnConstraints(config, schema, storageUnit.Name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the columns of %v: %w", storageUnit.Name, err)
+		}
+		table := Table{Name: storageUnit.Name, Columns: []Column{}, Indexes: []Index{}, ForeignKeys: []ForeignKey{}}
+		for _, constraint := range constraints {
+			table.Columns = append(table.Columns, Column{Name: constraint.Name, Type: constraint.Type, Nullable: constraint.Nullable})
+		}
+		for _, index := range indexes {
+			if index.Table == storageUnit.Name {
+				table.Indexes = append(table.Indexes, Index{Name: index.Name, Columns: index.Columns, Unique: index.Unique, Primary: index.Primary})
+			}
+		}
+		// Keys from other schemas to this one belong to their own schema's snapshot.
+		for _, foreignKey := range foreignKeys {
+			if foreignKey.Schema == schema && foreignKey.Table == storageUnit.Name {
+				table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+					Name:              foreignKey.Name,
+					Columns:           foreignKey.Columns,
+					ReferencedSchema:  foreignKey.ReferencedSchema,
+					ReferencedTable:   foreignKey.ReferencedTable,
+					ReferencedColumns: foreignKey.ReferencedColumns,
+				})
+			}
+		}
+		snapshot.Tables = append(snapshot.Tables, table)
+	}
+	return snapshot, nil
+}
+
+// Parse reads a snapshot saved as JSON.
+func Parse(content string) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal([]byte(content), snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %v", snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+// Diff lists what changed from before to after: tables first, then the columns, indexes and
+// foreign keys of the tables found in both, sorted by table and name. A table that was
+// added or removed is a single change. Foreign keys are matched by their columns and
+// references, as SQLite does not name them.
+func Diff(before *Snapshot, after *Snapshot) []Change {
+	changes := []Change{}
+	beforeTables := map[string]Table{}
+	for _, table := range before.Tables {
+		beforeTables[table.Name] = table
+	}
+	afterTables := map[string]Table{}
+	for _, table := range after.Tables {
+		afterTables[table.Name] = table
+	}
+
+	for _, name := range sortedKeys(beforeTables, afterTables) {
+		beforeTable, inBefore := beforeTables[name]
+		afterTable, inAfter := afterTables[name]
+		switch {
+		case !inAfter:
+			changes = append(changes, Change{Change: Change_Removed, Object: Object_Table, Table: name, Name: name, Before: describeTable(beforeTable)})
+		case !inBefore:
+			changes = append(changes, Change{Change: Change_Added, Object: Object_Table, Table: name, Name: name, After: describeTable(afterTable)})
+		default:
+			changes = append(changes, diffObjects(Object_Column, name, columnDescriptions(beforeTable), columnDescriptions(afterTable))...)
+			changes = append(changes, diffObjects(Object_Index, name, indexDescriptions(beforeTable), indexDescriptions(afterTable))...)
+			changes = append(changes, diffObjects(Object_ForeignKey, name, foreignKeyDescriptions(beforeTable), foreignKeyDescriptions(afterTable))...)
+		}
+	}
+	return changes
+}
+
+// diffObjects compares the objects of a table, given as descriptions by name.
+func diffObjects(object string, table string, before map[string]string, after map[string]string) []Change {
+	changes := []Change{}
+	for _, name := range sortedKeys(before, after) {
+		beforeDescription, inBefore := before[name]
+		afterDescription, inAfter := after[name]
+		switch {
+		case !inAfter:
+			changes = append(changes, Change{Change: Change_Removed, Object: object, Table: table, Name: name, Before: beforeDescription})
+		case !inBefore:
+			changes = append(changes, Change{Change: Change_Added, Object: object, Table: table, Name: name, After: afterDescription})
+		case beforeDescription != afterDescription:
+			changes = append(changes, Change{Change: Change_Changed, Object: object, Table: table, Name: name, Before: beforeDescription, After: afterDescription})
+		}
+	}
+	return changes
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func describeTable(table Table) string {
+	columns := []string{}
+	for _, column := range table.Columns {
+		columns = append(columns, fmt.Sprintf("%v %v", column.Name, describeColumn(column)))
+	}
+	return fmt.Sprintf("(%v)", strings.Join(columns, ", "))
+}
+
+func describeColumn(column Column) string {
+	if column.Nullable {
+		return column.Type
+	}
+	return column.Type + " NOT NULL"
+}
+
+func columnDescriptions(table Table) map[string]string {
+	descriptions := map[string]string{}
+	for _, column := range table.Columns {
+		descriptions[column.Name] = describeColumn(column)
+	}
+	return descriptions
+}
+
+func indexDescriptions(table Table) map[string]string {
+	descriptions := map[string]string{}
+	for _, index := range table.Indexes {
+		kind := "INDEX"
+		switch {
+		case index.Primary:
+			kind = "PRIMARY KEY"
+		case index.Unique:
+			kind = "UNIQUE"
+		}
+		descriptions[index.Name] = fmt.Sprintf("%v (%v)", kind, strings.Join(index.Columns, ", "))
+	}
+	return descriptions
+}
+
+func foreignKeyDescriptions(table Table) map[string]string {
+	descriptions := map[string]string{}
+	for _, foreignKey := range table.ForeignKeys {
+		description := fmt.Sprintf("(%v) REFERENCES %v.%v (%v)", strings.Join(foreignKey.Columns, ", "), foreignKey.ReferencedSchema, foreignKey.ReferencedTable, strings.Join(foreignKey.ReferencedColumns, ", "))
+		descriptions[description] = description
+	}
+	return descriptions
+}
@@ -0,0 +1,454 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+type Scope string
+
+const (
+	Scope_Global     Scope = "Global"
+	Scope_Connection Scope = "Connection"
+	Scope_User       Scope = "User"
+)
+
+type Type string
+
+const (
+	Type_String   Type = "String"
+	Type_Int      Type = "Int"
+	Type_Bool     Type = "Bool"
+	Type_Duration Type = "Duration"
+)
+
+const (
+	Key_PageSize     = "PageSize"
+	Key_MaxPageSize  = "MaxPageSize"
+	Key_QueryTimeout = "QueryTimeout"
+	Key_MaxRows      = "MaxRows"
+	Key_MaxResultMiB = "MaxResultMiB"
+	Key_MaxCellBytes = "MaxCellBytes"
+
+	Key_SlowQueryThreshold = "SlowQueryThreshold"
+
+	Key_RowCountEstimateThreshold = "RowCountEstimateThreshold"
+
+	Key_ResultCacheTTL = "ResultCacheTTL"
+
+	Key_NullDisplay      = "NullDisplay"
+	Key_MaxColumnWidth   = "MaxColumnWidth"
+	Key_ColumnOverflow   = "ColumnOverflow"
+	Key_NumericAlignment = "NumericAlignment"
+)
+
+const (
+	// refreshInterval is how often overrides changed by other servers are looked for.
+	refreshInterval = 10 * time.Second
+	// revisionName is the shared revision of the overrides.
+	revisionName = "settings"
+)
+
+var ErrUnknownSetting = errors.New("unknown setting")
+
+type Definition struct {
+	Key         string
+	Type        Type
+	Default     string
+	Description string
+	Scopes      []Scope
+	// Values lists the only values allowed, when the setting is a choice.
+	Values []string
+}
+
+// Value is a single override of a setting at a given scope. ScopeKey identifies the
+// connection or user the override applies to and is empty for global overrides.
+type Value struct {
+	Scope    Scope
+	ScopeKey string
+	Key      string
+	Value    string
+}
+
+// Setting is the effective value of a setting once all scopes have been resolved.
+type Setting struct {
+	Definition
+	Value string
+	Scope Scope
+}
+
+// Target describes who a setting is being resolved for; more specific scopes win.
+type Target struct {
+	Connection string
+	User       string
+}
+
+type valueKey struct {
+	scope    Scope
+	scopeKey string
+	key      string
+}
+
+var (
+	mutex       sync.RWMutex
+	definitions = map[string]Definition{}
+	values      = map[valueKey]string{}
+	store       = NewMemoryStore()
+	// revision and refreshedAt track the overrides shared with other servers.
+	revision    int64
+	refreshedAt time.Time
+)
+
+func init() {
+	Register(Definition{
+		Key:         Key_PageSize,
+		Type:        Type_Int,
+		Default:     "10",
+		Description: "Default number of rows shown per page when browsing a storage unit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+	})
+	Register(Definition{
+		Key:         Key_MaxPageSize,
+		Type:        Type_Int,
+		Default:     "10000",
+		Description: "Largest page size a client is allowed to request",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_QueryTimeout,
+		Type:        Type_Duration,
+		Default:     "0s",
+		Description: "How long a query may run before it is cancelled, 0s for no limit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_MaxRows,
+		Type:        Type_Int,
+		Default:     "0",
+		Description: "Most rows a single query returns, 0 for no limit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_MaxResultMiB,
+		Type:        Type_Int,
+		Default:     "256",
+		Description: "Most memory in MiB the rows of a single result may take before the query is aborted, 0 for no limit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_MaxCellBytes,
+		Type:        Type_Int,
+		Default:     "0",
+		Description: "Longest a value of a browsed table may be, in bytes, before it is cut short and fetched in full on demand, 0 for no limit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+	})
+	Register(Definition{
+		Key:         Key_SlowQueryThreshold,
+		Type:        Type_Duration,
+		Default:     "0s",
+		Description: "Queries running longer than this are logged and listed as slow, 0s to disable",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_RowCountEstimateThreshold,
+		Type:        Type_Int,
+		Default:     "1000000",
+		Description: "Row counts are estimated from catalog statistics instead of counted once a table is estimated to hold at least this many rows",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_ResultCacheTTL,
+		Type:        Type_Duration,
+		Default:     "0s",
+		Description: "How long the results of raw read queries are cached, 0s to disable the cache",
+		Scopes:      []Scope{Scope_Global, Scope_Connection},
+	})
+	Register(Definition{
+		Key:         Key_NullDisplay,
+		Type:        Type_String,
+		Default:     "empty",
+		Description: "How NULLs are shown in rendered results: empty, NULL or ∅",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+		Values:      []string{"empty", "NULL", "∅"},
+	})
+	Register(Definition{
+		Key:         Key_MaxColumnWidth,
+		Type:        Type_Int,
+		Default:     "0",
+		Description: "Widest a column of rendered results may be, in characters, 0 for no limit",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+	})
+	Register(Definition{
+		Key:         Key_ColumnOverflow,
+		Type:        Type_String,
+		Default:     "wrap",
+		Description: "Whether values wider than MaxColumnWidth wrap or are cut with an ellipsis",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+		Values:      []string{"wrap", "ellipsis"},
+	})
+	Register(Definition{
+		Key:         Key_NumericAlignment,
+		Type:        Type_String,
+		Default:     "right",
+		Description: "How numeric columns of rendered results are aligned",
+		Scopes:      []Scope{Scope_Global, Scope_Connection, Scope_User},
+		Values:      []string{"right", "left"},
+	})
+}
+
+func Register(definition Definition) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	definitions[definition.Key] = definition
+}
+
+// Initialize loads persisted overrides from the store, which is then used for every update.
+func Initialize(settingsStore Store) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	store = settingsStore
+	return load()
+}
+
+// load replaces the overrides with the ones in the store. Must be called with the mutex held.
+func load() error {
+	storeRevision, err := cache.Revision(revisionName)
+	if err != nil {
+		return err
+	}
+	loaded, err := store.Load()
+	if err != nil {
+		return err
+	}
+	values = map[valueKey]string{}
+	for _, value := range loaded {
+		values[valueKey{scope: value.Scope, scopeKey: value.ScopeKey, key: value.Key}] = value.Value
+	}
+	revision = storeRevision
+	refreshedAt = time.Now()
+	return nil
+}
+
+// refresh reloads the overrides once another server changed them, checking at most every
+// refreshInterval. Without a shared cache backend, this server is the only one changing them.
+func refresh() {
+	mutex.RLock()
+	stale := time.Since(refreshedAt) >= refreshInterval
+	mutex.RUnlock()
+	if !stale || cache.GetBackend() == nil {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if time.Since(refreshedAt) < refreshInterval {
+		return
+	}
+	refreshedAt = time.Now()
+	storeRevision, err := cache.Revision(revisionName)
+	if err == nil && storeRevision != revision {
+		err = load()
+	}
+	if err != nil {
+		log.Logger.Warnf("Unable to load the settings changed by other servers: %v", err)
+	}
+}
+
+func (t Target) keyFor(scope Scope) (string, bool) {
+	switch scope {
+	case Scope_Global:
+		return "", true
+	case Scope_Connection:
+		return t.Connection, len(t.Connection) > 0
+	case Scope_User:
+		return t.User, len(t.User) > 0
+	}
+	return "", false
+}
+
+func Get(target Target, key string) (Setting, error) {
+	refresh()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return resolve(target, key)
+}
+
+func resolve(target Target, key string) (Setting, error) {
+	definition, ok := definitions[key]
+	if !ok {
+		return Setting{}, fmt.Errorf("%w: %v", ErrUnknownSetting, key)
+	}
+	for _, scope := range []Scope{Scope_User, Scope_Connection, Scope_Global} {
+		scopeKeyValue, ok := target.keyFor(scope)
+		if !ok {
+			continue
+		}
+		if value, ok := values[valueKey{scope: scope, scopeKey: scopeKeyValue, key: key}]; ok {
+			return Setting{Definition: definition, Value: value, Scope: scope}, nil
+		}
+	}
+	return Setting{Definition: definition, Value: definition.Default, Scope: Scope_Global}, nil
+}
+
+func GetAll(target Target) []Setting {
+	refresh()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	all := []Setting{}
+	for key := range definitions {
+		setting, _ := resolve(target, key)
+		all = append(all, setting)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Key < all[j].Key
+	})
+	return all
+}
+
+func GetInt(target Target, key string) int {
+	setting, err := Get(target, key)
+	if err != nil {
+		return 0
+	}
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil {
+		value, _ = strconv.Atoi(setting.Default)
+	}
+	return value
+}
+
+func GetBool(target Target, key string) bool {
+	setting, err := Get(target, key)
+	if err != nil {
+		return false
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		value, _ = strconv.ParseBool(setting.Default)
+	}
+	return value
+}
+
+func GetDuration(target Target, key string) time.Duration {
+	setting, err := Get(target, key)
+	if err != nil {
+		return 0
+	}
+	value, err := time.ParseDuration(setting.Value)
+	if err != nil {
+		value, _ = time.ParseDuration(setting.Default)
+	}
+	return value
+}
+
+func GetString(target Target, key string) string {
+	setting, err := Get(target, key)
+	if err != nil {
+		return ""
+	}
+	return setting.Value
+}
+
+// Set overrides a setting for the scope of the target; an empty value removes the override.
+func Set(target Target, scope Scope, key string, value string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	definition, ok := definitions[key]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownSetting, key)
+	}
+	if !containsScope(definition.Scopes, scope) {
+		return fmt.Errorf("setting %v cannot be set at %v scope", key, scope)
+	}
+	scopeKeyValue, ok := target.keyFor(scope)
+	if !ok {
+		return fmt.Errorf("no %v to scope setting %v to", scope, key)
+	}
+	// Starts from the latest overrides, so that the ones other servers set are kept.
+	if cache.GetBackend() != nil {
+		if err := load(); err != nil {
+			return err
+		}
+	}
+	id := valueKey{scope: scope, scopeKey: scopeKeyValue, key: key}
+	if len(value) > 0 {
+		if err := validate(definition, value); err != nil {
+			return fmt.Errorf("invalid value for %v: %w", key, err)
+		}
+	}
+	updated := make(map[valueKey]string, len(values)+1)
+	for existing, existingValue := range values {
+		updated[existing] = existingValue
+	}
+	if len(value) == 0 {
+		delete(updated, id)
+	} else {
+		updated[id] = value
+	}
+	return persist(updated)
+}
+
+func validate(definition Definition, value string) error {
+	if len(definition.Values) > 0 {
+		for _, allowed := range definition.Values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", strings.Join(definition.Values, ", "))
+	}
+	var err error
+	switch definition.Type {
+	case Type_Int:
+		_, err = strconv.Atoi(value)
+	case Type_Bool:
+		_, err = strconv.ParseBool(value)
+	case Type_Duration:
+		_, err = time.ParseDuration(value)
+	}
+	return err
+}
+
+func containsScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// persist saves updated to the store and only then makes it the overrides in use, so that a
+// failed save changes nothing. Must be called with the mutex held.
+func persist(updated map[valueKey]string) error {
+	all := []Value{}
+	for id, value := range updated {
+		all = append(all, Value{Scope: id.scope, ScopeKey: id.scopeKey, Key: id.key, Value: value})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Key != all[j].Key {
+			return all[i].Key < all[j].Key
+		}
+		if all[i].Scope != all[j].Scope {
+			return all[i].Scope < all[j].Scope
+		}
+		return all[i].ScopeKey < all[j].ScopeKey
+	})
+	if err := store.Save(all); err != nil {
+		return err
+	}
+	values = updated
+	if err := cache.Touch(revisionName); err != nil {
+		return err
+	}
+	// This server already has the change.
+	revision++
+	return nil
+}
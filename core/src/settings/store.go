@@ -0,0 +1,68 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type Store interface {
+	Load() ([]Value, error)
+	Save(values []Value) error
+}
+
+type fileStore struct {
+	path string
+}
+
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) Load() ([]Value, error) {
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Value{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := []Value{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *fileStore) Save(values []Value) error {
+	content, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+type memoryStore struct {
+	values []Value
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Load() ([]Value, error) {
+	return s.values, nil
+}
+
+func (s *memoryStore) Save(values []Value) error {
+	s.values = values
+	return nil
+}
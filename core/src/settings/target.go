@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"fmt"
+
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+// TargetFor scopes settings to the connection described by the credentials and to the
+// user of the identity they are used with, or to the database user on that server when
+// users are not identified.
+func TargetFor(databaseType string, credentials *engine.Credentials) Target {
+	if credentials == nil {
+		return Target{}
+	}
+	target := Target{
+		Connection: fmt.Sprintf("%v://%v@%v/%v", databaseType, credentials.Username, credentials.Hostname, credentials.Database),
+		User:       fmt.Sprintf("%v://%v@%v", databaseType, credentials.Username, credentials.Hostname),
+	}
+	if len(credentials.Subject) > 0 {
+		target.User = credentials.Subject
+	}
+	// Tenants never share overrides or anything else keyed by connection, even on the same database.
+	if len(credentials.Tenant) > 0 {
+		target.Connection = fmt.Sprintf("%v/%v", credentials.Tenant, target.Connection)
+		target.User = fmt.Sprintf("%v/%v", credentials.Tenant, target.User)
+	}
+	return target
+}
+
+// PluginConfigFor builds the plugin config for a connection with its query defaults applied.
+func PluginConfigFor(databaseType string, credentials *engine.Credentials) *engine.PluginConfig {
+	target := TargetFor(databaseType, credentials)
+	config := engine.NewPluginConfig(credentials)
+	config.QueryTimeout = GetDuration(target, Key_QueryTimeout)
+	config.MaxRows = GetInt(target, Key_MaxRows)
+	config.MaxResultSize = int64(GetInt(target, Key_MaxResultMiB)) << 20
+	config.SlowQueryThreshold = GetDuration(target, Key_SlowQueryThreshold)
+	config.ResultCacheTTL = GetDuration(target, Key_ResultCacheTTL)
+	return config
+}
@@ -0,0 +1,136 @@
+package snippet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type Language string
+
+const (
+	Language_Curl   Language = "Curl"
+	Language_Go     Language = "Go"
+	Language_Python Language = "Python"
+)
+
+// The generated snippets read the server address and the auth token from these
+// environment variables so that no credentials end up in the snippet itself.
+const (
+	urlVariable   = "WHODB_URL"
+	tokenVariable = "WHODB_TOKEN"
+	apiPath       = "/api/query"
+)
+
+type request struct {
+	OperationName string          `json:"operationName,omitempty"`
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+}
+
+// Generate builds a snippet in the given language that replays the GraphQL operation against the API.
+func Generate(language Language, operation string, variables string) (string, error) {
+	document, err := parser.ParseQuery(&ast.Source{Input: operation})
+	if err != nil {
+		return "", fmt.Errorf("invalid operation: %v", err)
+	}
+	if len(document.Operations) != 1 {
+		return "", errors.New("exactly one operation is required")
+	}
+
+	body := request{
+		OperationName: document.Operations[0].Name,
+		Query:         strings.TrimSpace(operation),
+	}
+	if len(strings.TrimSpace(variables)) > 0 {
+		if !json.Valid([]byte(variables)) {
+			return "", errors.New("variables must be valid JSON")
+		}
+		body.Variables = json.RawMessage(variables)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	switch language {
+	case Language_Curl:
+		return curlSnippet(string(payload)), nil
+	case Language_Go:
+		return goSnippet(string(payload)), nil
+	case Language_Python:
+		return pythonSnippet(string(payload)), nil
+	}
+	return "", fmt.Errorf("unsupported snippet language: %v", language)
+}
+
+func curlSnippet(payload string) string {
+	quotedPayload := "'" + strings.ReplaceAll(payload, "'", `'\''`) + "'"
+	return fmt.Sprintf(`curl -X POST "$%v%v" \
+  -H "Content-Type: application/json" \
+  -H "Cookie: Token=$%v" \
+  --data %v
+`, urlVariable, apiPath, tokenVariable, quotedPayload)
+}
+
+func goSnippet(payload string) string {
+	quotedPayload := "`" + payload + "`"
+	if strings.Contains(payload, "`") {
+		quotedPayload = strconv.Quote(payload)
+	}
+	return fmt.Sprintf(`package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func main() {
+	payload := %v
+	req, err := http.NewRequest(http.MethodPost, os.Getenv(%q)+%q, strings.NewReader(payload))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "Token", Value: os.Getenv(%q)})
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(body))
+}
+`, quotedPayload, urlVariable, apiPath, tokenVariable)
+}
+
+func pythonSnippet(payload string) string {
+	return fmt.Sprintf(`import json
+import os
+
+import requests
+
+payload = json.loads(%v)
+response = requests.post(
+    os.environ[%q] + %q,
+    json=payload,
+    cookies={"Token": os.environ[%q]},
+)
+response.raise_for_status()
+print(response.json())
+`, strconv.Quote(payload), urlVariable, apiPath, tokenVariable)
+}
@@ -1,22 +1,174 @@
 package src
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/assertion"
+	"github.com/clidey/whodb/core/src/cache"
 	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/env"
+	"github.com/clidey/whodb/core/src/export"
+	"github.com/clidey/whodb/core/src/hook"
+	"github.com/clidey/whodb/core/src/lineage"
+	"github.com/clidey/whodb/core/src/log"
+	"github.com/clidey/whodb/core/src/metrics"
+	"github.com/clidey/whodb/core/src/openlineage"
+	"github.com/clidey/whodb/core/src/plugins/bigquery"
 	"github.com/clidey/whodb/core/src/plugins/mongodb"
 	"github.com/clidey/whodb/core/src/plugins/mysql"
+	"github.com/clidey/whodb/core/src/plugins/oracle"
 	"github.com/clidey/whodb/core/src/plugins/postgres"
 	"github.com/clidey/whodb/core/src/plugins/redis"
+	"github.com/clidey/whodb/core/src/plugins/snowflake"
 	"github.com/clidey/whodb/core/src/plugins/sqlite3"
+	"github.com/clidey/whodb/core/src/recyclebin"
+	"github.com/clidey/whodb/core/src/scheduledquery"
+	"github.com/clidey/whodb/core/src/scheduler"
+	"github.com/clidey/whodb/core/src/settings"
+	"github.com/clidey/whodb/core/src/tenant"
+	"github.com/clidey/whodb/core/src/version"
 )
 
+// resultCacheSize is how many query results the engine caches at most.
+const resultCacheSize = 200
+
 var MainEngine *engine.Engine
+var MainScheduler *scheduler.Scheduler
+var MainQueryRunner *scheduledquery.Runner
+var MainQuotas *tenant.Quotas
+var MainExports *export.Manager
+var MainRecycleBin *recyclebin.Bin
+var MainMetrics *metrics.Metrics
+var MainVersion *version.Service
+var MainLineage *openlineage.Emitter
+var MainAssertions *assertion.Assertions
+
+// InitializeCache connects to the shared cache backend, which the settings, the engine and
+// the scheduler all keep in step through, so it goes first.
+func InitializeCache() {
+	if len(env.CacheRedisURL) == 0 {
+		return
+	}
+	backend, err := cache.NewRedisBackend(env.CacheRedisURL)
+	if err != nil {
+		// Servers not sharing quota counters would each allow the full quota.
+		log.Logger.Fatalf("Unable to connect to the shared cache: %v", err)
+	}
+	cache.SetBackend(backend)
+	if len(env.SessionSecret) == 0 {
+		log.Logger.Warn("WHODB_SESSION_SECRET is not set, so users are signed out whenever their requests reach another server")
+	}
+}
 
 func InitializeEngine() *engine.Engine {
 	MainEngine = &engine.Engine{}
+	MainEngine.SetCircuitBreaker(engine.NewPanicCircuitBreaker(5, time.Minute))
+	MainQuotas = tenant.NewQuotas()
+	MainEngine.SetQuotaEnforcer(MainQuotas)
+	if env.MetricsEnabled {
+		MainMetrics = metrics.New()
+		MainEngine.SetMetrics(MainMetrics)
+	}
+	MainEngine.SetResultCache(engine.NewResultCache(resultCacheSize, func(query string) []string {
+		// Left nil when no table is found, so that the result is invalidated by any write.
+		var tables []string
+		for _, table := range lineage.ReferencedTables(query) {
+			tables = append(tables, strings.ToLower(table.Table))
+		}
+		return tables
+	}))
 	MainEngine.RegistryPlugin(postgres.NewPostgresPlugin())
 	MainEngine.RegistryPlugin(mysql.NewMySQLPlugin())
 	MainEngine.RegistryPlugin(sqlite3.NewSqlite3Plugin())
 	MainEngine.RegistryPlugin(mongodb.NewMongoDBPlugin())
 	MainEngine.RegistryPlugin(redis.NewRedisPlugin())
+	MainEngine.RegistryPlugin(snowflake.NewSnowflakePlugin())
+	MainEngine.RegistryPlugin(bigquery.NewBigQueryPlugin())
+	MainEngine.RegistryPlugin(oracle.NewOraclePlugin())
+
+	exportDirectory := env.ExportDirectory
+	if len(exportDirectory) == 0 {
+		exportDirectory = filepath.Join(os.TempDir(), "whodb-exports")
+	}
+	MainExports = export.NewManager(exportDirectory)
+
+	if len(env.HooksFile) > 0 {
+		hooks, err := hook.Load(env.HooksFile)
+		if err != nil {
+			// Running without the hooks could let through statements they are meant to block.
+			log.Logger.Fatalf("Unable to load hooks: %v", err)
+		}
+		MainEngine.AddQueryHooks(hooks)
+	}
+
+	if len(env.AssertionsFile) > 0 {
+		assertions, err := assertion.Load(env.AssertionsFile)
+		if err != nil {
+			log.Logger.Fatalf("Unable to load assertions: %v", err)
+		}
+		MainAssertions = assertions
+	}
+
+	if len(env.OpenLineageURL) > 0 {
+		namespace := env.OpenLineageNamespace
+		if len(namespace) == 0 {
+			namespace = "whodb"
+		}
+		// Added after the hooks, so that the statements reported are the ones that ran.
+		MainLineage = openlineage.New(env.OpenLineageURL, env.OpenLineageAPIKey, namespace)
+		MainEngine.AddQueryHooks(MainLineage)
+		MainExports.SetObserver(MainLineage.ExportFinished)
+	}
 	return MainEngine
 }
+
+func InitializeScheduler() *scheduler.Scheduler {
+	var store scheduler.Store
+	if len(env.SchedulerStorePath) > 0 {
+		store = scheduler.NewFileStore(env.SchedulerStorePath)
+	} else {
+		store = scheduler.NewMemoryStore()
+	}
+	MainScheduler = scheduler.New(store, 4)
+
+	var snapshotStore scheduledquery.SnapshotStore
+	if len(env.SnapshotStorePath) > 0 {
+		snapshotStore = scheduledquery.NewFileSnapshotStore(env.SnapshotStorePath)
+	} else {
+		snapshotStore = scheduledquery.NewMemorySnapshotStore()
+	}
+	MainQueryRunner = scheduledquery.Register(MainScheduler, MainEngine, snapshotStore)
+
+	if len(env.RecycleBinStorePath) > 0 {
+		MainRecycleBin = recyclebin.New(recyclebin.NewFileStore(env.RecycleBinStorePath))
+	} else {
+		MainRecycleBin = recyclebin.New(recyclebin.NewMemoryStore())
+	}
+
+	if err := MainScheduler.Start(); err != nil {
+		log.Logger.Errorf("Unable to start the scheduler: %v", err)
+	} else if err := scheduledquery.SealCredentials(MainScheduler); err != nil {
+		log.Logger.Errorf("Unable to seal the credentials of scheduled queries: %v", err)
+	}
+	return MainScheduler
+}
+
+func InitializeSettings() {
+	if len(env.SettingsStorePath) == 0 {
+		return
+	}
+	if err := settings.Initialize(settings.NewFileStore(env.SettingsStorePath)); err != nil {
+		log.Logger.Errorf("Unable to load settings: %v", err)
+	}
+}
+
+func InitializeVersion() {
+	MainVersion = version.New()
+	log.Logger.Infof("Starting WhoDB %v", version.Version)
+	if env.UpdateCheck {
+		MainVersion.StartUpdateChecks()
+	}
+}
@@ -0,0 +1,136 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+)
+
+type Type string
+
+const (
+	// Type_Text is a string literal, quoted and escaped.
+	Type_Text Type = "Text"
+	// Type_Number is a numeric literal.
+	Type_Number Type = "Number"
+	// Type_Boolean is TRUE or FALSE.
+	Type_Boolean Type = "Boolean"
+	// Type_Date is a date or timestamp in ISO 8601, written as a string literal.
+	Type_Date Type = "Date"
+	// Type_Identifier is a table or column name, always quoted.
+	Type_Identifier Type = "Identifier"
+	// Type_Raw is inserted as is, for fragments such as a list of values or a condition.
+	Type_Raw Type = "Raw"
+)
+
+var ErrMissingVariable = errors.New("no value given for variable")
+
+// Variable is the value of a {{name}} placeholder, rendered according to its type.
+type Variable struct {
+	Name  string
+	Type  Type
+	Value string
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var dateLayouts = []string{time.DateOnly, time.DateTime, "2006-01-02T15:04:05", time.RFC3339, time.RFC3339Nano}
+
+// Variables returns the names of the placeholders of query, in order of first appearance.
+func Variables(query string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, match := range placeholder.FindAllStringSubmatch(query, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Render replaces every placeholder of query with its variable, as a literal of the
+// variable's type written for databaseType. Placeholders stand for whole values, so they
+// must not be quoted in the query. Text is the default type.
+func Render(databaseType engine.DatabaseType, query string, variables []Variable) (string, error) {
+	values := map[string]string{}
+	for _, variable := range variables {
+		value, err := render(databaseType, variable)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for variable %v: %w", variable.Name, err)
+		}
+		values[variable.Name] = value
+	}
+	var missing []string
+	rendered := placeholder.ReplaceAllStringFunc(query, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			if !common.ContainsString(missing, name) {
+				missing = append(missing, name)
+			}
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %v", ErrMissingVariable, strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
+
+func render(databaseType engine.DatabaseType, variable Variable) (string, error) {
+	switch variable.Type {
+	case Type_Text, "":
+		return quoteString(databaseType, variable.Value), nil
+	case Type_Number:
+		value := strings.TrimSpace(variable.Value)
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", errors.New("not a number")
+		}
+		return value, nil
+	case Type_Boolean:
+		value, err := strconv.ParseBool(strings.TrimSpace(variable.Value))
+		if err != nil {
+			return "", errors.New("not a boolean")
+		}
+		if value {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case Type_Date:
+		value := strings.TrimSpace(variable.Value)
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return quoteString(databaseType, value), nil
+			}
+		}
+		return "", errors.New("not an ISO 8601 date")
+	case Type_Identifier:
+		return quoteIdentifier(databaseType, variable.Value), nil
+	case Type_Raw:
+		return variable.Value, nil
+	}
+	return "", fmt.Errorf("unsupported type %v", variable.Type)
+}
+
+func quoteString(databaseType engine.DatabaseType, value string) string {
+	// Backslashes escape in MySQL and BigQuery string literals.
+	if databaseType == engine.DatabaseType_MySQL || databaseType == engine.DatabaseType_BigQuery {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func quoteIdentifier(databaseType engine.DatabaseType, identifier string) string {
+	if databaseType == engine.DatabaseType_MySQL || databaseType == engine.DatabaseType_BigQuery {
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,227 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/cache"
+	"github.com/clidey/whodb/core/src/common"
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/clidey/whodb/core/src/log"
+)
+
+var ErrNoTenant = errors.New("you do not belong to any tenant")
+
+// Tenant isolates the connections, saved assets and usage of a team. Members are identified
+// by their roles, and connections are matched like in the auth policy.
+type Tenant struct {
+	Name        string   `json:"-"`
+	Roles       []string `json:"roles"`
+	Connections []string `json:"connections"`
+	// QueriesPerMinute and RowsPerDay are shared by all members; zero means no limit.
+	QueriesPerMinute int `json:"queriesPerMinute"`
+	RowsPerDay       int `json:"rowsPerDay"`
+}
+
+var (
+	mutex   sync.RWMutex
+	tenants = []Tenant{}
+)
+
+// Load reads the tenant definitions, a JSON object keyed by tenant name, from path.
+func Load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read tenants: %w", err)
+	}
+	definitions := map[string]Tenant{}
+	if err := json.Unmarshal(content, &definitions); err != nil {
+		return fmt.Errorf("invalid tenants: %w", err)
+	}
+	loaded := []Tenant{}
+	for name, tenant := range definitions {
+		tenant.Name = name
+		loaded = append(loaded, tenant)
+	}
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].Name < loaded[j].Name
+	})
+	mutex.Lock()
+	defer mutex.Unlock()
+	tenants = loaded
+	return nil
+}
+
+func IsEnabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(tenants) > 0
+}
+
+func Get(name string) (Tenant, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, tenant := range tenants {
+		if tenant.Name == name {
+			return tenant, true
+		}
+	}
+	return Tenant{}, false
+}
+
+// ForRoles returns the tenant of a user with the given roles. When several tenants match,
+// the first one by name wins so that the outcome does not depend on map ordering.
+func ForRoles(roles []string) (Tenant, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, tenant := range tenants {
+		for _, role := range tenant.Roles {
+			if role == "*" || common.ContainsString(roles, role) {
+				return tenant, nil
+			}
+		}
+	}
+	return Tenant{}, ErrNoTenant
+}
+
+// AllowsConnection reports whether the tenant may use the connection, given as
+// "<type>://<hostname>/<database>".
+func (t Tenant) AllowsConnection(connection string) bool {
+	return common.MatchesAnyPattern(t.Connections, connection)
+}
+
+type usage struct {
+	queries []time.Time
+	day     string
+	rows    int
+}
+
+// Quotas enforces the tenants' QueriesPerMinute and RowsPerDay. Usage is kept in memory,
+// so it starts over when the server restarts, unless a shared cache backend is set: usage
+// is then counted there, per calendar minute and day, across all servers.
+type Quotas struct {
+	mutex sync.Mutex
+	usage map[string]*usage
+	now   func() time.Time
+}
+
+func NewQuotas() *Quotas {
+	return &Quotas{
+		usage: map[string]*usage{},
+		now:   time.Now,
+	}
+}
+
+// usageFor must be called with the mutex held.
+func (q *Quotas) usageFor(tenant string) *usage {
+	now := q.now()
+	today := now.UTC().Format(time.DateOnly)
+	current, ok := q.usage[tenant]
+	if !ok {
+		current = &usage{day: today}
+		q.usage[tenant] = current
+	}
+	if current.day != today {
+		current.day = today
+		current.rows = 0
+	}
+	recent := []time.Time{}
+	for _, queriedAt := range current.queries {
+		if now.Sub(queriedAt) < time.Minute {
+			recent = append(recent, queriedAt)
+		}
+	}
+	current.queries = recent
+	return current
+}
+
+func (q *Quotas) AllowQuery(name string) error {
+	tenant, ok := Get(name)
+	if !ok {
+		return fmt.Errorf("%w: unknown tenant %v", engine.ErrQuotaExceeded, name)
+	}
+	if backend := cache.GetBackend(); backend != nil {
+		return q.allowSharedQuery(backend, tenant)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	current := q.usageFor(name)
+	if tenant.QueriesPerMinute > 0 && len(current.queries) >= tenant.QueriesPerMinute {
+		return fmt.Errorf("%w: %v queries per minute", engine.ErrQuotaExceeded, tenant.QueriesPerMinute)
+	}
+	if tenant.RowsPerDay > 0 && current.rows >= tenant.RowsPerDay {
+		return fmt.Errorf("%w: %v rows per day", engine.ErrQuotaExceeded, tenant.RowsPerDay)
+	}
+	current.queries = append(current.queries, q.now())
+	return nil
+}
+
+func (q *Quotas) RecordRows(name string, rows int) {
+	if backend := cache.GetBackend(); backend != nil {
+		if _, err := backend.Increment(q.rowsKey(name), int64(rows), sharedRowsTTL); err != nil {
+			log.Logger.Warnf("Unable to record the rows read by tenant %v: %v", name, err)
+		}
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.usageFor(name).rows += rows
+}
+
+// Usage returns how many queries the tenant ran in the last minute and rows it read today.
+func (q *Quotas) Usage(name string) (int, int) {
+	if backend := cache.GetBackend(); backend != nil {
+		queries, _ := backend.Increment(q.queriesKey(name), 0, sharedQueriesTTL)
+		rows, _ := backend.Increment(q.rowsKey(name), 0, sharedRowsTTL)
+		return int(queries), int(rows)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	current := q.usageFor(name)
+	return len(current.queries), current.rows
+}
+
+// Shared counters outlive their window a little, so that clocks slightly apart still agree.
+const (
+	sharedQueriesTTL = 2 * time.Minute
+	sharedRowsTTL    = 48 * time.Hour
+)
+
+// allowSharedQuery counts the query in the shared backend. Quotas are not enforced while the
+// backend is unreachable, so that an outage does not block every query.
+func (q *Quotas) allowSharedQuery(backend cache.Backend, tenant Tenant) error {
+	if tenant.RowsPerDay > 0 {
+		rows, err := backend.Increment(q.rowsKey(tenant.Name), 0, sharedRowsTTL)
+		if err != nil {
+			log.Logger.Warnf("Unable to check the quotas of tenant %v: %v", tenant.Name, err)
+			return nil
+		}
+		if rows >= int64(tenant.RowsPerDay) {
+			return fmt.Errorf("%w: %v rows per day", engine.ErrQuotaExceeded, tenant.RowsPerDay)
+		}
+	}
+	queries, err := backend.Increment(q.queriesKey(tenant.Name), 1, sharedQueriesTTL)
+	if err != nil {
+		log.Logger.Warnf("Unable to check the quotas of tenant %v: %v", tenant.Name, err)
+		return nil
+	}
+	if tenant.QueriesPerMinute > 0 && queries > int64(tenant.QueriesPerMinute) {
+		// Rejected queries do not count, as they do not run.
+		backend.Increment(q.queriesKey(tenant.Name), -1, sharedQueriesTTL)
+		return fmt.Errorf("%w: %v queries per minute", engine.ErrQuotaExceeded, tenant.QueriesPerMinute)
+	}
+	return nil
+}
+
+func (q *Quotas) queriesKey(tenant string) string {
+	return fmt.Sprintf("quota:%v:queries:%v", tenant, q.now().UTC().Format("2006-01-02T15:04"))
+}
+
+func (q *Quotas) rowsKey(tenant string) string {
+	return fmt.Sprintf("quota:%v:rows:%v", tenant, q.now().UTC().Format(time.DateOnly))
+}
@@ -0,0 +1,214 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clidey/whodb/core/src/engine"
+	"github.com/google/uuid"
+)
+
+// FieldError explains why the value given for a column would be rejected.
+type FieldError struct {
+	Column  string
+	Message string
+}
+
+// Error lists every invalid value of an edit, so they can all be fixed at once.
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	messages := []string{}
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%v %v", field.Column, field.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+type typeCheck struct {
+	types   []string
+	message string
+	valid   func(value string) bool
+}
+
+var timestampLayouts = []string{time.RFC3339, time.DateTime}
+
+var typeChecks = []typeCheck{
+	{
+		types:   []string{"integer", "int", "int2", "int4", "int8", "smallint", "bigint", "tinyint", "mediumint", "serial", "bigserial", "smallserial"},
+		message: "must be a whole number",
+		valid: func(value string) bool {
+			_, err := strconv.ParseInt(value, 10, 64)
+			return err == nil
+		},
+	},
+	{
+		types:   []string{"numeric", "decimal", "real", "double", "double precision", "float", "float4", "float8"},
+		message: "must be a number",
+		valid: func(value string) bool {
+			_, err := strconv.ParseFloat(value, 64)
+			return err == nil
+		},
+	},
+	{
+		types:   []string{"boolean", "bool", "bit"},
+		message: "must be true or false",
+		valid: func(value string) bool {
+			_, err := strconv.ParseBool(value)
+			return err == nil
+		},
+	},
+	{
+		types:   []string{"uuid"},
+		message: "must be a UUID",
+		valid: func(value string) bool {
+			_, err := uuid.Parse(value)
+			return err == nil
+		},
+	},
+	{
+		types:   []string{"date"},
+		message: "must be a date such as 2006-01-02",
+		valid: func(value string) bool {
+			_, err := time.Parse(time.DateOnly, value)
+			return err == nil
+		},
+	},
+	{
+		types:   []string{"timestamp", "timestamp with time zone", "timestamp without time zone", "timestamptz", "datetime"},
+		message: "must be a timestamp such as 2006-01-02T15:04:05Z",
+		valid: func(value string) bool {
+			for _, layout := range timestampLayouts {
+				if _, err := time.Parse(layout, value); err == nil {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		types:   []string{"json", "jsonb"},
+		message: "must be valid JSON",
+		valid: func(value string) bool {
+			return json.Valid([]byte(value))
+		},
+	},
+}
+
+// normalizeType lowercases a declared type and drops its size, e.g. VARCHAR(20) becomes varchar.
+func normalizeType(columnType string) string {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	if index := strings.Index(columnType, "("); index >= 0 {
+		columnType = strings.TrimSpace(columnType[:index])
+	}
+	return strings.TrimSuffix(columnType, " unsigned")
+}
+
+func findTypeCheck(columnType string) (typeCheck, bool) {
+	normalized := normalizeType(columnType)
+	for _, check := range typeChecks {
+		for _, checkType := range check.types {
+			if normalized == checkType {
+				return check, true
+			}
+		}
+	}
+	return typeCheck{}, false
+}
+
+// Validate checks the values of an edit against the column constraints. Values for columns
+// the constraints do not mention are reported too, since the database would reject them.
+func Validate(constraints []engine.ColumnConstraint, values map[string]string) error {
+	byName := map[string]engine.ColumnConstraint{}
+	for _, constraint := range constraints {
+		byName[constraint.Name] = constraint
+	}
+
+	fields := []FieldError{}
+	for column, value := range values {
+		constraint, ok := byName[column]
+		if !ok {
+			fields = append(fields, FieldError{Column: column, Message: "does not exist"})
+			continue
+		}
+		if message, ok := validateValue(constraint, value); !ok {
+			fields = append(fields, FieldError{Column: column, Message: message})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	sortFields(fields, constraints)
+	return &Error{Fields: fields}
+}
+
+func validateValue(constraint engine.ColumnConstraint, value string) (string, bool) {
+	check, typed := findTypeCheck(constraint.Type)
+	// Values are edited as text, so only non-text columns can tell an empty value apart.
+	if len(value) == 0 && typed {
+		if !constraint.Nullable {
+			return "is required", false
+		}
+		return "", true
+	}
+	if typed && !check.valid(value) {
+		return check.message, false
+	}
+	if constraint.MaxLength > 0 && utf8.RuneCountInString(value) > constraint.MaxLength {
+		return fmt.Sprintf("must be at most %v characters", constraint.MaxLength), false
+	}
+	if len(constraint.Values) > 0 && !containsValue(constraint.Values, value) {
+		return fmt.Sprintf("must be one of %v", strings.Join(constraint.Values, ", ")), false
+	}
+	return "", true
+}
+
+func containsValue(values []string, value string) bool {
+	for _, allowed := range values {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortFields orders errors like the columns of the table, with unknown columns last.
+func sortFields(fields []FieldError, constraints []engine.ColumnConstraint) {
+	position := map[string]int{}
+	for i, constraint := range constraints {
+		position[constraint.Name] = i
+	}
+	positionOf := func(column string) int {
+		if index, ok := position[column]; ok {
+			return index
+		}
+		return len(constraints)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if positionOf(fields[i].Column) != positionOf(fields[j].Column) {
+			return positionOf(fields[i].Column) < positionOf(fields[j].Column)
+		}
+		return fields[i].Column < fields[j].Column
+	})
+}
+
+// ValidateEdit fetches the constraints of the storage unit and validates the values against
+// them. Plugins that cannot describe their columns are left to the database to validate.
+func ValidateEdit(plugin *engine.Plugin, config *engine.PluginConfig, schema string, storageUnit string, values map[string]string) error {
+	constraints, err := plugin.GetColumnConstraints(config, schema, storageUnit)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return Validate(constraints, values)
+}
@@ -0,0 +1,197 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clidey/whodb/core/src/log"
+)
+
+// Set at build time, e.g. -ldflags "-X github.com/clidey/whodb/core/src/version.Version=v1.2.3".
+var (
+	Version = "dev"
+	Commit  = ""
+	// AssetsChecksum is the SHA-256 of the asset-manifest.json of the frontend built with the binary.
+	AssetsChecksum = ""
+)
+
+const (
+	releasesURL         = "https://api.github.com/repos/clidey/whodb/releases/latest"
+	updateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 10 * time.Second
+	assetManifestFile   = "asset-manifest.json"
+)
+
+// Info describes the running build and the latest release, when checked for.
+type Info struct {
+	Version         string
+	Commit          string
+	LatestVersion   string
+	UpdateAvailable bool
+	AssetsError     error
+}
+
+// Service keeps what is known about the running build: whether its frontend matches it, and
+// the latest release.
+type Service struct {
+	mutex         sync.RWMutex
+	assetsError   error
+	latestVersion string
+	client        *http.Client
+	releasesURL   string
+}
+
+func New() *Service {
+	return &Service{client: &http.Client{Timeout: updateCheckTimeout}, releasesURL: releasesURL}
+}
+
+// Info returns the version of the running build and how it compares to the latest release.
+func (s *Service) Info() Info {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return Info{
+		Version:         Version,
+		Commit:          Commit,
+		LatestVersion:   s.latestVersion,
+		UpdateAvailable: len(s.latestVersion) > 0 && IsNewer(s.latestVersion, Version),
+		AssetsError:     s.assetsError,
+	}
+}
+
+// VerifyAssets checks that the frontend in directory was built with this binary: its asset
+// manifest matches AssetsChecksum when set, and every file it lists is there. A missing
+// directory is not an error, as the frontend is served separately in development.
+func (s *Service) VerifyAssets(directory string) error {
+	err := checkAssets(directory)
+	s.mutex.Lock()
+	s.assetsError = err
+	s.mutex.Unlock()
+	return err
+}
+
+func checkAssets(directory string) error {
+	if _, err := os.Stat(directory); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(directory, assetManifestFile))
+	if err != nil {
+		return fmt.Errorf("unable to read the frontend asset manifest: %w", err)
+	}
+	if len(AssetsChecksum) > 0 {
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != AssetsChecksum {
+			return errors.New("the frontend was not built with this version of the server")
+		}
+	}
+	manifest := struct {
+		Files map[string]string `json:"files"`
+	}{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("invalid frontend asset manifest: %w", err)
+	}
+	missing := []string{}
+	for _, file := range manifest.Files {
+		if _, err := os.Stat(filepath.Join(directory, filepath.FromSlash(strings.TrimPrefix(file, "/")))); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("frontend assets are missing: %v", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// StartUpdateChecks looks for a newer release now and then once a day. Development builds
+// have no version to compare, so they are never checked.
+func (s *Service) StartUpdateChecks() {
+	if Version == "dev" {
+		log.Logger.Info("Skipping update checks for a development build")
+		return
+	}
+	go func() {
+		for {
+			s.checkForUpdate()
+			time.Sleep(updateCheckInterval)
+		}
+	}()
+}
+
+func (s *Service) checkForUpdate() {
+	request, err := http.NewRequest(http.MethodGet, s.releasesURL, nil)
+	if err != nil {
+		log.Logger.Warnf("Unable to check for updates: %v", err)
+		return
+	}
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("User-Agent", fmt.Sprintf("whodb/%v", Version))
+	response, err := s.client.Do(request)
+	if err != nil {
+		log.Logger.Warnf("Unable to check for updates: %v", err)
+		return
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		log.Logger.Warnf("Unable to check for updates: the release server answered %v", response.Status)
+		return
+	}
+	release := struct {
+		TagName string `json:"tag_name"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&release); err != nil {
+		log.Logger.Warnf("Unable to check for updates: %v", err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.latestVersion = release.TagName
+	s.mutex.Unlock()
+	if IsNewer(release.TagName, Version) {
+		log.Logger.Infof("WhoDB %v is available, this server runs %v", release.TagName, Version)
+	}
+}
+
+// IsNewer reports whether version latest is after current, comparing their major, minor and
+// patch numbers. Versions that cannot be parsed are never newer.
+func IsNewer(latest string, current string) bool {
+	latestParts, ok := parse(latest)
+	if !ok {
+		return false
+	}
+	currentParts, ok := parse(current)
+	if !ok {
+		return false
+	}
+	for i := range latestParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+	return false
+}
+
+func parse(version string) ([3]int, bool) {
+	parts := [3]int{}
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	fields := strings.Split(version, ".")
+	if len(fields) > len(parts) {
+		return parts, false
+	}
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = number
+	}
+	return parts, true
+}